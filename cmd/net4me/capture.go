@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/ai4networks/net4me/pkg/capture"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var captureCmd = &cobra.Command{
+	Use:    "capture <host> <port|link>",
+	Short:  "capture packets on a host port (or link to a peer host) as pcapng",
+	Args:   cobra.ExactArgs(2),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		host := findHost(args[0])
+		p, err := host.FindPort(args[1])
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to find port to capture")
+		}
+		c, err := capture.Open(host.NetworkNamespace(), p, capture.Options{
+			Filter:   viper.GetString("capture.filter"),
+			SnapLen:  viper.GetInt("capture.snaplen"),
+			Count:    viper.GetInt("capture.count"),
+			Duration: viper.GetDuration("capture.duration"),
+		})
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to open capture")
+		}
+		defer c.Close()
+
+		var sink capture.Sink
+		if path := viper.GetString("capture.write"); path == "" || path == "-" {
+			sink, err = capture.NewPcapngWriter(os.Stdout, c.InterfaceName(), c.SnapLen())
+		} else {
+			sink, err = capture.NewRingFile(path, c.InterfaceName(), c.SnapLen(), viper.GetInt64("capture.ring-size")*1000000, viper.GetInt("capture.ring-files"))
+		}
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to create capture output")
+		}
+		defer sink.Close()
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		logrus.WithField("host", host.Name()).WithField("port", c.InterfaceName()).Infoln("capturing packets")
+		count, err := c.Run(ctx, sink)
+		if err != nil {
+			logrus.WithError(err).Errorln("capture failed")
+		}
+		logrus.WithField("packets", count).Infoln("capture complete")
+	},
+}
+
+func init() {
+	captureCmd.Flags().StringP("write", "w", "-", "file to write pcapng to (- for stdout)")
+	viper.BindPFlag("capture.write", captureCmd.Flags().Lookup("write"))
+	captureCmd.Flags().StringP("filter", "f", "", "bpf filter expression or tcpdump -ddd program")
+	viper.BindPFlag("capture.filter", captureCmd.Flags().Lookup("filter"))
+	captureCmd.Flags().Int("snaplen", capture.DefaultSnapLen, "maximum bytes captured per packet")
+	viper.BindPFlag("capture.snaplen", captureCmd.Flags().Lookup("snaplen"))
+	captureCmd.Flags().IntP("count", "n", 0, "stop after capturing n packets (0 for no limit)")
+	viper.BindPFlag("capture.count", captureCmd.Flags().Lookup("count"))
+	captureCmd.Flags().Duration("duration", 0, "stop after the given duration (0 for no limit)")
+	viper.BindPFlag("capture.duration", captureCmd.Flags().Lookup("duration"))
+	captureCmd.Flags().Int64("ring-size", 0, "rotate the output file every n MB (0 to disable)")
+	viper.BindPFlag("capture.ring-size", captureCmd.Flags().Lookup("ring-size"))
+	captureCmd.Flags().Int("ring-files", 0, "number of rotated files to keep (0 to keep all)")
+	viper.BindPFlag("capture.ring-files", captureCmd.Flags().Lookup("ring-files"))
+	rootCmd.AddCommand(captureCmd)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"strings"
 
+	"github.com/ai4networks/net4me/pkg/node"
 	"github.com/spf13/viper"
 )
 
@@ -19,6 +20,9 @@ func init() {
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "enable verbose logging")
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 
+	rootCmd.PersistentFlags().String("topology", node.DefaultTopology, "name of the topology to operate on")
+	viper.BindPFlag("topology.name", rootCmd.PersistentFlags().Lookup("topology"))
+
 	viper.SetEnvKeyReplacer(strings.NewReplacer(`.`, `_`))
 	viper.AutomaticEnv()
 }
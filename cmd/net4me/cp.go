@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/ai4networks/net4me/pkg/transfer"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// remotePath splits a `host:path` argument, returning nil if the argument
+// does not name a host in the topology (and so is a local path).
+func remotePath(arg string) (*topology.Host, string) {
+	name, p, ok := strings.Cut(arg, ":")
+	if !ok {
+		return nil, ""
+	}
+	hosts := topology.Hosts(topology.FilterByName(name))
+	if len(hosts) == 0 {
+		return nil, ""
+	}
+	return hosts[0], p
+}
+
+var cpCmd = &cobra.Command{
+	Use:   "cp <src> <dst>",
+	Short: "copy files and directories between the local filesystem and a host",
+	Long: `Copy a file or directory into a directory on a host, or from a host into a
+local directory. The remote side is given as <host>:<path>, e.g.
+
+  net4me cp ./dataset site-1:/data
+  net4me cp --container worker site-1:/results ./results`,
+	Args:   cobra.ExactArgs(2),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		container := viper.GetString("cp.container")
+		srcHost, srcPath := remotePath(args[0])
+		dstHost, dstPath := remotePath(args[1])
+		switch {
+		case srcHost != nil && dstHost != nil:
+			logrus.Fatalln("copying directly between hosts is not supported")
+		case srcHost == nil && dstHost == nil:
+			logrus.Fatalln("either the source or destination must be a host path (<host>:<path>)")
+		case dstHost != nil:
+			fc, ok := dstHost.Node().(node.FileCopier)
+			if !ok {
+				logrus.WithField("host", dstHost.Name()).Fatalln("host does not support file transfer")
+			}
+			if err := transfer.CopyTo(fc, container, args[0], dstPath); err != nil {
+				logrus.WithError(err).Fatalln("failed to copy to host")
+			}
+			logrus.WithField("host", dstHost.Name()).WithField("path", dstPath).Infoln("copied to host")
+		default:
+			fc, ok := srcHost.Node().(node.FileCopier)
+			if !ok {
+				logrus.WithField("host", srcHost.Name()).Fatalln("host does not support file transfer")
+			}
+			if err := transfer.CopyFrom(fc, container, srcPath, args[1]); err != nil {
+				logrus.WithError(err).Fatalln("failed to copy from host")
+			}
+			logrus.WithField("host", srcHost.Name()).WithField("path", srcPath).Infoln("copied from host")
+		}
+	},
+}
+
+func init() {
+	cpCmd.Flags().StringP("container", "C", "", "container within the host to copy to/from (default the host itself)")
+	viper.BindPFlag("cp.container", cpCmd.Flags().Lookup("container"))
+	rootCmd.AddCommand(cpCmd)
+}
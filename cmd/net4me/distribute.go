@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/nodes/dind"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var distributeCmd = &cobra.Command{
+	Use:    "distribute <image>...",
+	Short:  "distribute images from the host engine to all sites matching a selector",
+	Args:   cobra.MinimumNArgs(1),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		manager, ok := node.Device("dind").(*dind.Manager)
+		if !ok {
+			logrus.Fatalln("dind device manager not found")
+		}
+		filters, err := topology.ParseSelector(viper.GetString("distribute.selector"))
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to parse site selector")
+		}
+		sites := make([]node.Node, 0)
+		for _, h := range topology.Hosts(append([]topology.HostFilter{topology.FilterByDevice("dind")}, filters...)...) {
+			sites = append(sites, h.Node())
+		}
+		if len(sites) == 0 {
+			logrus.Fatalln("no sites match the selector")
+		}
+		logrus.WithField("images", args).WithField("sites", len(sites)).Infoln("distributing images")
+		if err := manager.Distribute(args, sites, viper.GetInt("distribute.workers")); err != nil {
+			logrus.WithError(err).Fatalln("failed to distribute images")
+		}
+		logrus.WithField("sites", len(sites)).Infoln("distributed images")
+	},
+}
+
+func init() {
+	distributeCmd.Flags().StringP("selector", "s", "", "sites to distribute to, e.g. name=site-*,region=eu (default all sites)")
+	viper.BindPFlag("distribute.selector", distributeCmd.Flags().Lookup("selector"))
+	distributeCmd.Flags().Int("workers", 4, "number of sites loaded concurrently")
+	viper.BindPFlag("distribute.workers", distributeCmd.Flags().Lookup("workers"))
+	rootCmd.AddCommand(distributeCmd)
+}
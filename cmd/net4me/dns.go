@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/ai4networks/net4me/pkg/dns"
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/vishvananda/netlink"
+)
+
+var dnsCmd = &cobra.Command{
+	Use:    "dns <host>",
+	Short:  "serve topology name records from within the namespace of a host",
+	Args:   cobra.ExactArgs(1),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		host := findHost(args[0])
+		zone := dns.Zone(topology.Name())
+		server, err := dns.Serve(host.NetworkNamespace(), fmt.Sprintf(":%d", viper.GetInt("dns.port")), zone)
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to start dns server")
+		}
+		logrus.WithField("host", host.Name()).WithField("zone", server.Zone()).Infoln("serving dns records")
+
+		configured := make([]*topology.Host, 0)
+		if viper.GetBool("dns.configure") {
+			nameservers := make([]string, 0)
+			ports, err := host.Ports()
+			if err != nil {
+				logrus.WithError(err).Fatalln("failed to get dns host ports")
+			}
+			for _, p := range ports {
+				cidrs, err := port.PortAddresses(host.NetworkNamespace(), p, netlink.FAMILY_ALL)
+				if err != nil {
+					continue
+				}
+				for _, cidr := range cidrs {
+					if ip, _, err := net.ParseCIDR(cidr); err == nil && !ip.IsLinkLocalUnicast() {
+						nameservers = append(nameservers, ip.String())
+					}
+				}
+			}
+			if len(nameservers) == 0 {
+				logrus.WithField("host", host.Name()).Fatalln("dns host has no addresses to use as a nameserver")
+			}
+			for _, h := range topology.Hosts() {
+				resolvable, ok := h.Node().(node.Resolvable)
+				if !ok || h.ID() == host.ID() {
+					continue
+				}
+				if err := resolvable.SetResolvers(nameservers, []string{strings.TrimSuffix(zone, ".")}); err != nil {
+					logrus.WithError(err).WithField("host", h.Name()).Errorln("failed to configure host resolvers")
+					continue
+				}
+				configured = append(configured, h)
+				logrus.WithField("host", h.Name()).Infoln("configured host resolvers")
+			}
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		<-ctx.Done()
+		server.Close()
+		for _, h := range configured {
+			if err := h.Node().(node.Resolvable).RestoreResolvers(); err != nil {
+				logrus.WithError(err).WithField("host", h.Name()).Errorln("failed to restore host resolvers")
+				continue
+			}
+			logrus.WithField("host", h.Name()).Infoln("restored host resolvers")
+		}
+	},
+}
+
+func init() {
+	dnsCmd.Flags().Int("port", 53, "udp port to serve dns on")
+	viper.BindPFlag("dns.port", dnsCmd.Flags().Lookup("port"))
+	dnsCmd.Flags().Bool("configure", true, "configure the resolvers of all hosts to use the dns server")
+	viper.BindPFlag("dns.configure", dnsCmd.Flags().Lookup("configure"))
+	rootCmd.AddCommand(dnsCmd)
+}
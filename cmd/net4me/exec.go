@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/charmbracelet/x/term"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <host> [flags] -- <command>...",
+	Short: "run a command in a host, or a container within it",
+	Long: `Run a command in a host (e.g. a dind site) or in a container running on the
+host's inner engine, streaming its output. net4me exits with the exit code of
+the command. For an interactive shell:
+
+  net4me exec -it site-1 -- sh
+  net4me exec -it --container worker site-1 -- bash`,
+	Args:   cobra.MinimumNArgs(2),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		host := findHost(args[0])
+		execer, ok := host.Node().(node.Execer)
+		if !ok {
+			logrus.WithField("host", host.Name()).Fatalln("host does not support exec")
+		}
+		options := node.ExecOptions{
+			Container:  viper.GetString("exec.container"),
+			Cmd:        args[1:],
+			Env:        viper.GetStringSlice("exec.env"),
+			WorkingDir: viper.GetString("exec.workdir"),
+			User:       viper.GetString("exec.user"),
+			TTY:        viper.GetBool("exec.tty"),
+			Stdout:     os.Stdout,
+			Stderr:     os.Stderr,
+		}
+		if viper.GetBool("exec.interactive") {
+			options.Stdin = os.Stdin
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		restore := func() {}
+		if options.TTY && term.IsTerminal(os.Stdin.Fd()) {
+			state, err := term.MakeRaw(os.Stdin.Fd())
+			if err != nil {
+				logrus.WithError(err).Fatalln("failed to set terminal to raw mode")
+			}
+			restore = func() { term.Restore(os.Stdin.Fd(), state) }
+			resize := make(chan [2]uint, 1)
+			options.Resize = resize
+			sendSize := func() {
+				if w, h, err := term.GetSize(os.Stdout.Fd()); err == nil {
+					select {
+					case resize <- [2]uint{uint(w), uint(h)}:
+					default:
+					}
+				}
+			}
+			sendSize()
+			winch := make(chan os.Signal, 1)
+			signal.Notify(winch, syscall.SIGWINCH)
+			defer signal.Stop(winch)
+			go func() {
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-winch:
+						sendSize()
+					}
+				}
+			}()
+		}
+
+		code, err := execer.Exec(ctx, options)
+		cancel()
+		restore()
+		if err != nil {
+			logrus.WithError(err).Errorln("failed to execute command")
+			code = 126
+		}
+		os.Exit(code)
+	},
+}
+
+func init() {
+	execCmd.Flags().StringP("container", "C", "", "container within the host to run the command in (default the host itself)")
+	viper.BindPFlag("exec.container", execCmd.Flags().Lookup("container"))
+	execCmd.Flags().BoolP("interactive", "i", false, "attach stdin to the command")
+	viper.BindPFlag("exec.interactive", execCmd.Flags().Lookup("interactive"))
+	execCmd.Flags().BoolP("tty", "t", false, "allocate a pseudo-terminal")
+	viper.BindPFlag("exec.tty", execCmd.Flags().Lookup("tty"))
+	execCmd.Flags().StringSliceP("env", "e", nil, "environment variables to set (KEY=VALUE)")
+	viper.BindPFlag("exec.env", execCmd.Flags().Lookup("env"))
+	execCmd.Flags().StringP("workdir", "w", "", "working directory of the command")
+	viper.BindPFlag("exec.workdir", execCmd.Flags().Lookup("workdir"))
+	execCmd.Flags().StringP("user", "u", "", "user to run the command as")
+	viper.BindPFlag("exec.user", execCmd.Flags().Lookup("user"))
+	rootCmd.AddCommand(execCmd)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ai4networks/net4me/pkg/export"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var exportCmd = &cobra.Command{
+	Use:    "export",
+	Short:  "export the topology as a graph (dot, graphml or json)",
+	Args:   cobra.NoArgs,
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		filters, err := topology.ParseSelector(viper.GetString("export.selector"))
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to parse host selector")
+		}
+		g := export.Build(topology.Hosts(filters...))
+		w := os.Stdout
+		if output := viper.GetString("export.output"); output != "" && output != "-" {
+			if w, err = os.Create(output); err != nil {
+				logrus.WithError(err).Fatalln("failed to create output file")
+			}
+			defer w.Close()
+		}
+		if err := export.Write(w, g, viper.GetString("export.format")); err != nil {
+			logrus.WithError(err).Fatalln("failed to export topology")
+		}
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringP("format", "f", export.FormatDOT, "graph format (dot|graphml|json)")
+	viper.BindPFlag("export.format", exportCmd.Flags().Lookup("format"))
+	exportCmd.Flags().StringP("output", "o", "", "file to write the graph to (default stdout)")
+	viper.BindPFlag("export.output", exportCmd.Flags().Lookup("output"))
+	exportCmd.Flags().StringP("selector", "s", "", "hosts to export, e.g. device=dind,region=eu (default all hosts)")
+	viper.BindPFlag("export.selector", exportCmd.Flags().Lookup("selector"))
+	rootCmd.AddCommand(exportCmd)
+}
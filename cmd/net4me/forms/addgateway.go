@@ -0,0 +1,131 @@
+package forms
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+func AddGateway() {
+	locations := make([]*topology.Host, 0)
+	for _, h := range topology.Hosts() {
+		if h.Device() == "ovs" && h.Node().Running() {
+			locations = append(locations, h)
+		}
+	}
+	if len(locations) == 0 {
+		log.Info("no locations to add gateway to")
+		return
+	}
+	locationOptions := make([]huh.Option[string], 0, len(locations))
+	for _, l := range locations {
+		locationOptions = append(locationOptions, huh.NewOption(l.Name(), l.ID()))
+	}
+
+	gatewayName := ""
+	locationID := ""
+	addresses := ""
+	uplink := ""
+	nat := true
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Name for new gateway").
+				Placeholder("gw-1").
+				Prompt("> ").
+				CharLimit(12).
+				Validate(func(s string) error {
+					if s == "" {
+						return fmt.Errorf("name cannot be empty")
+					}
+					return nil
+				}).
+				Value(&gatewayName),
+			huh.NewSelect[string]().
+				Title("Select location for gateway").
+				Options(locationOptions...).
+				Value(&locationID),
+			huh.NewInput().
+				Title("Addresses (cidr, comma separated) for gateway in location").
+				Placeholder("10.0.0.254/24").
+				Prompt("> ").
+				Validate(func(s string) error {
+					if s == "" {
+						return fmt.Errorf("addresses cannot be empty")
+					}
+					for _, a := range splitList(s) {
+						if _, err := netip.ParsePrefix(a); err != nil {
+							return fmt.Errorf("invalid cidr prefix: %s", a)
+						}
+					}
+					return nil
+				}).
+				Value(&addresses),
+			huh.NewConfirm().
+				Title("Provide NAT egress to the host network?").
+				Affirmative("Yes").
+				Negative("No").
+				Value(&nat),
+			huh.NewInput().
+				Title("IPv4 uplink prefix (cidr, optional) between gateway and host").
+				Placeholder("allocated from 100.64.0.0/16").
+				Prompt("> ").
+				Validate(func(s string) error {
+					if s == "" {
+						return nil
+					}
+					if _, err := netip.ParsePrefix(s); err != nil {
+						return fmt.Errorf("invalid cidr prefix")
+					}
+					return nil
+				}).
+				Value(&uplink),
+		),
+	)
+	if err := form.Run(); err != nil {
+		log.Info("add gateway form was canceled")
+		return
+	}
+	config := map[string]interface{}{
+		"nat":    nat,
+		"uplink": uplink,
+	}
+	host, err := topology.NewHost("netns", gatewayName, make(map[string]string), config)
+	if err != nil {
+		log.Error("failed to create gateway", "error", err.Error())
+		return
+	} else {
+		log.Info("created gateway", "gateway", host.Name())
+	}
+
+	for _, l := range locations {
+		if l.ID() == locationID {
+			_, err := host.LinkWith(l, topology.LinkOptions{
+				Self: topology.LinkEndpoint{Addresses: splitList(addresses), Up: true},
+				Peer: topology.LinkEndpoint{Up: true},
+			})
+			if err != nil {
+				log.Error("failed to link gateway to location", "error", err.Error())
+				if err := host.Remove(); err != nil {
+					log.Error("failed to remove gateway", "error", err.Error())
+				}
+				return
+			} else {
+				log.Info("linked gateway to location", "gateway", host.Name(), "location", l.Name())
+			}
+		}
+	}
+
+	if err := host.Start(); err != nil {
+		log.Error("failed to start gateway", "error", err.Error())
+	} else {
+		log.Info("started gateway", "gateway", host.Name())
+	}
+}
+
+func init() {
+	addForm("Add Gateway", AddGateway)
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"net/netip"
 
-	"github.com/ai4networks/net4me/pkg/port"
 	"github.com/ai4networks/net4me/pkg/topology"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/log"
@@ -32,8 +31,11 @@ func AddSite() {
 
 	siteName := ""
 	locationID := ""
-	address := ""
+	addresses := ""
+	gateways := ""
+	ipv6 := "static"
 	gpu := false
+	isolated := true
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
@@ -53,25 +55,52 @@ func AddSite() {
 				Options(locationOptions...).
 				Value(&locationID),
 			huh.NewInput().
-				Title("IPv4 address (cidr) for site").
-				Placeholder("10.0.0..../24").
+				Title("IPv4 and/or IPv6 addresses (cidr, comma separated) for site").
+				Placeholder("10.0.0.1/24,fd00::1/64").
 				Prompt("> ").
-				CharLimit(18).
 				Validate(func(s string) error {
 					if s == "" {
-						return fmt.Errorf("ipv4 cannot be empty")
+						return fmt.Errorf("addresses cannot be empty")
 					}
-					if _, err := netip.ParsePrefix(s); err != nil {
-						return fmt.Errorf("invalid cidr ipv4 prefix")
+					for _, a := range splitList(s) {
+						if _, err := netip.ParsePrefix(a); err != nil {
+							return fmt.Errorf("invalid cidr prefix: %s", a)
+						}
 					}
 					return nil
 				}).
-				Value(&address),
+				Value(&addresses),
+			huh.NewInput().
+				Title("Default gateways (comma separated, optional) for site").
+				Placeholder("10.0.0.254,fd00::fe").
+				Prompt("> ").
+				Validate(func(s string) error {
+					for _, g := range splitList(s) {
+						if _, err := netip.ParseAddr(g); err != nil {
+							return fmt.Errorf("invalid gateway address: %s", g)
+						}
+					}
+					return nil
+				}).
+				Value(&gateways),
+			huh.NewSelect[string]().
+				Title("IPv6 on site link").
+				Options(
+					huh.NewOption("Static addresses only", "static"),
+					huh.NewOption("Autoconfigure from router advertisements", "ra"),
+					huh.NewOption("Disabled (IPv4 only)", "disabled"),
+				).
+				Value(&ipv6),
 			huh.NewConfirm().
 				Title("Attach GPU to site?").
 				Affirmative("Yes").
 				Negative("No").
 				Value(&gpu),
+			huh.NewConfirm().
+				Title("Isolate site to emulated links only?").
+				Affirmative("Yes").
+				Negative("No").
+				Value(&isolated),
 		),
 	)
 	if err := form.Run(); err != nil {
@@ -79,7 +108,8 @@ func AddSite() {
 		return
 	}
 	config := map[string]interface{}{
-		"gpu": gpu,
+		"gpu":      gpu,
+		"isolated": isolated,
 	}
 	host, err := topology.NewHost("dind", siteName, make(map[string]string), config)
 	if err != nil {
@@ -91,8 +121,18 @@ func AddSite() {
 
 	for _, l := range locations {
 		if l.ID() == locationID {
-			lk, err := host.Link(l)
-			if err != nil {
+			if _, err := host.LinkWith(l, topology.LinkOptions{
+				Self: topology.LinkEndpoint{
+					Addresses: splitList(addresses),
+					Gateways:  splitList(gateways),
+					IPv6: &topology.IPv6Options{
+						Disabled: ipv6 == "disabled",
+						AcceptRA: ipv6 == "ra",
+					},
+					Up: true,
+				},
+				Peer: topology.LinkEndpoint{Up: true},
+			}); err != nil {
 				log.Error("failed to link site to location", "error", err.Error())
 				if err := host.Remove(); err != nil {
 					log.Error("failed to remove site", "error", err.Error())
@@ -101,15 +141,6 @@ func AddSite() {
 			} else {
 				log.Info("linked site to location", "site", host.Name(), "location", l.Name())
 			}
-			if err := port.PortAddAddress(lk.SelfHost().NetworkNamespace(), lk.SelfPort(), address); err != nil {
-				log.Error("failed to add address to site", "error", err.Error())
-			}
-			if err := port.PortSetUp(lk.SelfHost().NetworkNamespace(), lk.SelfPort()); err != nil {
-				log.Error("failed to set up port on site", "error", err.Error())
-			}
-			if err := port.PortSetUp(lk.PeerHost().NetworkNamespace(), lk.PeerPort()); err != nil {
-				log.Error("failed to set up port on location", "error", err.Error())
-			}
 		}
 	}
 
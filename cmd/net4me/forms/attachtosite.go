@@ -1,29 +1,29 @@
 package forms
 
 import (
+	"context"
 	"fmt"
+	"os"
 
+	"github.com/ai4networks/net4me/pkg/node"
 	"github.com/ai4networks/net4me/pkg/topology"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/log"
-	"github.com/docker/docker/client"
-	"github.com/neaas/nescript"
-	"github.com/neaas/nescript/docker"
 )
 
 func SiteExec() {
-	var siteID string
+	var siteID, container string
 	currentHosts := topology.Hosts()
-	if len(currentHosts) == 0 {
-		log.Info("no sites to attach to")
-		return
-	}
 	options := make([]huh.Option[string], 0, len(currentHosts))
 	for _, h := range currentHosts {
-		if h.Device() == "dind" {
+		if _, ok := h.Node().(node.Execer); ok {
 			options = append(options, huh.NewOption(h.Name(), h.ID()))
 		}
 	}
+	if len(options) == 0 {
+		log.Info("no sites to attach to")
+		return
+	}
 	command := "docker image ls"
 	form := huh.NewForm(
 		huh.NewGroup(
@@ -31,6 +31,10 @@ func SiteExec() {
 				Title("Select Site").
 				Options(options...).
 				Value(&siteID),
+			huh.NewInput().
+				Title("Container").
+				Description("Container within the site (leave empty for the site itself)").
+				Value(&container),
 			huh.NewInput().
 				Title("Command").
 				Placeholder("docker image ls").
@@ -47,25 +51,23 @@ func SiteExec() {
 		log.Info("site selection form was canceled")
 		return
 	}
-	localDocker, err := client.NewClientWithOpts(client.FromEnv)
+	hosts := topology.Hosts(topology.FilterByID(siteID))
+	if len(hosts) == 0 {
+		log.Error("site no longer exists")
+		return
+	}
+	code, err := hosts[0].Node().(node.Execer).Exec(context.Background(), node.ExecOptions{
+		Container: container,
+		Cmd:       []string{"sh", "-c", command},
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	})
 	if err != nil {
-		log.Error("failed to connect to local docker engine", "error", err.Error())
+		log.Error("failed to execute command", "error", err.Error())
+		return
 	}
-	for _, h := range currentHosts {
-		if h.ID() == siteID {
-			script := nescript.NewScript(command)
-			process, err := script.Cmd().Exec(docker.Executor(localDocker, h.NodeID(), ""))
-			if err != nil {
-				log.Error("failed to execute command", "error", err.Error())
-				return
-			}
-			result, err := process.Result()
-			if err != nil {
-				log.Error("failed to get result from command", "error", err.Error())
-				return
-			}
-			fmt.Printf("%s\n", result.StdOut)
-		}
+	if code != 0 {
+		log.Warn("command exited with non-zero code", "code", code)
 	}
 }
 
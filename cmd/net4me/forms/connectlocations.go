@@ -104,37 +104,24 @@ func ConnectLocations() {
 		log.Error("failed to find locations")
 		return
 	}
-	link, err := src.Link(dst)
-	if err != nil {
-		log.Error("failed to connect locations", "error", err.Error())
-		return
-	}
-	if err := port.PortSetUp(link.SelfHost().NetworkNamespace(), link.SelfPort()); err != nil {
-		log.Error("failed to set up port", "error", err.Error())
-		return
+	options := topology.LinkOptions{
+		Self: topology.LinkEndpoint{Up: true},
+		Peer: topology.LinkEndpoint{Up: true},
 	}
-	if err := port.PortSetUp(link.PeerHost().NetworkNamespace(), link.PeerPort()); err != nil {
-		log.Error("failed to set up port", "error", err.Error())
-		return
-	}
-	log.Info("connected locations", "src", src.Name(), "dst", dst.Name())
 	latency, errL := strconv.ParseInt(latencyS, 10, 0)
 	jitter, errJ := strconv.ParseInt(jitterS, 10, 0)
 	loss, errP := strconv.ParseFloat(lossS, 32)
 	if errL != nil || errJ != nil || errP != nil {
 		log.Info("skipping emulation setup")
-		return
-	}
-	if err := port.PortSetEmulation(link.SelfHost().NetworkNamespace(), link.SelfPort(), uint32(latency), uint32(jitter), float32(loss)); err != nil {
-		log.Error("failed to set emulation", "error", err.Error())
-		return
-
+	} else {
+		emulation := &port.Emulation{Latency: uint32(latency), Jitter: uint32(jitter), Loss: float32(loss)}
+		options.Self.Emulation, options.Peer.Emulation = emulation, emulation
 	}
-	if err := port.PortSetEmulation(link.PeerHost().NetworkNamespace(), link.PeerPort(), uint32(latency), uint32(jitter), float32(loss)); err != nil {
-		log.Error("failed to set emulation", "error", err.Error())
+	if _, err := src.LinkWith(dst, options); err != nil {
+		log.Error("failed to connect locations", "error", err.Error())
 		return
 	}
-
+	log.Info("connected locations", "src", src.Name(), "dst", dst.Name())
 }
 
 func init() {
@@ -1,48 +1,94 @@
 package forms
 
-func FileToSite() {
+import (
+	"fmt"
+	"path"
 
-	// localDocker, err := client.NewClientWithOpts(client.FromEnv)
-	// if err != nil {
-	// 	log.Error("failed to connect to local docker engine", "error", err.Error())
-	// }
-	// localDocker.Container
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/ai4networks/net4me/pkg/transfer"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
 
-	// var removeHostNames []string
-	// currentHosts := topology.Hosts()
-	// if len(currentHosts) == 0 {
-	// 	log.Info("no hosts to remove")
-	// 	return
-	// }
-	// options := make([]huh.Option[string], 0, len(currentHosts))
-	// for _, h := range currentHosts {
-	// 	options = append(options, huh.NewOption(h.Name(), h.ID()))
-	// }
-	// form := huh.NewForm(
-	// 	huh.NewGroup(
-	// 		huh.NewMultiSelect[string]().
-	// 			Title("Select Hosts to Remove").
-	// 			Options(options...).
-	// 			Value(&removeHostNames),
-	// 	),
-	// )
-	// if err := form.Run(); err != nil {
-	// 	log.Info("host selection form was canceled")
-	// 	return
-	// }
-	// for _, h := range currentHosts {
-	// 	for _, n := range removeHostNames {
-	// 		if h.ID() == n {
-	// 			if err := h.Remove(); err != nil {
-	// 				log.Error("failed to remove host", "error", err.Error(), "host", h.Name())
-	// 			} else {
-	// 				log.Info("removed host", "host", h.Name())
-	// 			}
-	// 		}
-	// 	}
-	// }
+func FileToSite() {
+	currentHosts := topology.Hosts()
+	options := make([]huh.Option[string], 0, len(currentHosts))
+	for _, h := range currentHosts {
+		if _, ok := h.Node().(node.FileCopier); ok {
+			options = append(options, huh.NewOption(h.Name(), h.ID()))
+		}
+	}
+	if len(options) == 0 {
+		log.Info("no sites support file transfer")
+		return
+	}
+	var siteID, container, localPath, sitePath string
+	toSite := true
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Select Site").
+				Options(options...).
+				Value(&siteID),
+			huh.NewSelect[bool]().
+				Title("Direction").
+				Options(
+					huh.NewOption("Copy local files to site", true),
+					huh.NewOption("Copy site files to local", false),
+				).
+				Value(&toSite),
+			huh.NewInput().
+				Title("Container").
+				Description("Container within the site (leave empty for the site itself)").
+				Value(&container),
+			huh.NewInput().
+				Title("Local path").
+				Description("File or directory to send, or directory to receive into").
+				Validate(func(s string) error {
+					if s == "" {
+						return fmt.Errorf("local path cannot be empty")
+					}
+					return nil
+				}).
+				Value(&localPath),
+			huh.NewInput().
+				Title("Site path").
+				Description("Directory to receive into, or file or directory to fetch").
+				Validate(func(s string) error {
+					if !path.IsAbs(s) {
+						return fmt.Errorf("site path must be absolute")
+					}
+					return nil
+				}).
+				Value(&sitePath),
+		),
+	)
+	if err := form.Run(); err != nil {
+		log.Info("file transfer form was canceled")
+		return
+	}
+	hosts := topology.Hosts(topology.FilterByID(siteID))
+	if len(hosts) == 0 {
+		log.Error("site no longer exists")
+		return
+	}
+	fc := hosts[0].Node().(node.FileCopier)
+	if toSite {
+		if err := transfer.CopyTo(fc, container, localPath, sitePath); err != nil {
+			log.Error("failed to copy to site", "error", err.Error())
+			return
+		}
+		log.Info("copied to site", "site", hosts[0].Name(), "path", sitePath)
+		return
+	}
+	if err := transfer.CopyFrom(fc, container, sitePath, localPath); err != nil {
+		log.Error("failed to copy from site", "error", err.Error())
+		return
+	}
+	log.Info("copied from site", "site", hosts[0].Name(), "path", localPath)
 }
 
 func init() {
-	addForm("Add file to site", FileToSite)
+	addForm("Transfer files to/from site", FileToSite)
 }
@@ -1,5 +1,7 @@
 package forms
 
+import "strings"
+
 var forms = map[string]func(){}
 
 func addForm(name string, f func()) {
@@ -13,3 +15,15 @@ func Forms() map[string]func() {
 func Form(name string) func() {
 	return forms[name]
 }
+
+// splitList splits a comma separated list of values, trimming any whitespace
+// and dropping empty values.
+func splitList(s string) []string {
+	values := make([]string, 0)
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
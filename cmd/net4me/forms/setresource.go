@@ -1,30 +1,45 @@
 package forms
 
 import (
-	"context"
+	"fmt"
 	"strconv"
 
+	"github.com/ai4networks/net4me/pkg/nodes/dind"
 	"github.com/ai4networks/net4me/pkg/topology"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/log"
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
+	"github.com/docker/go-units"
 )
 
+// optional wraps a validator so that empty values are accepted.
+func optional(validate func(string) error) func(string) error {
+	return func(s string) error {
+		if s == "" {
+			return nil
+		}
+		return validate(s)
+	}
+}
+
 func SetSiteResources() {
 	var targetHost string
-	var cpusS string
-	currentHosts := topology.Hosts()
+	var cpusS, cpuset, memory, memorySwap, pidsS, blkioS string
+	currentHosts := topology.Hosts(topology.FilterByDevice("dind"))
 	if len(currentHosts) == 0 {
-		log.Info("no hosts to remove")
+		log.Info("no sites to update")
 		return
 	}
 	options := make([]huh.Option[string], 0, len(currentHosts))
 	for _, h := range currentHosts {
-		if h.Device() == "dind" {
-			options = append(options, huh.NewOption(h.Name(), h.ID()))
-		}
+		options = append(options, huh.NewOption(h.Name(), h.ID()))
 	}
+	memoryValidator := optional(func(s string) error {
+		if s == "-1" {
+			return nil
+		}
+		_, err := units.RAMInBytes(s)
+		return err
+	})
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewSelect[string]().
@@ -33,34 +48,87 @@ func SetSiteResources() {
 				Value(&targetHost),
 			huh.NewInput().
 				Title("CPUs").
-				Placeholder("1").
+				Description("Leave any limit empty to keep its current value").
+				Placeholder("1.5").
+				Prompt("> ").
+				Validate(optional(func(s string) error {
+					_, err := strconv.ParseFloat(s, 64)
+					return err
+				})).
+				Value(&cpusS),
+			huh.NewInput().
+				Title("CPU Set").
+				Placeholder("0-3").
+				Prompt("> ").
+				Value(&cpuset),
+			huh.NewInput().
+				Title("Memory").
+				Placeholder("2g").
+				Prompt("> ").
+				Validate(memoryValidator).
+				Value(&memory),
+			huh.NewInput().
+				Title("Memory + Swap").
+				Placeholder("4g (-1 for unlimited swap)").
+				Prompt("> ").
+				Validate(memoryValidator).
+				Value(&memorySwap),
+			huh.NewInput().
+				Title("PIDs Limit").
+				Placeholder("1024").
 				Prompt("> ").
-				Validate(func(s string) error {
-					if _, err := strconv.ParseFloat(s, 64); err != nil {
+				Validate(optional(func(s string) error {
+					_, err := strconv.ParseInt(s, 10, 64)
+					return err
+				})).
+				Value(&pidsS),
+			huh.NewInput().
+				Title("Block IO Weight").
+				Placeholder("500").
+				Prompt("> ").
+				Validate(optional(func(s string) error {
+					w, err := strconv.ParseUint(s, 10, 16)
+					if err != nil {
 						return err
 					}
+					if w < 10 || w > 1000 {
+						return fmt.Errorf("weight must be between 10 and 1000")
+					}
 					return nil
-				}).
-				Value(&cpusS),
+				})).
+				Value(&blkioS),
 		),
 	)
 	if err := form.Run(); err != nil {
 		log.Info("host selection form was canceled")
 		return
 	}
-	cpus, _ := strconv.ParseFloat(cpusS, 64)
-	localDocker, err := client.NewClientWithOpts(client.FromEnv)
-	if err != nil {
-		log.Error("failed to connect to local docker engine", "error", err.Error())
+	resources := dind.Resources{
+		CPUSetCPUs: cpuset,
+		Memory:     memory,
+		MemorySwap: memorySwap,
 	}
-	for _, h := range currentHosts {
-		if h.ID() == targetHost {
-			localDocker.ContainerUpdate(context.Background(), h.NodeID(), container.UpdateConfig{
-				Resources: container.Resources{
-					CPUQuota: int64(cpus * 100000),
-				},
-			})
+	if cpusS != "" {
+		resources.CPUs, _ = strconv.ParseFloat(cpusS, 64)
+	}
+	if pidsS != "" {
+		resources.PidsLimit, _ = strconv.ParseInt(pidsS, 10, 64)
+	}
+	if blkioS != "" {
+		w, _ := strconv.ParseUint(blkioS, 10, 16)
+		resources.BlkioWeight = uint16(w)
+	}
+	for _, h := range topology.Hosts(topology.FilterByID(targetHost)) {
+		n, ok := h.Node().(*dind.Node)
+		if !ok {
+			log.Error("host is not a dind site", "host", h.Name())
+			return
+		}
+		if err := n.SetResources(resources); err != nil {
+			log.Error("failed to set site resources", "host", h.Name(), "error", err.Error())
+			return
 		}
+		log.Info("updated site resources", "host", h.Name())
 	}
 }
 
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ai4networks/net4me/pkg/gc"
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/state"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "find resources left behind by interrupted commands, and optionally remove them",
+	Long: `gc finds the resources of the topology that are not in use, such as link
+ports left in the port pool, site containers that were never started and ports
+of bridges whose device is gone. With --all, resources shared by topologies are
+included, such as volume copy helpers, incomplete snapshots and unused port
+pools. Orphans are only reported, unless --clean is given.
+
+Ports are only in the pool while links are being created, so gc should not be
+run while other net4me commands are changing the topology.`,
+	Args:   cobra.NoArgs,
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		options := gc.Options{
+			GCOptions: node.GCOptions{
+				All:     viper.GetBool("gc.all"),
+				Volumes: viper.GetBool("gc.volumes"),
+			},
+		}
+		if known := viper.GetString("gc.known"); known != "" {
+			f, err := os.Open(known)
+			if err != nil {
+				logrus.WithError(err).Fatalln("failed to open known topology document")
+			}
+			doc, err := state.Decode(f, documentFormat("gc.format", known))
+			f.Close()
+			if err != nil {
+				logrus.WithError(err).Fatalln("failed to read known topology document")
+			}
+			options.Known = doc
+		}
+		orphans, err := gc.Find(topology.GetTopology(), options)
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to find orphans")
+		}
+
+		clean := viper.GetBool("gc.clean") && !viper.GetBool("gc.dryrun")
+		results := make([]string, len(orphans))
+		failed := 0
+		for i, o := range orphans {
+			switch {
+			case !viper.GetBool("gc.clean"):
+				results[i] = "found"
+			case !clean:
+				results[i] = "would remove"
+			default:
+				if err := o.Remove(); err != nil {
+					logrus.WithError(err).WithField("kind", o.Kind).WithField("name", o.Name).Errorln("failed to remove orphan")
+					results[i] = "failed"
+					failed++
+				} else {
+					results[i] = "removed"
+				}
+			}
+		}
+
+		if viper.GetBool("gc.json") {
+			type result struct {
+				gc.Orphan
+				Result string `json:"result"`
+			}
+			out := make([]result, len(orphans))
+			for i, o := range orphans {
+				out[i] = result{o, results[i]}
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+				logrus.WithError(err).Fatalln("failed to encode orphans")
+			}
+		} else {
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "TOPOLOGY\tDEVICE\tKIND\tNAME\tREASON\tRESULT")
+			for i, o := range orphans {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", o.Topology, o.Device, o.Kind, o.Name, o.Reason, results[i])
+			}
+			tw.Flush()
+		}
+		if failed > 0 {
+			logrus.WithField("failed", failed).WithField("orphans", len(orphans)).Fatalln("failed to remove orphans")
+		}
+	},
+}
+
+func init() {
+	gcCmd.Flags().Bool("clean", false, "remove the orphans that are found")
+	viper.BindPFlag("gc.clean", gcCmd.Flags().Lookup("clean"))
+	gcCmd.Flags().Bool("dry-run", false, "with --clean, only report what would be removed")
+	viper.BindPFlag("gc.dryrun", gcCmd.Flags().Lookup("dry-run"))
+	gcCmd.Flags().Bool("all", false, "include the resources of all topologies and those shared by them")
+	viper.BindPFlag("gc.all", gcCmd.Flags().Lookup("all"))
+	gcCmd.Flags().Bool("volumes", false, "with --all, include the volumes of persistent sites that no longer exist")
+	viper.BindPFlag("gc.volumes", gcCmd.Flags().Lookup("volumes"))
+	gcCmd.Flags().String("known", "", "saved document of the topology, whose hosts not in it are orphans")
+	viper.BindPFlag("gc.known", gcCmd.Flags().Lookup("known"))
+	gcCmd.Flags().String("format", "", "format of the known document, yaml or json (default from the file extension, else yaml)")
+	viper.BindPFlag("gc.format", gcCmd.Flags().Lookup("format"))
+	gcCmd.Flags().Bool("json", false, "output orphans as json")
+	viper.BindPFlag("gc.json", gcCmd.Flags().Lookup("json"))
+	rootCmd.AddCommand(gcCmd)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var createCmd = &cobra.Command{
+	Use:    "create <device> <name>...",
+	Short:  "create hosts concurrently, e.g. create dind site --count 50 --start",
+	Args:   cobra.MinimumNArgs(2),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		names := args[1:]
+		if count := viper.GetInt("create.count"); count > 0 {
+			if len(names) != 1 {
+				logrus.Fatalln("count requires a single name prefix")
+			}
+			prefix := names[0]
+			names = make([]string, count)
+			for i := range names {
+				names[i] = fmt.Sprintf("%s%d", prefix, i+1)
+			}
+		}
+		specs := make([]topology.HostSpec, len(names))
+		for i, name := range names {
+			specs[i] = topology.HostSpec{
+				Device: args[0],
+				Name:   name,
+				Labels: make(map[string]string),
+				Config: make(map[string]any),
+			}
+		}
+		options := bulkOptions("create")
+		options.Start = viper.GetBool("create.start")
+		results := topology.NewHosts(specs, options)
+		printBulkResults(results)
+		if err := topology.BulkError(results); err != nil {
+			logrus.WithError(err).Fatalln("failed to create hosts")
+		}
+	},
+}
+
+var startCmd = &cobra.Command{
+	Use:    "start [host]...",
+	Short:  "start hosts concurrently, waiting for each to be ready",
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		hosts := selectHosts(args, viper.GetString("start.selector"), func(s topology.HostState) bool { return s == topology.HostStateReady })
+		results := topology.StartHosts(hosts, bulkOptions("start"))
+		printBulkResults(results)
+		if err := topology.BulkError(results); err != nil {
+			logrus.WithError(err).Fatalln("failed to start hosts")
+		}
+	},
+}
+
+var stopCmd = &cobra.Command{
+	Use:    "stop [host]...",
+	Short:  "stop hosts concurrently",
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		hosts := selectHosts(args, viper.GetString("stop.selector"), topology.HostState.Started)
+		results := topology.StopHosts(hosts, bulkOptions("stop"))
+		printBulkResults(results)
+		if err := topology.BulkError(results); err != nil {
+			logrus.WithError(err).Fatalln("failed to stop hosts")
+		}
+	},
+}
+
+// selectHosts returns the named hosts, or if none are named, the hosts that
+// match the selector and whose state matches.
+func selectHosts(names []string, selector string, state func(topology.HostState) bool) []*topology.Host {
+	if len(names) > 0 {
+		hosts := make([]*topology.Host, 0, len(names))
+		for _, name := range names {
+			hosts = append(hosts, findHost(name))
+		}
+		return hosts
+	}
+	filters, err := topology.ParseSelector(selector)
+	if err != nil {
+		logrus.WithError(err).Fatalln("failed to parse host selector")
+	}
+	hosts := make([]*topology.Host, 0)
+	for _, h := range topology.Hosts(filters...) {
+		if state(h.State()) {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+func bulkOptions(command string) topology.BulkOptions {
+	return topology.BulkOptions{
+		Workers: viper.GetInt(command + ".workers"),
+		Timeout: viper.GetDuration(command + ".timeout"),
+	}
+}
+
+func printBulkResults(results []topology.BulkResult) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tRESULT\tDURATION\tERROR")
+	for _, r := range results {
+		result := "ok"
+		if r.TimedOut {
+			result = "timeout"
+		} else if r.Err != nil {
+			result = "failed"
+		}
+		errText := ""
+		if r.Err != nil {
+			errText = r.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Name, result, r.Duration.Round(time.Millisecond), errText)
+	}
+	tw.Flush()
+}
+
+func init() {
+	for _, c := range []*cobra.Command{createCmd, startCmd, stopCmd} {
+		c.Flags().Int("workers", topology.DefaultBulkWorkers, "number of hosts operated on concurrently")
+		viper.BindPFlag(c.Name()+".workers", c.Flags().Lookup("workers"))
+		c.Flags().Duration("timeout", 0, "time allowed per host, after which its remaining steps are skipped and it is reported as timed out (default none)")
+		viper.BindPFlag(c.Name()+".timeout", c.Flags().Lookup("timeout"))
+	}
+	for _, c := range []*cobra.Command{startCmd, stopCmd} {
+		c.Flags().StringP("selector", "s", "", "hosts to operate on when none are named, e.g. device=dind,name=site-*")
+		viper.BindPFlag(c.Name()+".selector", c.Flags().Lookup("selector"))
+	}
+	createCmd.Flags().Int("count", 0, "create this many hosts, named by suffixing the name with 1..count")
+	viper.BindPFlag("create.count", createCmd.Flags().Lookup("count"))
+	createCmd.Flags().Bool("start", false, "start each host once created")
+	viper.BindPFlag("create.start", createCmd.Flags().Lookup("start"))
+	rootCmd.AddCommand(createCmd, startCmd, stopCmd)
+}
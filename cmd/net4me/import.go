@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ai4networks/net4me/pkg/importer"
+	"github.com/ai4networks/net4me/pkg/state"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var importCmd = &cobra.Command{
+	Use:    "import <file>",
+	Short:  "create a topology from a containerlab, mininet or gns3 topology file",
+	Args:   cobra.ExactArgs(1),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := viper.GetString("import.format")
+		if format == "" {
+			var err error
+			if format, err = importer.DetectFormat(args[0]); err != nil {
+				logrus.WithError(err).Fatalln("failed to detect topology format, set it with --format")
+			}
+		}
+		f, err := os.Open(args[0])
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to open topology file")
+		}
+		defer f.Close()
+		doc, warnings, err := importer.Import(f, format, importer.Options{
+			HostDevice:   viper.GetString("import.hostdevice"),
+			SwitchDevice: viper.GetString("import.switchdevice"),
+		})
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to import topology")
+		}
+		for _, w := range warnings {
+			logrus.WithField("format", format).Warnln(w)
+		}
+		if output := viper.GetString("import.output"); output != "" {
+			w := os.Stdout
+			if output != "-" {
+				if w, err = os.Create(output); err != nil {
+					logrus.WithError(err).Fatalln("failed to create output file")
+				}
+				defer w.Close()
+			}
+			if err := state.Encode(w, doc, state.FormatFromPath(output)); err != nil {
+				logrus.WithError(err).Fatalln("failed to write topology document")
+			}
+			return
+		}
+		if viper.GetBool("import.dryrun") {
+			if err := state.Validate(doc); err != nil {
+				logrus.WithError(err).Fatalln("imported topology can not be created")
+			}
+			logrus.WithField("hosts", len(doc.Hosts)).WithField("links", len(doc.Links)).Infoln("imported topology can be created")
+			return
+		}
+		if err := state.Restore(doc); err != nil {
+			logrus.WithError(err).Fatalln("failed to create imported topology")
+		}
+		logrus.
+			WithField("hosts", len(doc.Hosts)).
+			WithField("links", len(doc.Links)).
+			WithField("routes", len(doc.Routes)).
+			Infoln("created imported topology")
+	},
+}
+
+func init() {
+	importCmd.Flags().String("format", "", "topology format, clab, mininet or gns3 (default from the file name)")
+	viper.BindPFlag("import.format", importCmd.Flags().Lookup("format"))
+	importCmd.Flags().String("host-device", importer.DefaultOptions.HostDevice, "device to create hosts as (e.g. dind or netns)")
+	viper.BindPFlag("import.hostdevice", importCmd.Flags().Lookup("host-device"))
+	importCmd.Flags().String("switch-device", importer.DefaultOptions.SwitchDevice, "device to create switches as")
+	viper.BindPFlag("import.switchdevice", importCmd.Flags().Lookup("switch-device"))
+	importCmd.Flags().StringP("output", "o", "", "write the converted topology document to a file (- for stdout) instead of creating it")
+	viper.BindPFlag("import.output", importCmd.Flags().Lookup("output"))
+	importCmd.Flags().Bool("dry-run", false, "only check that the imported topology can be created")
+	viper.BindPFlag("import.dryrun", importCmd.Flags().Lookup("dry-run"))
+	rootCmd.AddCommand(importCmd)
+}
@@ -9,7 +9,6 @@ import (
 
 	"github.com/ai4networks/net4me/cmd/net4me/forms"
 	"github.com/ai4networks/net4me/pkg/node"
-	"github.com/ai4networks/net4me/pkg/port"
 	"github.com/ai4networks/net4me/pkg/topology"
 	"github.com/docker/docker/client"
 	"github.com/sirupsen/logrus"
@@ -70,39 +69,23 @@ var linkCmd = &cobra.Command{
 		logrus.Infoln("started hosts")
 
 		// link hosts
-		if l, err := h1.Link(s1); err != nil {
+		if l, err := h1.LinkWith(s1, topology.LinkOptions{
+			Self: topology.LinkEndpoint{Addresses: []string{"192.168.5.5/24"}, Up: true},
+			Peer: topology.LinkEndpoint{Up: true},
+		}); err != nil {
 			logrus.WithError(err).Errorln("failed to link hosts")
 		} else {
 			logrus.WithField("link", l).Infoln("linked host 1")
-			// add addresses
-			if err := port.PortAddAddress(l.SelfHost().NetworkNamespace(), l.SelfPort(), "192.168.5.5/24"); err != nil {
-				logrus.WithError(err).Errorln("failed to add address to host 1")
-			}
-			// set up
-			if err := port.PortSetUp(l.SelfHost().NetworkNamespace(), l.SelfPort()); err != nil {
-				logrus.WithError(err).Errorln("failed to set up port on host 1")
-			}
-			if err := port.PortSetUp(l.PeerHost().NetworkNamespace(), l.PeerPort()); err != nil {
-				logrus.WithError(err).Errorln("failed to set up port on switch 1")
-			}
 		}
 
 		// link hosts
-		if l, err := h2.Link(s1); err != nil {
+		if l, err := h2.LinkWith(s1, topology.LinkOptions{
+			Self: topology.LinkEndpoint{Addresses: []string{"192.168.5.4/24"}, Up: true},
+			Peer: topology.LinkEndpoint{Up: true},
+		}); err != nil {
 			logrus.WithError(err).Errorln("failed to link hosts")
 		} else {
 			logrus.WithField("link", l).Infoln("linked host 2")
-			// add addresses
-			if err := port.PortAddAddress(l.SelfHost().NetworkNamespace(), l.SelfPort(), "192.168.5.4/24"); err != nil {
-				logrus.WithError(err).Errorln("failed to add address to host 2")
-			}
-			// set up
-			if err := port.PortSetUp(l.SelfHost().NetworkNamespace(), l.SelfPort()); err != nil {
-				logrus.WithError(err).Errorln("failed to set up port on host 2")
-			}
-			if err := port.PortSetUp(l.PeerHost().NetworkNamespace(), l.PeerPort()); err != nil {
-				logrus.WithError(err).Errorln("failed to set up port on switch 1")
-			}
 		}
 
 		// list link on host 1
@@ -6,6 +6,7 @@ import (
 	"github.com/ai4networks/net4me/cmd/net4me/forms"
 	"github.com/ai4networks/net4me/pkg/influx"
 	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/topology"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/log"
 	"github.com/sirupsen/logrus"
@@ -13,6 +14,7 @@ import (
 	"github.com/spf13/viper"
 
 	_ "github.com/ai4networks/net4me/pkg/nodes/dind"
+	_ "github.com/ai4networks/net4me/pkg/nodes/netns"
 	_ "github.com/ai4networks/net4me/pkg/nodes/ovs"
 )
 
@@ -34,7 +36,8 @@ var (
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 
-			log.Info("starting net4me")
+			log.Info("starting net4me", "topology", viper.GetString("topology.name"))
+			selectTopology()
 			for _, m := range node.Managers() {
 				deviceConfig := viper.GetStringMap(fmt.Sprintf("manager.%s", m.Device()))
 				if len(deviceConfig) == 0 {
@@ -49,7 +52,7 @@ var (
 
 			if viper.GetString("influx.address") != "" {
 				log.Info("starting influx exporter", "address", viper.GetString("influx.address"))
-				if errC, err := influx.RunExporter(viper.GetString("influx.address"), viper.GetString("influx.token"), viper.GetString("influx.org"), viper.GetString("influx.bucket"), viper.GetInt("influx.interval")); err != nil {
+				if errC, err := influx.RunExporter(topology.GetTopology(), viper.GetString("influx.address"), viper.GetString("influx.token"), viper.GetString("influx.org"), viper.GetString("influx.bucket"), viper.GetInt("influx.interval")); err != nil {
 					log.Error("failed to start influx exporter", "error", err.Error())
 				} else {
 					log.Info("started influx exporter")
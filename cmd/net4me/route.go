@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ai4networks/net4me/pkg/routing"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var routeCmd = &cobra.Command{
+	Use:    "route",
+	Short:  "compute and install static routes across the topology",
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		weight := routing.WeightHops
+		switch viper.GetString("route.weight") {
+		case "hops":
+		case "latency":
+			weight = routing.WeightLatency
+		default:
+			logrus.WithField("weight", viper.GetString("route.weight")).Fatalln("unknown route weight")
+		}
+		interval := viper.GetInt("route.watch")
+		if interval <= 0 {
+			routes, err := routing.Apply(weight)
+			if err != nil {
+				logrus.WithError(err).Fatalln("failed to apply routes")
+			}
+			for _, r := range routes {
+				logrus.
+					WithField("host", r.Host.Name()).
+					WithField("destination", r.Destination).
+					WithField("gateway", r.Gateway).
+					WithField("port", r.Port.Attrs().Name).
+					Infoln("installed route")
+			}
+			return
+		}
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		errC, err := routing.RunRecompute(ctx, weight, interval)
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to apply routes")
+		}
+		logrus.WithField("interval", interval).Infoln("watching links for route recomputation")
+		if err := <-errC; err != nil {
+			logrus.WithError(err).Fatalln("route recomputation failed")
+		}
+	},
+}
+
+func init() {
+	routeCmd.Flags().String("weight", "hops", "link weight used for path computation (hops|latency)")
+	viper.BindPFlag("route.weight", routeCmd.Flags().Lookup("weight"))
+	routeCmd.Flags().Int("watch", 0, "recompute routes when links change, checking every n seconds (0 to disable)")
+	viper.BindPFlag("route.watch", routeCmd.Flags().Lookup("watch"))
+	rootCmd.AddCommand(routeCmd)
+}
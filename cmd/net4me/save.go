@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/ai4networks/net4me/pkg/state"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// documentFormat returns the format flag if set, otherwise the format implied
+// by the document path.
+func documentFormat(key, path string) string {
+	if format := viper.GetString(key); format != "" {
+		return format
+	}
+	return state.FormatFromPath(path)
+}
+
+var saveCmd = &cobra.Command{
+	Use:    "save",
+	Short:  "save the live topology to a document that can be restored",
+	Args:   cobra.NoArgs,
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		doc, err := state.Save()
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to save topology")
+		}
+		output := viper.GetString("save.output")
+		var w io.Writer = os.Stdout
+		if output != "" && output != "-" {
+			f, err := os.Create(output)
+			if err != nil {
+				logrus.WithError(err).Fatalln("failed to create output file")
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := state.Encode(w, doc, documentFormat("save.format", output)); err != nil {
+			logrus.WithError(err).Fatalln("failed to write topology document")
+		}
+		logrus.
+			WithField("hosts", len(doc.Hosts)).
+			WithField("links", len(doc.Links)).
+			WithField("routes", len(doc.Routes)).
+			Infoln("saved topology")
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:    "restore <file>",
+	Short:  "recreate a topology from a saved document",
+	Args:   cobra.ExactArgs(1),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				logrus.WithError(err).Fatalln("failed to open topology document")
+			}
+			defer f.Close()
+			r = f
+		}
+		doc, err := state.Decode(r, documentFormat("restore.format", args[0]))
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to read topology document")
+		}
+		if viper.GetBool("restore.dryrun") {
+			if err := state.Validate(doc); err != nil {
+				logrus.WithError(err).Fatalln("topology document can not be restored")
+			}
+			logrus.WithField("hosts", len(doc.Hosts)).WithField("links", len(doc.Links)).Infoln("topology document can be restored")
+			return
+		}
+		if err := state.Restore(doc); err != nil {
+			logrus.WithError(err).Fatalln("failed to restore topology")
+		}
+		logrus.
+			WithField("hosts", len(doc.Hosts)).
+			WithField("links", len(doc.Links)).
+			WithField("routes", len(doc.Routes)).
+			Infoln("restored topology")
+	},
+}
+
+func init() {
+	saveCmd.Flags().StringP("output", "o", "", "file to write the document to (default stdout)")
+	viper.BindPFlag("save.output", saveCmd.Flags().Lookup("output"))
+	saveCmd.Flags().String("format", "", "document format, yaml or json (default from the file extension, else yaml)")
+	viper.BindPFlag("save.format", saveCmd.Flags().Lookup("format"))
+	restoreCmd.Flags().String("format", "", "document format, yaml or json (default from the file extension, else yaml)")
+	viper.BindPFlag("restore.format", restoreCmd.Flags().Lookup("format"))
+	restoreCmd.Flags().Bool("dry-run", false, "only check that the document can be restored")
+	viper.BindPFlag("restore.dryrun", restoreCmd.Flags().Lookup("dry-run"))
+	rootCmd.AddCommand(saveCmd, restoreCmd)
+}
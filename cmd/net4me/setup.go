@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// setupTopology sets up all registered device managers using their manager
+// configuration and then loads the topology from the system. This is intended
+// to be used as the PreRun of any command that operates on an existing
+// topology.
+func setupTopology(cmd *cobra.Command, args []string) {
+	logrus.WithField("manager_count", len(node.Managers())).Debugln("found managers")
+	for _, m := range node.Managers() {
+		deviceConfig := viper.GetStringMap(fmt.Sprintf("manager.%s", m.Device()))
+		if len(deviceConfig) == 0 {
+			logrus.WithField("device", m.Device()).Warnln("custom device manager configuration not found")
+		}
+		if err := m.Setup(deviceConfig); err != nil {
+			logrus.WithError(err).WithField("device", m.Device()).Errorln("failed to setup manager")
+			continue
+		}
+		logrus.WithField("device", m.Device()).Debugln("setup device manager")
+	}
+	selectTopology()
+	topology.EnableLinkCache(viper.GetBool("topology.linkCache"))
+	if err := topology.LoadTopology(); err != nil {
+		logrus.WithError(err).Fatalln("failed to load topology")
+	}
+	logrus.WithField("host_count", len(topology.Hosts())).Debugln("loaded topology")
+}
+
+// selectTopology makes the topology named by the topology flag the default,
+// so that commands only operate on its hosts. The topology is not loaded.
+func selectTopology() {
+	name := viper.GetString("topology.name")
+	if err := topology.ValidateName(name); err != nil {
+		logrus.WithError(err).Fatalln("invalid topology")
+	}
+	if name != topology.Name() {
+		topology.SetDefault(topology.New(name))
+	}
+}
+
+// findHost returns the host in the topology with the given name. If no host
+// has the given name, the command is terminated.
+func findHost(name string) *topology.Host {
+	if hosts := topology.Hosts(topology.FilterByName(name)); len(hosts) > 0 {
+		return hosts[0]
+	}
+	logrus.WithField("host", name).Fatalln("host not found in topology")
+	return nil
+}
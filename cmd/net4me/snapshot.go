@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/nodes/dind"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func dindManager() *dind.Manager {
+	manager, ok := node.Device("dind").(*dind.Manager)
+	if !ok {
+		logrus.Fatalln("dind device manager not found")
+	}
+	return manager
+}
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "save and restore the state of sites",
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:    "create <site> <name>",
+	Short:  "snapshot a site, including the images and containers of its engine",
+	Args:   cobra.ExactArgs(2),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		host := findHost(args[0])
+		site, ok := host.Node().(*dind.Node)
+		if !ok {
+			logrus.WithField("host", args[0]).Fatalln("host is not a site")
+		}
+		snapshot, err := dindManager().Snapshot(site, args[1])
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to snapshot site")
+		}
+		logrus.WithField("snapshot", snapshot.Name).WithField("site", snapshot.Site).Infoln("created snapshot")
+	},
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:    "list",
+	Short:  "list site snapshots",
+	Args:   cobra.NoArgs,
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		snapshots, err := dindManager().Snapshots()
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to list snapshots")
+		}
+		if viper.GetBool("snapshot.json") {
+			if err := json.NewEncoder(os.Stdout).Encode(snapshots); err != nil {
+				logrus.WithError(err).Fatalln("failed to encode snapshots")
+			}
+			return
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tSITE\tIMAGE\tVOLUME\tCREATED")
+		for _, s := range snapshots {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.Name, s.Site, s.Image, s.Volume, s.Created.Format(time.RFC3339))
+		}
+		tw.Flush()
+	},
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:    "restore <name> <site>",
+	Short:  "create a new persistent site from a snapshot",
+	Args:   cobra.ExactArgs(2),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		host, err := topology.NewHost("dind", args[1], make(map[string]string), map[string]any{
+			"snapshot":   args[0],
+			"persistent": true,
+		})
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to restore snapshot")
+		}
+		if err := host.Start(); err != nil {
+			logrus.WithError(err).Fatalln("failed to start restored site")
+		}
+		logrus.WithField("snapshot", args[0]).WithField("site", host.Name()).Infoln("restored snapshot")
+	},
+}
+
+var snapshotRemoveCmd = &cobra.Command{
+	Use:    "rm <name>...",
+	Short:  "remove site snapshots",
+	Args:   cobra.MinimumNArgs(1),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := dindManager()
+		for _, name := range args {
+			if err := manager.RemoveSnapshot(name); err != nil {
+				logrus.WithError(err).WithField("snapshot", name).Errorln("failed to remove snapshot")
+				continue
+			}
+			logrus.WithField("snapshot", name).Infoln("removed snapshot")
+		}
+	},
+}
+
+func init() {
+	snapshotListCmd.Flags().Bool("json", false, "output snapshots as json")
+	viper.BindPFlag("snapshot.json", snapshotListCmd.Flags().Lookup("json"))
+	snapshotCmd.AddCommand(snapshotCreateCmd, snapshotListCmd, snapshotRestoreCmd, snapshotRemoveCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/influx"
+	"github.com/ai4networks/net4me/pkg/traffic"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var trafficCmd = &cobra.Command{
+	Use:    "traffic <source> <target>",
+	Short:  "generate a tcp or udp traffic flow between two hosts",
+	Args:   cobra.ExactArgs(2),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		source, target := findHost(args[0]), findHost(args[1])
+		bitrate, err := traffic.ParseBitrate(viper.GetString("traffic.bitrate"))
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to parse bitrate")
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		logrus.
+			WithField("source", source.Name()).
+			WithField("target", target.Name()).
+			WithField("protocol", viper.GetString("traffic.protocol")).
+			Infoln("starting traffic flow")
+		result, err := traffic.Run(ctx, source, target, traffic.Options{
+			Protocol:   viper.GetString("traffic.protocol"),
+			Address:    viper.GetString("traffic.address"),
+			Port:       viper.GetInt("traffic.port"),
+			Bitrate:    bitrate,
+			Duration:   viper.GetDuration("traffic.duration"),
+			Parallel:   viper.GetInt("traffic.parallel"),
+			DSCP:       viper.GetInt("traffic.dscp"),
+			PacketSize: viper.GetInt("traffic.packet-size"),
+		})
+		if err != nil {
+			logrus.WithError(err).Fatalln("traffic flow failed")
+		}
+
+		if viper.GetBool("traffic.export") {
+			if err := influx.WriteTraffic(viper.GetString("influx.address"), viper.GetString("influx.token"), viper.GetString("influx.org"), viper.GetString("influx.bucket"), result); err != nil {
+				logrus.WithError(err).Errorln("failed to export traffic result")
+			}
+		}
+		if viper.GetBool("traffic.json") {
+			if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+				logrus.WithError(err).Fatalln("failed to encode result")
+			}
+			return
+		}
+		entry := logrus.
+			WithField("address", result.Address).
+			WithField("duration", result.Duration.Round(time.Millisecond)).
+			WithField("sent", result.BytesSent).
+			WithField("received", result.BytesReceived).
+			WithField("throughput_mbps", result.Throughput/1000000)
+		if result.Protocol == traffic.ProtocolUDP {
+			entry = entry.WithField("loss", result.Loss).WithField("jitter", result.Jitter)
+		}
+		entry.Infoln("traffic flow complete")
+	},
+}
+
+func init() {
+	trafficCmd.Flags().StringP("protocol", "p", traffic.ProtocolTCP, "transport protocol of the flow (tcp|udp)")
+	viper.BindPFlag("traffic.protocol", trafficCmd.Flags().Lookup("protocol"))
+	trafficCmd.Flags().String("address", "", "target address to send to (default first address of target)")
+	viper.BindPFlag("traffic.address", trafficCmd.Flags().Lookup("address"))
+	trafficCmd.Flags().Int("port", traffic.DefaultPort, "port the receiver listens on")
+	viper.BindPFlag("traffic.port", trafficCmd.Flags().Lookup("port"))
+	trafficCmd.Flags().StringP("bitrate", "b", "0", "target bitrate in bits/s with optional K/M/G suffix (0 for unlimited tcp, 1M udp)")
+	viper.BindPFlag("traffic.bitrate", trafficCmd.Flags().Lookup("bitrate"))
+	trafficCmd.Flags().DurationP("duration", "t", 10*time.Second, "duration of the flow (0 to run until interrupted)")
+	viper.BindPFlag("traffic.duration", trafficCmd.Flags().Lookup("duration"))
+	trafficCmd.Flags().IntP("parallel", "P", 1, "number of parallel streams")
+	viper.BindPFlag("traffic.parallel", trafficCmd.Flags().Lookup("parallel"))
+	trafficCmd.Flags().Int("dscp", 0, "dscp value to mark sent packets with")
+	viper.BindPFlag("traffic.dscp", trafficCmd.Flags().Lookup("dscp"))
+	trafficCmd.Flags().Int("packet-size", traffic.DefaultPacketSize, "payload size of udp datagrams")
+	viper.BindPFlag("traffic.packet-size", trafficCmd.Flags().Lookup("packet-size"))
+	trafficCmd.Flags().Bool("json", false, "output the result as json")
+	viper.BindPFlag("traffic.json", trafficCmd.Flags().Lookup("json"))
+	trafficCmd.Flags().Bool("export", false, "export the result to the configured influx instance")
+	viper.BindPFlag("traffic.export", trafficCmd.Flags().Lookup("export"))
+	rootCmd.AddCommand(trafficCmd)
+}
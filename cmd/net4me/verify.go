@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/routing"
+	"github.com/ai4networks/net4me/pkg/verify"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:    "verify",
+	Short:  "verify reachability, latency and loss between all hosts",
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		weight := routing.WeightHops
+		if viper.GetString("verify.weight") == "latency" {
+			weight = routing.WeightLatency
+		}
+		results, err := verify.Run(verify.Options{
+			ProbeOptions: verify.ProbeOptions{
+				Count:    viper.GetInt("verify.count"),
+				Interval: viper.GetDuration("verify.interval"),
+				Timeout:  viper.GetDuration("verify.timeout"),
+			},
+			Protocol:      viper.GetString("verify.protocol"),
+			UDPPort:       viper.GetInt("verify.udp-port"),
+			Workers:       viper.GetInt("verify.workers"),
+			Weight:        weight,
+			RTTTolerance:  viper.GetFloat64("verify.rtt-tolerance"),
+			RTTSlack:      viper.GetDuration("verify.rtt-slack"),
+			LossTolerance: viper.GetFloat64("verify.loss-tolerance"),
+		})
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to verify topology")
+		}
+
+		mismatches := 0
+		for _, r := range results {
+			if len(r.Mismatches) > 0 || r.Error != "" {
+				mismatches++
+			}
+		}
+		if viper.GetBool("verify.json") {
+			if err := json.NewEncoder(os.Stdout).Encode(results); err != nil {
+				logrus.WithError(err).Fatalln("failed to encode results")
+			}
+		} else {
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "SOURCE\tTARGET\tADDRESS\tREACHABLE\tRTT (MIN/AVG/MAX)\tLOSS\tEXPECTED RTT\tEXPECTED LOSS\tSTATUS")
+			for _, r := range results {
+				status := "ok"
+				if r.Error != "" {
+					status = "error: " + r.Error
+				} else if len(r.Mismatches) > 0 {
+					status = "mismatch: " + strings.Join(r.Mismatches, ", ")
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s/%s/%s\t%.1f%%\t%s\t%.1f%%\t%s\n",
+					r.Source, r.Target, r.Address, r.Reachable,
+					r.RTTMin, r.RTTAvg, r.RTTMax, r.Loss,
+					r.ExpectedRTT, r.ExpectedLoss, status)
+			}
+			tw.Flush()
+		}
+		if mismatches > 0 {
+			logrus.WithField("mismatches", mismatches).WithField("pairs", len(results)).Errorln("topology verification failed")
+			os.Exit(1)
+		}
+		logrus.WithField("pairs", len(results)).Infoln("topology verified")
+	},
+}
+
+func init() {
+	verifyCmd.Flags().String("protocol", verify.ProtocolICMP, "probe protocol (icmp|udp)")
+	viper.BindPFlag("verify.protocol", verifyCmd.Flags().Lookup("protocol"))
+	verifyCmd.Flags().Int("count", 5, "number of probes sent to each address")
+	viper.BindPFlag("verify.count", verifyCmd.Flags().Lookup("count"))
+	verifyCmd.Flags().Duration("interval", 200*time.Millisecond, "interval between probes")
+	viper.BindPFlag("verify.interval", verifyCmd.Flags().Lookup("interval"))
+	verifyCmd.Flags().Duration("timeout", time.Second, "time to wait for each probe reply")
+	viper.BindPFlag("verify.timeout", verifyCmd.Flags().Lookup("timeout"))
+	verifyCmd.Flags().Int("udp-port", 7777, "port used for udp probes")
+	viper.BindPFlag("verify.udp-port", verifyCmd.Flags().Lookup("udp-port"))
+	verifyCmd.Flags().Int("workers", 8, "number of host pairs probed concurrently")
+	viper.BindPFlag("verify.workers", verifyCmd.Flags().Lookup("workers"))
+	verifyCmd.Flags().String("weight", "hops", "link weight used to determine expected paths (hops|latency)")
+	viper.BindPFlag("verify.weight", verifyCmd.Flags().Lookup("weight"))
+	verifyCmd.Flags().Float64("rtt-tolerance", 0.2, "allowed fractional difference between measured and expected rtt")
+	viper.BindPFlag("verify.rtt-tolerance", verifyCmd.Flags().Lookup("rtt-tolerance"))
+	verifyCmd.Flags().Duration("rtt-slack", 2*time.Millisecond, "allowed absolute difference between measured and expected rtt")
+	viper.BindPFlag("verify.rtt-slack", verifyCmd.Flags().Lookup("rtt-slack"))
+	verifyCmd.Flags().Float64("loss-tolerance", 10, "allowed percentage points of loss above expected")
+	viper.BindPFlag("verify.loss-tolerance", verifyCmd.Flags().Lookup("loss-tolerance"))
+	verifyCmd.Flags().Bool("json", false, "output results as json")
+	viper.BindPFlag("verify.json", verifyCmd.Flags().Lookup("json"))
+	rootCmd.AddCommand(verifyCmd)
+}
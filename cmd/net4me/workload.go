@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/workload"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var workloadCmd = &cobra.Command{
+	Use:   "workload",
+	Short: "deploy and manage docker compose workloads on sites",
+}
+
+// workloads returns the workloads given by the manifest flag, or a single
+// workload built from the site, compose and project flags.
+func workloads() []workload.Workload {
+	if manifest := viper.GetString("workload.manifest"); manifest != "" {
+		ws, err := workload.LoadManifest(manifest)
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to load workload manifest")
+		}
+		return ws
+	}
+	if viper.GetString("workload.site") == "" {
+		logrus.Fatalln("either a manifest or a site is required")
+	}
+	return []workload.Workload{{
+		Site:    viper.GetString("workload.site"),
+		Compose: viper.GetString("workload.compose"),
+		Project: viper.GetString("workload.project"),
+	}}
+}
+
+var workloadUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "deploy workloads to their sites",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, w := range workloads() {
+			if err := workload.Deploy(w); err != nil {
+				logrus.WithError(err).Fatalln("failed to deploy workload")
+			}
+			logrus.WithField("project", w.ProjectName()).WithField("sites", w.Targets()).Infoln("deployed workload")
+		}
+	},
+}
+
+var workloadStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show the state of workload containers on their sites",
+	Run: func(cmd *cobra.Command, args []string) {
+		type siteStatus struct {
+			Site     string                 `json:"site"`
+			Project  string                 `json:"project"`
+			Services []node.WorkloadService `json:"services"`
+		}
+		statuses := make([]siteStatus, 0)
+		for _, w := range workloads() {
+			for _, site := range w.Targets() {
+				services, err := workload.Status(site, w.ProjectName())
+				if err != nil {
+					logrus.WithError(err).WithField("site", site).Fatalln("failed to get workload status")
+				}
+				statuses = append(statuses, siteStatus{Site: site, Project: w.ProjectName(), Services: services})
+			}
+		}
+		if viper.GetBool("workload.json") {
+			if err := json.NewEncoder(os.Stdout).Encode(statuses); err != nil {
+				logrus.WithError(err).Fatalln("failed to encode status")
+			}
+			return
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SITE\tPROJECT\tSERVICE\tCONTAINER\tIMAGE\tSTATE\tSTATUS")
+		for _, s := range statuses {
+			for _, svc := range s.Services {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.Site, s.Project, svc.Service, svc.Container, svc.Image, svc.State, svc.Status)
+			}
+		}
+		tw.Flush()
+	},
+}
+
+var workloadLogsCmd = &cobra.Command{
+	Use:    "logs <site> <project>",
+	Short:  "show the logs of a workload on a site",
+	Args:   cobra.ExactArgs(2),
+	PreRun: setupTopology,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		if err := workload.Logs(ctx, args[0], args[1], viper.GetBool("workload.follow"), viper.GetString("workload.tail"), os.Stdout); err != nil {
+			logrus.WithError(err).Fatalln("failed to get workload logs")
+		}
+	},
+}
+
+var workloadDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "tear down workloads on their sites",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, w := range workloads() {
+			for _, site := range w.Targets() {
+				if err := workload.Remove(site, w.ProjectName()); err != nil {
+					logrus.WithError(err).WithField("site", site).Errorln("failed to tear down workload")
+					continue
+				}
+				logrus.WithField("project", w.ProjectName()).WithField("site", site).Infoln("tore down workload")
+			}
+		}
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{workloadUpCmd, workloadStatusCmd, workloadDownCmd} {
+		c.Flags().StringP("manifest", "f", "", "workload manifest mapping compose files to sites")
+		c.Flags().String("site", "", "site to target when no manifest is given")
+		c.Flags().String("compose", "compose.yaml", "compose file to deploy when no manifest is given")
+		c.Flags().StringP("project", "p", "", "compose project name when no manifest is given (default directory name)")
+		// flags are bound when the command runs, as they are shared by name
+		// across the subcommands
+		c.PreRun = func(cmd *cobra.Command, args []string) {
+			viper.BindPFlag("workload.manifest", cmd.Flags().Lookup("manifest"))
+			viper.BindPFlag("workload.site", cmd.Flags().Lookup("site"))
+			viper.BindPFlag("workload.compose", cmd.Flags().Lookup("compose"))
+			viper.BindPFlag("workload.project", cmd.Flags().Lookup("project"))
+			if f := cmd.Flags().Lookup("json"); f != nil {
+				viper.BindPFlag("workload.json", f)
+			}
+			setupTopology(cmd, args)
+		}
+	}
+	workloadStatusCmd.Flags().Bool("json", false, "output status as json")
+	workloadLogsCmd.Flags().BoolP("follow", "F", false, "follow log output")
+	viper.BindPFlag("workload.follow", workloadLogsCmd.Flags().Lookup("follow"))
+	workloadLogsCmd.Flags().String("tail", "all", "number of lines to show from the end of each container's logs")
+	viper.BindPFlag("workload.tail", workloadLogsCmd.Flags().Lookup("tail"))
+	workloadCmd.AddCommand(workloadUpCmd, workloadStatusCmd, workloadLogsCmd, workloadDownCmd)
+	rootCmd.AddCommand(workloadCmd)
+}
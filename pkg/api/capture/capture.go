@@ -0,0 +1,64 @@
+package capture
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/api/host"
+	c "github.com/ai4networks/net4me/pkg/capture"
+	"github.com/gorilla/mux"
+)
+
+// Capture streams a live pcapng capture of a host port (or link to a peer
+// host) in the response body. The capture runs until the client disconnects
+// or the optional count or duration (seconds) limits are reached. A BPF filter
+// can be given using the filter query parameter.
+func Capture(w http.ResponseWriter, r *http.Request) {
+	h, err := host.Lookup(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	p, err := h.FindPort(mux.Vars(r)["port"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	options := c.Options{
+		Filter: query.Get("filter"),
+	}
+	for key, target := range map[string]*int{"snaplen": &options.SnapLen, "count": &options.Count} {
+		if v := query.Get(key); v != "" {
+			if *target, err = strconv.Atoi(v); err != nil {
+				http.Error(w, "invalid "+key, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if v := query.Get("duration"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid duration", http.StatusBadRequest)
+			return
+		}
+		options.Duration = time.Duration(seconds) * time.Second
+	}
+
+	capture, err := c.Open(h.NetworkNamespace(), p, options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer capture.Close()
+
+	w.Header().Set("Content-Type", "application/x-pcapng")
+	w.WriteHeader(http.StatusOK)
+	writer, err := c.NewPcapngWriter(w, capture.InterfaceName(), capture.SnapLen())
+	if err != nil {
+		return
+	}
+	capture.Run(r.Context(), writer)
+}
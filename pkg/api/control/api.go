@@ -3,7 +3,11 @@ package control
 import (
 	"net/http"
 
+	"github.com/ai4networks/net4me/pkg/api/capture"
+	"github.com/ai4networks/net4me/pkg/api/exec"
+	"github.com/ai4networks/net4me/pkg/api/files"
 	"github.com/ai4networks/net4me/pkg/api/node"
+	"github.com/ai4networks/net4me/pkg/api/workload"
 	"github.com/gorilla/mux"
 )
 
@@ -18,7 +22,20 @@ func Serve(address string) error {
 func init() {
 	// v0
 	v0 := router.PathPrefix("/api/v0").Subrouter()
+	routesV0(v0)
+	// the same routes scoped to a named topology, rather than the default
+	routesV0(v0.PathPrefix("/topology/{topology}").Subrouter())
+}
 
+func routesV0(v0 *mux.Router) {
 	v0.HandleFunc("/device/{dev}/nodes", node.Nodes).Methods(http.MethodGet)
 	v0.HandleFunc("/device/{dev}/node/add", node.Add).Methods(http.MethodPost)
+	v0.HandleFunc("/host/{host}/capture/{port}", capture.Capture).Methods(http.MethodGet)
+	v0.HandleFunc("/host/{host}/exec", exec.Exec).Methods(http.MethodPost)
+	v0.HandleFunc("/host/{host}/files", files.Download).Methods(http.MethodGet)
+	v0.HandleFunc("/host/{host}/files", files.Upload).Methods(http.MethodPut)
+	v0.HandleFunc("/host/{host}/workload/{project}", workload.Status).Methods(http.MethodGet)
+	v0.HandleFunc("/host/{host}/workload/{project}", workload.Deploy).Methods(http.MethodPut)
+	v0.HandleFunc("/host/{host}/workload/{project}", workload.Remove).Methods(http.MethodDelete)
+	v0.HandleFunc("/host/{host}/workload/{project}/logs", workload.Logs).Methods(http.MethodGet)
 }
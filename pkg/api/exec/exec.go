@@ -0,0 +1,113 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ai4networks/net4me/pkg/api/host"
+	n "github.com/ai4networks/net4me/pkg/node"
+)
+
+// Exec runs a command in a host, or a container within it. By default the
+// command's output and exit code are returned as json once it completes. If
+// the stream query parameter is set, the combined output is streamed as it is
+// produced, with the exit code sent in the Exit-Code trailer. If the command
+// could not be run, the exit code is -1 and the error is sent in the
+// Exec-Error trailer.
+func Exec(w http.ResponseWriter, r *http.Request) {
+	h, err := host.Lookup(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	execer, ok := h.Node().(n.Execer)
+	if !ok {
+		http.Error(w, "host does not support exec", http.StatusBadRequest)
+		return
+	}
+	var requestBody ExecRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(requestBody.Cmd) == 0 {
+		http.Error(w, "cmd is required", http.StatusBadRequest)
+		return
+	}
+	options := n.ExecOptions{
+		Container:  requestBody.Container,
+		Cmd:        requestBody.Cmd,
+		Env:        requestBody.Env,
+		WorkingDir: requestBody.WorkingDir,
+		User:       requestBody.User,
+	}
+	if requestBody.Stdin != "" {
+		options.Stdin = strings.NewReader(requestBody.Stdin)
+	}
+
+	if stream, _ := strconv.ParseBool(r.URL.Query().Get("stream")); stream {
+		streamExec(r.Context(), w, execer, options)
+		return
+	}
+
+	var stdout, stderr bytes.Buffer
+	options.Stdout, options.Stderr = &stdout, &stderr
+	code, err := execer.Exec(r.Context(), options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonResponse, err := json.Marshal(ExecResponse{
+		ExitCode: code,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+// streamExec runs the command, streaming its combined output and sending its
+// exit code, and any error running it, as trailers.
+func streamExec(ctx context.Context, w http.ResponseWriter, execer n.Execer, options n.ExecOptions) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Trailer", "Exit-Code, Exec-Error")
+	w.WriteHeader(http.StatusOK)
+	out := &streamWriter{w: w, lock: &sync.Mutex{}}
+	out.flusher, _ = w.(http.Flusher)
+	options.Stdout, options.Stderr = out, out
+	code, err := execer.Exec(ctx, options)
+	if err != nil {
+		code = -1
+		// header values can not span lines
+		w.Header().Set("Exec-Error", strings.Join(strings.Fields(err.Error()), " "))
+	}
+	w.Header().Set("Exit-Code", strconv.Itoa(code))
+}
+
+// streamWriter serialises writes from the output streams of the command and
+// flushes each one to the client.
+type streamWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	lock    *sync.Mutex
+}
+
+func (s *streamWriter) Write(b []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	n, err := s.w.Write(b)
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	return n, err
+}
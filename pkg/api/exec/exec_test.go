@@ -0,0 +1,59 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	n "github.com/ai4networks/net4me/pkg/node"
+)
+
+type fakeExecer struct {
+	output string
+	code   int
+	err    error
+}
+
+func (f fakeExecer) Exec(ctx context.Context, options n.ExecOptions) (int, error) {
+	io.WriteString(options.Stdout, f.output)
+	return f.code, f.err
+}
+
+// streamExec runs the execer as the stream handler does, returning the body
+// and trailers received by the client.
+func runStream(t *testing.T, execer fakeExecer) (string, http.Header) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		streamExec(r.Context(), w, execer, n.ExecOptions{})
+	}))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body), resp.Trailer
+}
+
+func TestExecStreamTrailers(t *testing.T) {
+	body, trailer := runStream(t, fakeExecer{output: "hello\n", code: 3})
+	if body != "hello\n" || trailer.Get("Exit-Code") != "3" || trailer.Get("Exec-Error") != "" {
+		t.Fatalf("got body %q and trailers %v", body, trailer)
+	}
+
+	body, trailer = runStream(t, fakeExecer{err: fmt.Errorf("container not found:\nsite1")})
+	if body != "" || trailer.Get("Exit-Code") != "-1" {
+		t.Fatalf("got body %q and trailers %v", body, trailer)
+	}
+	if got := trailer.Get("Exec-Error"); !strings.Contains(got, "container not found: site1") {
+		t.Fatalf("got error trailer %q", got)
+	}
+}
@@ -0,0 +1,16 @@
+package exec
+
+type ExecRequest struct {
+	Cmd        []string `json:"cmd"`
+	Container  string   `json:"container"`
+	Env        []string `json:"env"`
+	WorkingDir string   `json:"workdir"`
+	User       string   `json:"user"`
+	Stdin      string   `json:"stdin"`
+}
+
+type ExecResponse struct {
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+}
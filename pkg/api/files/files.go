@@ -0,0 +1,62 @@
+package files
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/ai4networks/net4me/pkg/api/host"
+	n "github.com/ai4networks/net4me/pkg/node"
+)
+
+// copier looks up the file copier of the host in the request path, writing an
+// error response if it can not be found.
+func copier(w http.ResponseWriter, r *http.Request) (n.FileCopier, bool) {
+	h, err := host.Lookup(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	fc, ok := h.Node().(n.FileCopier)
+	if !ok {
+		http.Error(w, "host does not support file transfer", http.StatusBadRequest)
+		return nil, false
+	}
+	if r.URL.Query().Get("path") == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return nil, false
+	}
+	return fc, true
+}
+
+// Download returns a tar archive of the file or directory at the path query
+// parameter, from the host or the container (query parameter) within it.
+func Download(w http.ResponseWriter, r *http.Request) {
+	fc, ok := copier(w, r)
+	if !ok {
+		return
+	}
+	archive, err := fc.ArchiveFrom(r.URL.Query().Get("container"), r.URL.Query().Get("path"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer archive.Close()
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, archive)
+}
+
+// Upload extracts the tar archive in the request body into the directory at
+// the path query parameter, on the host or the container (query parameter)
+// within it. The directory must already exist.
+func Upload(w http.ResponseWriter, r *http.Request) {
+	fc, ok := copier(w, r)
+	if !ok {
+		return
+	}
+	if err := fc.ArchiveTo(r.URL.Query().Get("container"), r.URL.Query().Get("path"), r.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,51 @@
+package host
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/gorilla/mux"
+)
+
+// LookupTopology returns the topology named by the `topology` path parameter of
+// the request, which must have been opened or have hosts (see
+// topology.Lookup). Without the parameter, the default topology of the server
+// is returned.
+func LookupTopology(r *http.Request) (*topology.Topology, error) {
+	name := mux.Vars(r)["topology"]
+	if name == "" {
+		return topology.GetTopology(), nil
+	}
+	return topology.Lookup(name)
+}
+
+// OpenTopology returns the topology named by the `topology` path parameter of
+// the request like LookupTopology, but creates the topology if it does not
+// exist, e.g. so that hosts can be added to it.
+func OpenTopology(r *http.Request) (*topology.Topology, error) {
+	name := mux.Vars(r)["topology"]
+	if name == "" {
+		return topology.GetTopology(), nil
+	}
+	return topology.Open(name)
+}
+
+// Lookup returns the topology host named by the `host` path parameter of the
+// request, within the topology of the request (see LookupTopology). If the
+// parameter is missing or no host has the name, an error is returned.
+func Lookup(r *http.Request) (*topology.Host, error) {
+	name := mux.Vars(r)["host"]
+	if name == "" {
+		return nil, fmt.Errorf("host is required")
+	}
+	t, err := LookupTopology(r)
+	if err != nil {
+		return nil, err
+	}
+	hosts := t.Hosts(topology.FilterByName(name))
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("host not found: %s", name)
+	}
+	return hosts[0], nil
+}
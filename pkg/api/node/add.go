@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/ai4networks/net4me/pkg/api/host"
 	n "github.com/ai4networks/net4me/pkg/node"
 	"github.com/gorilla/mux"
 )
@@ -28,20 +29,20 @@ func Add(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	node, err := deviceManager.Add(requestBody.Name, make(map[string]string), requestBody.Config)
+	t, err := host.OpenTopology(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
-	nodeName, err := node.Name()
+	h, err := t.NewHost(device, requestBody.Name, make(map[string]string), requestBody.Config)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	response := NodeAddResponse{
-		ID:   node.ID(),
-		Name: nodeName,
+		ID:   h.NodeID(),
+		Name: h.Name(),
 	}
 	jsonResponse, err := json.Marshal(response)
 	if err != nil {
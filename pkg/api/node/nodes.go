@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/ai4networks/net4me/pkg/api/host"
 	n "github.com/ai4networks/net4me/pkg/node"
 	"github.com/gorilla/mux"
 )
@@ -21,7 +22,12 @@ func Nodes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	nodes, err := deviceManager.Nodes()
+	t, err := host.LookupTopology(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	nodes, err := deviceManager.Nodes(n.FilterByTopology(t.Name()))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
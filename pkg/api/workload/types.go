@@ -0,0 +1,6 @@
+package workload
+
+type DeployRequest struct {
+	Compose string            `json:"compose"`
+	Env     map[string]string `json:"env"`
+}
@@ -0,0 +1,130 @@
+package workload
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ai4networks/net4me/pkg/api/host"
+	n "github.com/ai4networks/net4me/pkg/node"
+	"github.com/gorilla/mux"
+)
+
+// runner looks up the workload runner of the host in the request path,
+// writing an error response if it can not be found.
+func runner(w http.ResponseWriter, r *http.Request) (n.WorkloadRunner, string, bool) {
+	h, err := host.Lookup(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, "", false
+	}
+	wr, ok := h.Node().(n.WorkloadRunner)
+	if !ok {
+		http.Error(w, "host does not support workloads", http.StatusBadRequest)
+		return nil, "", false
+	}
+	return wr, mux.Vars(r)["project"], true
+}
+
+// Deploy brings up a compose project on a host from the compose file given in
+// the request body. As only a single file is sent, the compose file can not
+// reference other local files (e.g. build contexts or env files).
+func Deploy(w http.ResponseWriter, r *http.Request) {
+	wr, project, ok := runner(w, r)
+	if !ok {
+		return
+	}
+	var requestBody DeployRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dir, err := os.MkdirTemp("", "net4me-workload-*")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte(requestBody.Compose), 0644); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := wr.DeployWorkload(project, dir, "compose.yaml", requestBody.Env); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	Status(w, r)
+}
+
+// Status returns the state of every container of a compose project on a host.
+func Status(w http.ResponseWriter, r *http.Request) {
+	wr, project, ok := runner(w, r)
+	if !ok {
+		return
+	}
+	services, err := wr.WorkloadStatus(project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonResponse, err := json.Marshal(services)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+// Logs streams the logs of a compose project on a host. Set the follow query
+// parameter to keep streaming until the client disconnects, and tail to limit
+// the number of lines returned from each container.
+func Logs(w http.ResponseWriter, r *http.Request) {
+	wr, project, ok := runner(w, r)
+	if !ok {
+		return
+	}
+	follow, _ := strconv.ParseBool(r.URL.Query().Get("follow"))
+	tail := r.URL.Query().Get("tail")
+	if tail == "" {
+		tail = "all"
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	flusher, _ := w.(http.Flusher)
+	out := &flushWriter{w: w, flusher: flusher}
+	if err := wr.WorkloadLogs(r.Context(), project, follow, tail, out); err != nil && !out.written {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Remove tears down a compose project on a host.
+func Remove(w http.ResponseWriter, r *http.Request) {
+	wr, project, ok := runner(w, r)
+	if !ok {
+		return
+	}
+	if err := wr.RemoveWorkload(project); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// flushWriter flushes the response after every write so logs are streamed.
+type flushWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	written bool
+}
+
+func (f *flushWriter) Write(b []byte) (int, error) {
+	f.written = true
+	n, err := f.w.Write(b)
+	if f.flusher != nil {
+		f.flusher.Flush()
+	}
+	return n, err
+}
@@ -0,0 +1,142 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/neaas/neslink"
+	"golang.org/x/sys/unix"
+)
+
+// DefaultSnapLen is the maximum number of bytes captured per packet when no
+// snap length is given.
+const DefaultSnapLen = 262144
+
+// Options configures a capture.
+type Options struct {
+	// Filter is a BPF filter expression (e.g. "tcp port 80") or a compiled BPF
+	// program in the `tcpdump -ddd` format. If empty, all packets are captured.
+	Filter string
+	// SnapLen is the maximum number of bytes captured per packet.
+	SnapLen int
+	// Count stops the capture after the given number of packets (0 for no
+	// limit).
+	Count int
+	// Duration stops the capture after the given time (0 for no limit).
+	Duration time.Duration
+}
+
+// Capture is a packet capture on a single port within a network namespace.
+type Capture struct {
+	fd      int
+	ifName  string
+	options Options
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
+
+// Open opens an AF_PACKET socket bound to the port within the given namespace.
+// The socket is created within the namespace, so can be read from the control
+// process without further namespace switches.
+func Open(nsp neslink.NsProvider, p port.Port, options Options) (*Capture, error) {
+	if options.SnapLen <= 0 {
+		options.SnapLen = DefaultSnapLen
+	}
+	var filter []unix.SockFilter
+	if options.Filter != "" {
+		f, err := CompileFilter(nsp, p.Attrs().Name, options.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("could not compile capture filter: %w", err)
+		}
+		filter = f
+	}
+	c := &Capture{
+		fd:      -1,
+		ifName:  p.Attrs().Name,
+		options: options,
+	}
+	if err := neslink.Do(
+		nsp,
+		neslink.NAGeneric("open-packet-socket", func() error {
+			fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW|unix.SOCK_CLOEXEC, int(htons(unix.ETH_P_ALL)))
+			if err != nil {
+				return err
+			}
+			c.fd = fd
+			if len(filter) > 0 {
+				if err := unix.SetsockoptSockFprog(fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, &unix.SockFprog{
+					Len:    uint16(len(filter)),
+					Filter: &filter[0],
+				}); err != nil {
+					return fmt.Errorf("could not attach filter: %w", err)
+				}
+			}
+			if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &unix.Timeval{Usec: 200000}); err != nil {
+				return err
+			}
+			return unix.Bind(fd, &unix.SockaddrLinklayer{
+				Protocol: htons(unix.ETH_P_ALL),
+				Ifindex:  p.Attrs().Index,
+			})
+		}),
+	); err != nil {
+		if c.fd >= 0 {
+			unix.Close(c.fd)
+		}
+		return nil, fmt.Errorf("could not open capture on port %s: %w", p.Attrs().Name, err)
+	}
+	return c, nil
+}
+
+// InterfaceName returns the name of the captured port.
+func (c *Capture) InterfaceName() string {
+	return c.ifName
+}
+
+// SnapLen returns the maximum number of bytes captured per packet.
+func (c *Capture) SnapLen() int {
+	return c.options.SnapLen
+}
+
+// Run reads packets from the capture and writes them to the sink until the
+// context is canceled, or the count or duration limit is reached. The number
+// of packets captured is returned.
+func (c *Capture) Run(ctx context.Context, sink Sink) (int, error) {
+	if c.options.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.options.Duration)
+		defer cancel()
+	}
+	buf := make([]byte, c.options.SnapLen)
+	count := 0
+	for {
+		if ctx.Err() != nil {
+			return count, nil
+		}
+		if c.options.Count > 0 && count >= c.options.Count {
+			return count, nil
+		}
+		n, _, err := unix.Recvfrom(c.fd, buf, unix.MSG_TRUNC)
+		if err != nil {
+			if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+				continue
+			}
+			return count, fmt.Errorf("could not read from capture: %w", err)
+		}
+		captured := min(n, len(buf))
+		if err := sink.WritePacket(time.Now(), buf[:captured], n); err != nil {
+			return count, fmt.Errorf("could not write packet: %w", err)
+		}
+		count++
+	}
+}
+
+// Close closes the capture socket.
+func (c *Capture) Close() error {
+	return unix.Close(c.fd)
+}
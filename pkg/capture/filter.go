@@ -0,0 +1,70 @@
+package capture
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/neaas/neslink"
+	"golang.org/x/sys/unix"
+)
+
+// ParseFilter parses a classic BPF program in the decimal format produced by
+// `tcpdump -ddd`. The first line gives the number of instructions, followed by
+// one instruction per line in the form `code jt jf k`.
+func ParseFilter(program string) ([]unix.SockFilter, error) {
+	scanner := bufio.NewScanner(strings.NewReader(program))
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("empty bpf program")
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid bpf instruction count: %w", err)
+	}
+	filter := make([]unix.SockFilter, 0, count)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var code uint16
+		var jt, jf uint8
+		var k uint32
+		if _, err := fmt.Sscanf(line, "%d %d %d %d", &code, &jt, &jf, &k); err != nil {
+			return nil, fmt.Errorf("invalid bpf instruction %q: %w", line, err)
+		}
+		filter = append(filter, unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k})
+	}
+	if len(filter) != count {
+		return nil, fmt.Errorf("bpf program has %d instructions, expected %d", len(filter), count)
+	}
+	return filter, nil
+}
+
+// CompileFilter compiles a filter expression to a BPF program. If the
+// expression is already a compiled program (see ParseFilter), it is used as
+// is. Otherwise, the expression is compiled using tcpdump for the named
+// interface within the given namespace, so tcpdump must be available on the
+// system running net4me (but not within the namespace).
+func CompileFilter(nsp neslink.NsProvider, ifName, expression string) ([]unix.SockFilter, error) {
+	if filter, err := ParseFilter(expression); err == nil {
+		return filter, nil
+	}
+	var program []byte
+	if err := neslink.Do(
+		nsp,
+		neslink.NAGeneric("compile-bpf", func() error {
+			out, err := exec.Command("tcpdump", "-ddd", "-i", ifName, expression).Output()
+			if err != nil {
+				return fmt.Errorf("tcpdump could not compile filter: %w", err)
+			}
+			program = out
+			return nil
+		}),
+	); err != nil {
+		return nil, err
+	}
+	return ParseFilter(string(program))
+}
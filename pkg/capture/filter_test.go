@@ -0,0 +1,60 @@
+package capture
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		program string
+		want    []unix.SockFilter
+		wantErr bool
+	}{
+		{
+			name:    "tcpdump output",
+			program: "4\n40 0 0 12\n21 0 1 2048\n6 0 0 262144\n6 0 0 0\n",
+			want: []unix.SockFilter{
+				{Code: 40, K: 12},
+				{Code: 21, Jf: 1, K: 2048},
+				{Code: 6, K: 262144},
+				{Code: 6},
+			},
+		},
+		{
+			name:    "blank lines and spacing",
+			program: " 1 \n\n6 0 0 65535\n\n",
+			want:    []unix.SockFilter{{Code: 6, K: 65535}},
+		},
+		{name: "empty", program: "", wantErr: true},
+		{name: "expression", program: "tcp port 80", wantErr: true},
+		{name: "too few instructions", program: "2\n6 0 0 0\n", wantErr: true},
+		{name: "too many instructions", program: "1\n6 0 0 0\n6 0 0 0\n", wantErr: true},
+		{name: "invalid instruction", program: "1\n6 0 0\n", wantErr: true},
+		{name: "out of range", program: "1\n6 300 0 0\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilter(tt.program)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d instructions, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("instruction %d: got %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
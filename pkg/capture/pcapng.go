@@ -0,0 +1,110 @@
+package capture
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const (
+	pcapngBlockSHB uint32 = 0x0A0D0D0A
+	pcapngBlockIDB uint32 = 0x00000001
+	pcapngBlockEPB uint32 = 0x00000006
+
+	pcapngByteOrderMagic uint32 = 0x1A2B3C4D
+	pcapngLinkTypeEther  uint16 = 1
+	pcapngOptEnd         uint16 = 0
+	pcapngOptIfName      uint16 = 2
+)
+
+// Sink receives the packets of a capture.
+type Sink interface {
+	// WritePacket writes a single packet to the sink. The data may be truncated
+	// to the snap length, with the original length of the packet given.
+	WritePacket(ts time.Time, data []byte, length int) error
+
+	// Close flushes and closes the sink.
+	Close() error
+}
+
+// PcapngWriter writes packets from a single interface as a pcapng stream. The
+// section and interface headers are written when the writer is created, so
+// the output is readable (e.g. by Wireshark) as it is being written.
+type PcapngWriter struct {
+	w io.Writer
+}
+
+// NewPcapngWriter creates a pcapng writer for packets captured from the named
+// interface, writing the pcapng headers immediately.
+func NewPcapngWriter(w io.Writer, ifName string, snapLen int) (*PcapngWriter, error) {
+	pw := &PcapngWriter{w: w}
+	shb := make([]byte, 16)
+	binary.LittleEndian.PutUint32(shb[0:], pcapngByteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[4:], 1)
+	binary.LittleEndian.PutUint16(shb[6:], 0)
+	binary.LittleEndian.PutUint64(shb[8:], ^uint64(0))
+	if err := pw.writeBlock(pcapngBlockSHB, shb); err != nil {
+		return nil, err
+	}
+	idb := make([]byte, 8)
+	binary.LittleEndian.PutUint16(idb[0:], pcapngLinkTypeEther)
+	binary.LittleEndian.PutUint32(idb[4:], uint32(snapLen))
+	idb = append(idb, pcapngOption(pcapngOptIfName, []byte(ifName))...)
+	idb = append(idb, pcapngOption(pcapngOptEnd, nil)...)
+	if err := pw.writeBlock(pcapngBlockIDB, idb); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// WritePacket writes the packet as an enhanced packet block. Timestamps are
+// written with the default pcapng resolution of microseconds.
+func (pw *PcapngWriter) WritePacket(ts time.Time, data []byte, length int) error {
+	epb := make([]byte, 20, 20+len(data)+3)
+	micros := uint64(ts.UnixMicro())
+	binary.LittleEndian.PutUint32(epb[0:], 0)
+	binary.LittleEndian.PutUint32(epb[4:], uint32(micros>>32))
+	binary.LittleEndian.PutUint32(epb[8:], uint32(micros))
+	binary.LittleEndian.PutUint32(epb[12:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(epb[16:], uint32(length))
+	epb = append(epb, pad4(data)...)
+	return pw.writeBlock(pcapngBlockEPB, epb)
+}
+
+// Close closes the underlying writer if it is closable.
+func (pw *PcapngWriter) Close() error {
+	if c, ok := pw.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (pw *PcapngWriter) writeBlock(blockType uint32, body []byte) error {
+	total := uint32(12 + len(body))
+	block := make([]byte, 0, total)
+	block = binary.LittleEndian.AppendUint32(block, blockType)
+	block = binary.LittleEndian.AppendUint32(block, total)
+	block = append(block, body...)
+	block = binary.LittleEndian.AppendUint32(block, total)
+	_, err := pw.w.Write(block)
+	if f, ok := pw.w.(interface{ Flush() }); ok && err == nil {
+		f.Flush()
+	}
+	return err
+}
+
+func pcapngOption(code uint16, value []byte) []byte {
+	opt := make([]byte, 4)
+	binary.LittleEndian.PutUint16(opt[0:], code)
+	binary.LittleEndian.PutUint16(opt[2:], uint16(len(value)))
+	return append(opt, pad4(value)...)
+}
+
+// pad4 returns b padded with zeros to a multiple of 4 bytes. The padding is
+// never written into the spare capacity of b, which may belong to the caller.
+func pad4(b []byte) []byte {
+	if rem := len(b) % 4; rem != 0 {
+		return append(b[:len(b):len(b)], make([]byte, 4-rem)...)
+	}
+	return b
+}
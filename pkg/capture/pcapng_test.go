@@ -0,0 +1,97 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+type pcapngBlock struct {
+	blockType uint32
+	body      []byte
+}
+
+// readBlocks splits a pcapng stream into its blocks, checking that the leading
+// and trailing lengths of each block match.
+func readBlocks(t *testing.T, b []byte) []pcapngBlock {
+	t.Helper()
+	blocks := make([]pcapngBlock, 0)
+	for len(b) > 0 {
+		if len(b) < 12 {
+			t.Fatalf("truncated block of %d bytes", len(b))
+		}
+		total := binary.LittleEndian.Uint32(b[4:])
+		if total%4 != 0 || int(total) > len(b) {
+			t.Fatalf("invalid block length %d", total)
+		}
+		if trailing := binary.LittleEndian.Uint32(b[total-4:]); trailing != total {
+			t.Fatalf("trailing block length %d does not match %d", trailing, total)
+		}
+		blocks = append(blocks, pcapngBlock{binary.LittleEndian.Uint32(b), b[8 : total-4]})
+		b = b[total:]
+	}
+	return blocks
+}
+
+func TestPcapngWriter(t *testing.T) {
+	var buf bytes.Buffer
+	pw, err := NewPcapngWriter(&buf, "eth0", 96)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Unix(1700000000, 123456000)
+	// the packet is a slice with spare capacity, which must not be written to
+	// when padding
+	packet := []byte{1, 2, 3, 4, 5, 0xff, 0xff, 0xff}[:5]
+	if err := pw.WritePacket(ts, packet, 60); err != nil {
+		t.Fatal(err)
+	}
+	if packet[:8][5] != 0xff {
+		t.Fatal("padding was written into the packet buffer")
+	}
+
+	blocks := readBlocks(t, buf.Bytes())
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3", len(blocks))
+	}
+
+	shb := blocks[0]
+	if shb.blockType != pcapngBlockSHB || binary.LittleEndian.Uint32(shb.body) != pcapngByteOrderMagic {
+		t.Fatalf("invalid section header block %x", shb.body)
+	}
+	if major := binary.LittleEndian.Uint16(shb.body[4:]); major != 1 {
+		t.Fatalf("got major version %d, want 1", major)
+	}
+
+	idb := blocks[1]
+	if idb.blockType != pcapngBlockIDB {
+		t.Fatalf("got block type %x, want interface description", idb.blockType)
+	}
+	if lt := binary.LittleEndian.Uint16(idb.body); lt != pcapngLinkTypeEther {
+		t.Fatalf("got link type %d, want ethernet", lt)
+	}
+	if snap := binary.LittleEndian.Uint32(idb.body[4:]); snap != 96 {
+		t.Fatalf("got snap length %d, want 96", snap)
+	}
+	opt := idb.body[8:]
+	if code, length := binary.LittleEndian.Uint16(opt), binary.LittleEndian.Uint16(opt[2:]); code != pcapngOptIfName || string(opt[4:4+length]) != "eth0" {
+		t.Fatalf("invalid interface name option %x", opt)
+	}
+
+	epb := blocks[2]
+	if epb.blockType != pcapngBlockEPB {
+		t.Fatalf("got block type %x, want enhanced packet", epb.blockType)
+	}
+	micros := uint64(binary.LittleEndian.Uint32(epb.body[4:]))<<32 | uint64(binary.LittleEndian.Uint32(epb.body[8:]))
+	if micros != uint64(ts.UnixMicro()) {
+		t.Fatalf("got timestamp %d, want %d", micros, ts.UnixMicro())
+	}
+	captured, original := binary.LittleEndian.Uint32(epb.body[12:]), binary.LittleEndian.Uint32(epb.body[16:])
+	if captured != 5 || original != 60 {
+		t.Fatalf("got lengths %d/%d, want 5/60", captured, original)
+	}
+	if !bytes.Equal(epb.body[20:25], packet) || !bytes.Equal(epb.body[25:], []byte{0, 0, 0}) {
+		t.Fatalf("invalid packet data %x", epb.body[20:])
+	}
+}
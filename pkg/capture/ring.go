@@ -0,0 +1,98 @@
+package capture
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// RingFile is a sink that writes pcapng files, rotating to a new file once the
+// current file reaches the maximum size. Only the most recent files are kept,
+// with older files being removed. Files are named using the given path with an
+// increasing sequence number, e.g. capture.0.pcapng, capture.1.pcapng.
+type RingFile struct {
+	path     string
+	ifName   string
+	snapLen  int
+	maxSize  int64
+	maxFiles int
+
+	seq     int
+	size    int64
+	file    *os.File
+	current *PcapngWriter
+}
+
+// NewRingFile creates a rotating pcapng file sink. If maxSize is 0, the file
+// is never rotated. If maxFiles is 0, rotated files are never removed.
+func NewRingFile(path, ifName string, snapLen int, maxSize int64, maxFiles int) (*RingFile, error) {
+	r := &RingFile{
+		path:     path,
+		ifName:   ifName,
+		snapLen:  snapLen,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+		seq:      -1,
+	}
+	if err := r.rotate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RingFile) fileName(seq int) string {
+	if r.maxSize == 0 {
+		return r.path
+	}
+	base := strings.TrimSuffix(r.path, ".pcapng")
+	return fmt.Sprintf("%s.%d.pcapng", base, seq)
+}
+
+func (r *RingFile) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return err
+		}
+	}
+	r.seq++
+	if r.maxFiles > 0 && r.seq >= r.maxFiles {
+		os.Remove(r.fileName(r.seq - r.maxFiles))
+	}
+	f, err := os.Create(r.fileName(r.seq))
+	if err != nil {
+		return fmt.Errorf("could not create capture file: %w", err)
+	}
+	r.file = f
+	r.size = 0
+	writer, err := NewPcapngWriter(r, r.ifName, r.snapLen)
+	if err != nil {
+		return fmt.Errorf("could not write capture file header: %w", err)
+	}
+	r.current = writer
+	return nil
+}
+
+// Write writes to the current file. This is used by the pcapng writer of the
+// current file and should not be called directly.
+func (r *RingFile) Write(b []byte) (int, error) {
+	n, err := r.file.Write(b)
+	r.size += int64(n)
+	return n, err
+}
+
+// WritePacket writes the packet to the current file, rotating the file first
+// if it has reached its maximum size.
+func (r *RingFile) WritePacket(ts time.Time, data []byte, length int) error {
+	if r.maxSize > 0 && r.size >= r.maxSize {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	return r.current.WritePacket(ts, data, length)
+}
+
+// Close closes the current file.
+func (r *RingFile) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,54 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/vishvananda/netlink"
+)
+
+// Domain is the top level domain under which all topology records are
+// published.
+const Domain = "net4me"
+
+// Zone returns the fully qualified DNS zone for the topology with the given
+// name, e.g. `default.net4me.`.
+func Zone(topologyName string) string {
+	return fmt.Sprintf("%s.%s.", strings.ToLower(topologyName), Domain)
+}
+
+// HostRecord returns the fully qualified DNS name for the host in the zone.
+func HostRecord(hostName, zone string) string {
+	return fmt.Sprintf("%s.%s", strings.ToLower(hostName), zone)
+}
+
+// Records returns a map of fully qualified DNS names to addresses for every
+// host in the topology. All non link-local addresses on the ports of each host
+// are included.
+func Records(zone string) map[string][]net.IP {
+	records := make(map[string][]net.IP)
+	for _, h := range topology.Hosts() {
+		ports, err := h.Ports()
+		if err != nil {
+			continue
+		}
+		name := HostRecord(h.Name(), zone)
+		for _, p := range ports {
+			cidrs, err := port.PortAddresses(h.NetworkNamespace(), p, netlink.FAMILY_ALL)
+			if err != nil {
+				continue
+			}
+			for _, cidr := range cidrs {
+				ip, _, err := net.ParseCIDR(cidr)
+				if err != nil || ip.IsLinkLocalUnicast() {
+					continue
+				}
+				records[name] = append(records[name], ip)
+			}
+		}
+	}
+	return records
+}
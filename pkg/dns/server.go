@@ -0,0 +1,191 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neaas/neslink"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// recordTTL is the TTL (seconds) given to all answers, and also the interval at
+// which the records are refreshed from the topology.
+const recordTTL = 10
+
+// Server is an authoritative DNS server for the records of a topology zone.
+// Queries for names outside of the zone are refused, as the server does not
+// perform recursion.
+type Server struct {
+	zone string
+	conn net.PacketConn
+
+	lock      *sync.RWMutex
+	records   map[string][]net.IP
+	refreshed time.Time
+	// refreshing is held while the records are being refreshed, so that only
+	// one refresh runs at a time
+	refreshing *sync.Mutex
+}
+
+// Serve starts a DNS server for the given zone, listening on the given UDP
+// address within the network namespace of the given provider. The socket is
+// created within the namespace, so the server is reachable from the emulated
+// network while being run by the control process. The server runs until
+// Close is called.
+func Serve(nsp neslink.NsProvider, address, zone string) (*Server, error) {
+	s := &Server{
+		zone:       zone,
+		lock:       &sync.RWMutex{},
+		refreshing: &sync.Mutex{},
+	}
+	if err := neslink.Do(
+		nsp,
+		neslink.NAGeneric("dns-listen", func() error {
+			conn, err := net.ListenPacket("udp", address)
+			if err != nil {
+				return err
+			}
+			s.conn = conn
+			return nil
+		}),
+	); err != nil {
+		return nil, fmt.Errorf("could not listen for dns queries: %w", err)
+	}
+	go s.serve()
+	return s, nil
+}
+
+// Zone returns the zone the server is authoritative for.
+func (s *Server) Zone() string {
+	return s.zone
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	return s.conn.Close()
+}
+
+func (s *Server) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		resp, err := s.answer(buf[:n])
+		if err != nil {
+			logrus.WithError(err).WithField("client", addr.String()).Debugln("dns: could not answer query")
+			continue
+		}
+		if _, err := s.conn.WriteTo(resp, addr); err != nil {
+			logrus.WithError(err).WithField("client", addr.String()).Debugln("dns: could not send answer")
+		}
+	}
+}
+
+// lookup returns the addresses for the given name within the zone. Records
+// older than the record TTL are refreshed from the topology in the background,
+// with the stale records being used until the refresh completes. Only the
+// first lookup waits for the records to be loaded.
+func (s *Server) lookup(name string) ([]net.IP, bool) {
+	s.lock.RLock()
+	records, refreshed := s.records, s.refreshed
+	s.lock.RUnlock()
+	switch {
+	case records == nil:
+		s.refreshing.Lock()
+		s.refresh()
+		s.lock.RLock()
+		records = s.records
+		s.lock.RUnlock()
+	case time.Since(refreshed) > recordTTL*time.Second && s.refreshing.TryLock():
+		go s.refresh()
+	}
+	ips, ok := records[strings.ToLower(name)]
+	return ips, ok
+}
+
+// refresh loads the records from the topology unless they were refreshed by
+// another caller in the meantime. It must be called with the refreshing lock
+// held, which it releases.
+func (s *Server) refresh() {
+	defer s.refreshing.Unlock()
+	s.lock.RLock()
+	fresh := s.records != nil && time.Since(s.refreshed) <= recordTTL*time.Second
+	s.lock.RUnlock()
+	if fresh {
+		return
+	}
+	records := Records(s.zone)
+	s.lock.Lock()
+	s.records, s.refreshed = records, time.Now()
+	s.lock.Unlock()
+}
+
+func (s *Server) answer(query []byte) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, err
+	}
+	respHeader := dnsmessage.Header{
+		ID:            header.ID,
+		Response:      true,
+		Authoritative: true,
+		RCode:         dnsmessage.RCodeSuccess,
+	}
+	name := question.Name.String()
+	var ips []net.IP
+	// the zone must match on a label boundary, so e.g. xtest.net4me. is not
+	// within test.net4me.
+	if lower := strings.ToLower(name); lower != s.zone && !strings.HasSuffix(lower, "."+s.zone) {
+		respHeader.Authoritative = false
+		respHeader.RCode = dnsmessage.RCodeRefused
+	} else {
+		var found bool
+		if ips, found = s.lookup(name); !found {
+			respHeader.RCode = dnsmessage.RCodeNameError
+		}
+	}
+	builder := dnsmessage.NewBuilder(make([]byte, 0, 512), respHeader)
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(question); err != nil {
+		return nil, err
+	}
+	if err := builder.StartAnswers(); err != nil {
+		return nil, err
+	}
+	resource := dnsmessage.ResourceHeader{
+		Name:  question.Name,
+		Class: dnsmessage.ClassINET,
+		TTL:   recordTTL,
+	}
+	for _, ip := range ips {
+		switch {
+		case ip.To4() != nil && (question.Type == dnsmessage.TypeA || question.Type == dnsmessage.TypeALL):
+			var a [4]byte
+			copy(a[:], ip.To4())
+			if err := builder.AResource(resource, dnsmessage.AResource{A: a}); err != nil {
+				return nil, err
+			}
+		case ip.To4() == nil && (question.Type == dnsmessage.TypeAAAA || question.Type == dnsmessage.TypeALL):
+			var aaaa [16]byte
+			copy(aaaa[:], ip.To16())
+			if err := builder.AAAAResource(resource, dnsmessage.AAAAResource{AAAA: aaaa}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return builder.Finish()
+}
@@ -0,0 +1,79 @@
+package dns
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func testServer(records map[string][]net.IP) *Server {
+	return &Server{
+		zone:       Zone("test"),
+		lock:       &sync.RWMutex{},
+		refreshing: &sync.Mutex{},
+		records:    records,
+		refreshed:  time.Now(),
+	}
+}
+
+func query(t *testing.T, name string, typ dnsmessage.Type) []byte {
+	t.Helper()
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 1})
+	if err := builder.StartQuestions(); err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(name),
+		Type:  typ,
+		Class: dnsmessage.ClassINET,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := builder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestAnswer(t *testing.T) {
+	s := testServer(map[string][]net.IP{
+		HostRecord("site1", Zone("test")): {net.ParseIP("10.0.0.1"), net.ParseIP("fd00::1")},
+	})
+	tests := []struct {
+		name    string
+		query   string
+		typ     dnsmessage.Type
+		rcode   dnsmessage.RCode
+		answers int
+	}{
+		{"a record", "site1.test.net4me.", dnsmessage.TypeA, dnsmessage.RCodeSuccess, 1},
+		{"aaaa record", "site1.test.net4me.", dnsmessage.TypeAAAA, dnsmessage.RCodeSuccess, 1},
+		{"case insensitive", "SITE1.test.net4me.", dnsmessage.TypeA, dnsmessage.RCodeSuccess, 1},
+		{"unknown host", "site2.test.net4me.", dnsmessage.TypeA, dnsmessage.RCodeNameError, 0},
+		{"outside zone", "example.com.", dnsmessage.TypeA, dnsmessage.RCodeRefused, 0},
+		{"zone suffix without label boundary", "xtest.net4me.", dnsmessage.TypeA, dnsmessage.RCodeRefused, 0},
+		{"zone apex", "test.net4me.", dnsmessage.TypeA, dnsmessage.RCodeNameError, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.answer(query(t, tt.query, tt.typ))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var msg dnsmessage.Message
+			if err := msg.Unpack(resp); err != nil {
+				t.Fatal(err)
+			}
+			if msg.RCode != tt.rcode {
+				t.Fatalf("got rcode %s, want %s", msg.RCode, tt.rcode)
+			}
+			if len(msg.Answers) != tt.answers {
+				t.Fatalf("got %d answers, want %d", len(msg.Answers), tt.answers)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dotQuote returns the string as a quoted DOT ID.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// dotAttrs formats the attributes as a DOT attribute list. Attributes that
+// graphviz does not know (e.g. labels) are kept for other tools to use.
+func dotAttrs(keys []string, attrs map[string]string) string {
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if v, ok := attrs[k]; ok && v != "" {
+			parts = append(parts, fmt.Sprintf("%s=%s", dotQuote(k), dotQuote(v)))
+		}
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func writeDOT(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "graph %s {\n", dotQuote(g.Name))
+	fmt.Fprintln(bw, `  node [shape=box, style="rounded,filled", fontname="Helvetica"];`)
+	fmt.Fprintln(bw, `  edge [fontname="Helvetica", fontsize=10];`)
+	for _, n := range g.Nodes {
+		attrs := map[string]string{
+			"label":     fmt.Sprintf("%s\n%s", n.ID, n.Device),
+			"fillcolor": strings.ToLower(n.Color),
+			"device":    n.Device,
+			"state":     n.State,
+			"icon":      n.Icon,
+		}
+		keys := []string{"label", "fillcolor", "device", "state", "icon"}
+		for _, k := range sortedKeys(n.Labels) {
+			attrs["label:"+k] = n.Labels[k]
+			keys = append(keys, "label:"+k)
+		}
+		fmt.Fprintf(bw, "  %s %s;\n", dotQuote(n.ID), dotAttrs(keys, attrs))
+	}
+	for _, e := range g.Edges {
+		attrs := map[string]string{
+			"taillabel":        e.Source.Port,
+			"headlabel":        e.Target.Port,
+			"label":            edgeLabel(e),
+			"source_addresses": strings.Join(e.Source.Addresses, ","),
+			"target_addresses": strings.Join(e.Target.Addresses, ","),
+		}
+		keys := []string{"taillabel", "headlabel", "label", "source_addresses", "target_addresses"}
+		for _, side := range []struct {
+			name     string
+			endpoint Endpoint
+		}{{"source", e.Source}, {"target", e.Target}} {
+			if side.endpoint.Emulation != nil {
+				attrs[side.name+"_emulation"] = emulationString(side.endpoint.Emulation)
+				keys = append(keys, side.name+"_emulation")
+			}
+			for _, k := range sortedKeys(side.endpoint.Counters) {
+				key := side.name + "_" + k
+				attrs[key] = fmt.Sprint(side.endpoint.Counters[k])
+				keys = append(keys, key)
+			}
+		}
+		fmt.Fprintf(bw, "  %s -- %s %s;\n", dotQuote(e.Source.Node), dotQuote(e.Target.Node), dotAttrs(keys, attrs))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// edgeLabel summarises the addresses and emulation of a link for diagrams.
+func edgeLabel(e Edge) string {
+	lines := make([]string, 0)
+	for _, side := range []Endpoint{e.Source, e.Target} {
+		lines = append(lines, side.Addresses...)
+		if side.Emulation != nil {
+			lines = append(lines, emulationString(side.Emulation))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
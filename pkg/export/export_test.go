@@ -0,0 +1,162 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ai4networks/net4me/pkg/port"
+)
+
+func testGraph() *Graph {
+	return &Graph{
+		Name: "lab",
+		Nodes: []Node{
+			{ID: "h1", Device: "netns", State: "running", Labels: map[string]string{"role": "client"}, Icon: "host", Color: "Blue"},
+			{ID: `r"1`, Device: "dind", State: "stopped", Labels: map[string]string{}, Icon: "router", Color: "Red"},
+		},
+		Edges: []Edge{
+			{
+				ID: `h1:eth0-r"1:eth1`,
+				Source: Endpoint{
+					Node:      "h1",
+					Port:      "eth0",
+					Addresses: []string{"10.0.0.2/30"},
+					Emulation: &port.Emulation{Latency: 10000, Jitter: 2000, Loss: 0.5},
+					Counters:  map[string]any{"tx_bytes": uint64(20), "rx_bytes": uint64(10)},
+				},
+				Target: Endpoint{
+					Node:      `r"1`,
+					Port:      "eth1",
+					Addresses: []string{"10.0.0.1/30", "fd00::1/64"},
+					Counters:  map[string]any{},
+				},
+			},
+		},
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	want := `graph "lab" {
+  node [shape=box, style="rounded,filled", fontname="Helvetica"];
+  edge [fontname="Helvetica", fontsize=10];
+  "h1" ["label"="h1\nnetns", "fillcolor"="blue", "device"="netns", "state"="running", "icon"="host", "label:role"="client"];
+  "r\"1" ["label"="r\"1\ndind", "fillcolor"="red", "device"="dind", "state"="stopped", "icon"="router"];
+  "h1" -- "r\"1" ["taillabel"="eth0", "headlabel"="eth1", "label"="10.0.0.2/30\n10ms ±2ms 0.5% loss\n10.0.0.1/30\nfd00::1/64", "source_addresses"="10.0.0.2/30", "target_addresses"="10.0.0.1/30,fd00::1/64", "source_emulation"="10ms ±2ms 0.5% loss", "source_rx_bytes"="10", "source_tx_bytes"="20"];
+}
+`
+	var b bytes.Buffer
+	if err := Write(&b, testGraph(), FormatDOT); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestWriteGraphML(t *testing.T) {
+	var b bytes.Buffer
+	if err := Write(&b, testGraph(), FormatGraphML); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), xml.Header) {
+		t.Fatal("missing xml header")
+	}
+	var doc graphml
+	if err := xml.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Graph.ID != "lab" || len(doc.Graph.Nodes) != 2 || len(doc.Graph.Edges) != 1 {
+		t.Fatalf("unexpected graph %+v", doc.Graph)
+	}
+	keys := make(map[string]graphmlKey)
+	for _, k := range doc.Keys {
+		if _, ok := keys[k.ID]; ok {
+			t.Errorf("key %s declared more than once", k.ID)
+		}
+		keys[k.ID] = k
+	}
+	data := func(d []graphmlData) map[string]string {
+		values := make(map[string]string)
+		for _, v := range d {
+			if _, ok := keys[v.Key]; !ok {
+				t.Errorf("data key %s is not declared", v.Key)
+			}
+			values[v.Key] = v.Value
+		}
+		return values
+	}
+	tests := []struct {
+		name string
+		got  map[string]string
+		want map[string]string
+	}{
+		{
+			name: "node",
+			got:  data(doc.Graph.Nodes[0].Data),
+			want: map[string]string{"n_device": "netns", "n_state": "running", "n_icon": "host", "n_color": "Blue", "n_label.role": "client"},
+		},
+		{
+			name: "edge",
+			got:  data(doc.Graph.Edges[0].Data),
+			want: map[string]string{
+				"e_source_port":       "eth0",
+				"e_source_addresses":  "10.0.0.2/30",
+				"e_source_latency_us": "10000",
+				"e_source_jitter_us":  "2000",
+				"e_source_loss":       "0.5",
+				"e_source_rx_bytes":   "10",
+				"e_source_tx_bytes":   "20",
+				"e_target_port":       "eth1",
+				"e_target_addresses":  "10.0.0.1/30,fd00::1/64",
+			},
+		},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s data: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if e := doc.Graph.Edges[0]; e.Source != "h1" || e.Target != `r"1` {
+		t.Errorf("got edge %s -- %s", e.Source, e.Target)
+	}
+	if k := keys["e_source_loss"]; k.For != "edge" || k.Name != "source_loss" || k.Type != "double" {
+		t.Errorf("unexpected key %+v", k)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := Write(&b, testGraph(), FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var doc jsonGraph
+	if err := json.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Graph.ID != "lab" || doc.Graph.Directed {
+		t.Fatalf("unexpected graph %+v", doc.Graph)
+	}
+	if n, ok := doc.Graph.Nodes[`r"1`]; !ok || n.Label != `r"1` || n.Metadata.Device != "dind" {
+		t.Errorf("unexpected node %+v", n)
+	}
+	if len(doc.Graph.Edges) != 1 {
+		t.Fatalf("got %d edges", len(doc.Graph.Edges))
+	}
+	e := doc.Graph.Edges[0]
+	if e.Source != "h1" || e.Target != `r"1` || e.Label != edgeLabel(testGraph().Edges[0]) {
+		t.Errorf("unexpected edge %+v", e)
+	}
+	if em := e.Metadata.Source.Emulation; em == nil || *em != (port.Emulation{Latency: 10000, Jitter: 2000, Loss: 0.5}) {
+		t.Errorf("unexpected emulation %+v", em)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, testGraph(), "svg"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,150 @@
+// Package export renders the topology as a graph in formats understood by
+// common graph and diagram tools.
+package export
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"sort"
+
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	FormatDOT     = "dot"
+	FormatGraphML = "graphml"
+	FormatJSON    = "json"
+)
+
+// Graph is a snapshot of the topology as a graph. Nodes are identified by the
+// name of their host, as it is stable across runs of net4me.
+type Graph struct {
+	Name  string `json:"name"`
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Node is a host of the topology.
+type Node struct {
+	ID     string            `json:"id"`
+	Device string            `json:"device"`
+	State  string            `json:"state"`
+	Labels map[string]string `json:"labels"`
+	Icon   string            `json:"icon"`
+	Color  string            `json:"color"`
+}
+
+// Endpoint is the port of a host at one end of a link.
+type Endpoint struct {
+	Node      string          `json:"node"`
+	Port      string          `json:"port"`
+	Addresses []string        `json:"addresses"`
+	Emulation *port.Emulation `json:"emulation,omitempty"`
+	Counters  map[string]any  `json:"counters"`
+}
+
+// Edge is a link between two hosts.
+type Edge struct {
+	ID     string   `json:"id"`
+	Source Endpoint `json:"source"`
+	Target Endpoint `json:"target"`
+}
+
+// Build creates a graph of the given hosts and the links between them. Links
+// to hosts that are not given are left out. The state of each host includes
+// its health, and links are discovered rather than taken from the link cache,
+// so that their counters are current.
+func Build(hosts []*topology.Host) *Graph {
+	g := &Graph{
+		Name:  topology.Name(),
+		Nodes: make([]Node, 0, len(hosts)),
+		Edges: make([]Edge, 0),
+	}
+	included := make(map[string]bool)
+	for _, h := range hosts {
+		included[h.ID()] = true
+		labels := make(map[string]string)
+		maps.Copy(labels, h.Labels())
+		g.Nodes = append(g.Nodes, Node{
+			ID:     h.Name(),
+			Device: h.Device(),
+			State:  string(h.Health()),
+			Labels: labels,
+			Icon:   h.Node().Manager().Icon(),
+			Color:  h.Node().Manager().Color(),
+		})
+	}
+	for _, l := range topology.DiscoverLinks() {
+		if !included[l.SelfHost().ID()] || !included[l.PeerHost().ID()] {
+			continue
+		}
+		self := fmt.Sprintf("%s:%s", l.SelfHost().Name(), l.SelfPort().Attrs().Name)
+		peer := fmt.Sprintf("%s:%s", l.PeerHost().Name(), l.PeerPort().Attrs().Name)
+		g.Edges = append(g.Edges, Edge{
+			ID:     self + "-" + peer,
+			Source: endpoint(l.SelfHost(), l.SelfPort()),
+			Target: endpoint(l.PeerHost(), l.PeerPort()),
+		})
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.Slice(g.Edges, func(i, j int) bool { return g.Edges[i].ID < g.Edges[j].ID })
+	return g
+}
+
+func endpoint(h *topology.Host, p port.Port) Endpoint {
+	e := Endpoint{
+		Node:      h.Name(),
+		Port:      p.Attrs().Name,
+		Addresses: make([]string, 0),
+		Counters:  port.Statistics(p),
+	}
+	cidrs, err := port.PortAddresses(h.NetworkNamespace(), p, netlink.FAMILY_ALL)
+	if err != nil {
+		logrus.WithError(err).WithField("host", h.Name()).Warnln("could not get port addresses for export")
+	}
+	for _, cidr := range cidrs {
+		if ip, _, err := net.ParseCIDR(cidr); err == nil && !ip.IsLinkLocalUnicast() {
+			e.Addresses = append(e.Addresses, cidr)
+		}
+	}
+	if e.Emulation, err = port.PortEmulation(h.NetworkNamespace(), p); err != nil {
+		logrus.WithError(err).WithField("host", h.Name()).Warnln("could not get port emulation for export")
+	}
+	return e
+}
+
+// Write renders the graph to w in the given format.
+func Write(w io.Writer, g *Graph, format string) error {
+	switch format {
+	case FormatDOT:
+		return writeDOT(w, g)
+	case FormatGraphML:
+		return writeGraphML(w, g)
+	case FormatJSON:
+		return writeJSON(w, g)
+	}
+	return fmt.Errorf("unknown export format: %s", format)
+}
+
+// sortedKeys returns the keys of the map in order, so that output is stable.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// emulationString describes the emulation in a short human readable form.
+func emulationString(e *port.Emulation) string {
+	if e == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.3gms ±%.3gms %.3g%% loss", float64(e.Latency)/1000, float64(e.Jitter)/1000, e.Loss)
+}
@@ -0,0 +1,129 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type graphml struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphmlKey `xml:"key"`
+	Graph   graphmlGraph `xml:"graph"`
+}
+
+type graphmlKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphmlGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphmlNode `xml:"node"`
+	Edges       []graphmlEdge `xml:"edge"`
+}
+
+type graphmlNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphmlData `xml:"data"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphmlKeys declares the data keys of a graphml document as they are used.
+type graphmlKeys struct {
+	keys []graphmlKey
+	seen map[string]bool
+}
+
+// data returns the data for the attribute, declaring its key if needed.
+func (k *graphmlKeys) data(scope, name, typ string, value any) graphmlData {
+	id := scope[:1] + "_" + name
+	if !k.seen[id] {
+		k.seen[id] = true
+		k.keys = append(k.keys, graphmlKey{ID: id, For: scope, Name: name, Type: typ})
+	}
+	return graphmlData{Key: id, Value: fmt.Sprint(value)}
+}
+
+func writeGraphML(w io.Writer, g *Graph) error {
+	keys := &graphmlKeys{seen: make(map[string]bool)}
+	doc := graphml{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Graph: graphmlGraph{
+			ID:          g.Name,
+			EdgeDefault: "undirected",
+			Nodes:       make([]graphmlNode, 0, len(g.Nodes)),
+			Edges:       make([]graphmlEdge, 0, len(g.Edges)),
+		},
+	}
+	for _, n := range g.Nodes {
+		node := graphmlNode{
+			ID: n.ID,
+			Data: []graphmlData{
+				keys.data("node", "device", "string", n.Device),
+				keys.data("node", "state", "string", n.State),
+				keys.data("node", "icon", "string", n.Icon),
+				keys.data("node", "color", "string", n.Color),
+			},
+		}
+		for _, k := range sortedKeys(n.Labels) {
+			node.Data = append(node.Data, keys.data("node", "label."+k, "string", n.Labels[k]))
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for _, e := range g.Edges {
+		edge := graphmlEdge{
+			ID:     e.ID,
+			Source: e.Source.Node,
+			Target: e.Target.Node,
+			Data:   make([]graphmlData, 0),
+		}
+		for _, side := range []struct {
+			name     string
+			endpoint Endpoint
+		}{{"source", e.Source}, {"target", e.Target}} {
+			edge.Data = append(edge.Data,
+				keys.data("edge", side.name+"_port", "string", side.endpoint.Port),
+				keys.data("edge", side.name+"_addresses", "string", strings.Join(side.endpoint.Addresses, ",")),
+			)
+			if em := side.endpoint.Emulation; em != nil {
+				edge.Data = append(edge.Data,
+					keys.data("edge", side.name+"_latency_us", "long", em.Latency),
+					keys.data("edge", side.name+"_jitter_us", "long", em.Jitter),
+					keys.data("edge", side.name+"_loss", "double", em.Loss),
+				)
+			}
+			for _, k := range sortedKeys(side.endpoint.Counters) {
+				edge.Data = append(edge.Data, keys.data("edge", side.name+"_"+k, "long", side.endpoint.Counters[k]))
+			}
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+	doc.Keys = keys.keys
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("could not encode graphml: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonGraph is the graph in JSON Graph Format (https://jsongraphformat.info),
+// with the host and link attributes held as metadata.
+type jsonGraph struct {
+	Graph struct {
+		ID       string              `json:"id"`
+		Label    string              `json:"label"`
+		Directed bool                `json:"directed"`
+		Nodes    map[string]jsonNode `json:"nodes"`
+		Edges    []jsonEdge          `json:"edges"`
+	} `json:"graph"`
+}
+
+type jsonNode struct {
+	Label    string `json:"label"`
+	Metadata Node   `json:"metadata"`
+}
+
+type jsonEdge struct {
+	ID       string `json:"id"`
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Label    string `json:"label,omitempty"`
+	Metadata Edge   `json:"metadata"`
+}
+
+func writeJSON(w io.Writer, g *Graph) error {
+	var doc jsonGraph
+	doc.Graph.ID = g.Name
+	doc.Graph.Label = g.Name
+	doc.Graph.Nodes = make(map[string]jsonNode)
+	doc.Graph.Edges = make([]jsonEdge, 0, len(g.Edges))
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes[n.ID] = jsonNode{Label: n.ID, Metadata: n}
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, jsonEdge{
+			ID:       e.ID,
+			Source:   e.Source.Node,
+			Target:   e.Target.Node,
+			Label:    edgeLabel(e),
+			Metadata: e,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,187 @@
+// Package gc finds and removes the resources that net4me leaves behind when a
+// command is interrupted, such as link ports left in the port pool, or
+// containers of sites that were never started.
+package gc
+
+import (
+	"fmt"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/ai4networks/net4me/pkg/state"
+	"github.com/ai4networks/net4me/pkg/topology"
+)
+
+// Options control which orphans are found.
+type Options struct {
+	node.GCOptions
+	// Known is the document of the topology as it should be, if given. Hosts
+	// of the topology that are not in the document are orphans.
+	Known *state.Document
+}
+
+// Orphan is a resource that is not in use by the topology.
+type Orphan struct {
+	node.Orphan
+	Topology string `json:"topology,omitempty"`
+
+	remove func() error
+}
+
+// Remove removes the orphaned resource.
+func (o Orphan) Remove() error {
+	return o.remove()
+}
+
+// Find returns the orphans of the topology, or of all topologies if set in the
+// options. The orphans are ordered so that they can be removed in turn, with
+// hosts first and the port pools they use last.
+func Find(t *topology.Topology, options Options) ([]Orphan, error) {
+	options.Topology = t.Name()
+	orphans := make([]Orphan, 0)
+	if options.Known != nil {
+		orphans = append(orphans, unknownHosts(t, options.Known)...)
+	}
+
+	pools := []*port.Pool{t.Pool()}
+	if options.All {
+		var err error
+		if pools, err = port.Pools(); err != nil {
+			return nil, err
+		}
+	}
+	for _, pool := range pools {
+		poolOrphans, err := poolPorts(pool)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, poolOrphans...)
+	}
+
+	for _, m := range node.Managers() {
+		c, ok := m.(node.Collector)
+		if !ok {
+			continue
+		}
+		managerOrphans, err := c.Orphans(options.GCOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find orphans of %s: %w", m.Device(), err)
+		}
+		for _, o := range managerOrphans {
+			orphans = append(orphans, Orphan{
+				Orphan: o,
+				remove: func() error { return c.RemoveOrphan(o) },
+			})
+		}
+	}
+
+	if options.All {
+		for _, pool := range pools {
+			unused, err := unusedPool(pool)
+			if err != nil {
+				return nil, err
+			}
+			if unused {
+				orphans = append(orphans, Orphan{
+					Orphan: node.Orphan{
+						Kind:   "pool",
+						ID:     pool.Name(),
+						Name:   pool.Name(),
+						Reason: "port pool of a topology without hosts",
+					},
+					Topology: pool.Topology(),
+					remove:   pool.Delete,
+				})
+			}
+		}
+	}
+	return orphans, nil
+}
+
+// Remove removes the orphans in turn, returning the error of each, if any.
+func Remove(orphans []Orphan) []error {
+	errs := make([]error, len(orphans))
+	for i, o := range orphans {
+		errs[i] = o.Remove()
+	}
+	return errs
+}
+
+// unknownHosts returns the hosts of the topology that are not in the document.
+func unknownHosts(t *topology.Topology, doc *state.Document) []Orphan {
+	known := make(map[string]bool)
+	for _, h := range doc.Hosts {
+		known[h.Name] = true
+	}
+	orphans := make([]Orphan, 0)
+	for _, h := range t.Hosts() {
+		if known[h.Name()] {
+			continue
+		}
+		orphans = append(orphans, Orphan{
+			Orphan: node.Orphan{
+				Device: h.Device(),
+				Kind:   "host",
+				ID:     h.NodeID(),
+				Name:   h.Name(),
+				Reason: "host is not in the known topology",
+			},
+			Topology: t.Name(),
+			remove:   h.Remove,
+		})
+	}
+	return orphans
+}
+
+// poolPorts returns the ports in the pool. As links are only in the pool while
+// being created or removed, any port there was left behind. A port whose peer
+// is also in the pool is reported once, as destroying either removes both.
+func poolPorts(pool *port.Pool) ([]Orphan, error) {
+	ports, err := pool.Ports()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ports of pool %s: %w", pool.Name(), err)
+	}
+	byIndex := make(map[int]port.Port)
+	for _, p := range ports {
+		byIndex[p.Attrs().Index] = p
+	}
+	orphans := make([]Orphan, 0)
+	for _, p := range ports {
+		reason := "link port whose peer was attached to a host"
+		if peer, ok := byIndex[p.Attrs().ParentIndex]; ok && peer.Attrs().ParentIndex == p.Attrs().Index {
+			if peer.Attrs().Index < p.Attrs().Index {
+				continue
+			}
+			reason = "link ports that were never attached"
+		}
+		orphans = append(orphans, Orphan{
+			Orphan: node.Orphan{
+				Kind:   "pool-port",
+				ID:     fmt.Sprintf("%s/%d", pool.Name(), p.Attrs().Index),
+				Name:   p.Attrs().Name,
+				Reason: reason,
+			},
+			Topology: pool.Topology(),
+			remove:   func() error { return pool.DestroyPortPair(p) },
+		})
+	}
+	return orphans, nil
+}
+
+// unusedPool returns true if the pool belongs to a topology other than the
+// default which has no nodes.
+func unusedPool(pool *port.Pool) (bool, error) {
+	if pool.Name() == port.DefaultPool {
+		return false, nil
+	}
+	for _, m := range node.Managers() {
+		nodes, err := m.Nodes(node.FilterByTopology(pool.Topology()))
+		if err != nil {
+			return false, fmt.Errorf("failed to list nodes of %s: %w", m.Device(), err)
+		}
+		if len(nodes) > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
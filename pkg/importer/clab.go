@@ -0,0 +1,176 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// clabTopology is the subset of a containerlab topology definition that can be
+// imported.
+type clabTopology struct {
+	Name     string `yaml:"name"`
+	Topology struct {
+		Defaults clabNode            `yaml:"defaults"`
+		Kinds    map[string]clabNode `yaml:"kinds"`
+		Nodes    map[string]clabNode `yaml:"nodes"`
+		Links    []clabLink          `yaml:"links"`
+	} `yaml:"topology"`
+}
+
+type clabNode struct {
+	Kind   string            `yaml:"kind"`
+	Image  string            `yaml:"image"`
+	Labels map[string]string `yaml:"labels"`
+	Exec   []string          `yaml:"exec"`
+}
+
+// clabLink is a link in either the brief (`node:iface` strings) or extended
+// (`{node, interface}` maps) endpoint syntax.
+type clabLink struct {
+	Type      string      `yaml:"type"`
+	Endpoints []yaml.Node `yaml:"endpoints"`
+}
+
+// clabSpecialNodes are the endpoint nodes containerlab uses to connect
+// interfaces to the host rather than to another node.
+var clabSpecialNodes = map[string]bool{
+	"host":     true,
+	"mgmt-net": true,
+	"macvlan":  true,
+}
+
+// clabSwitchKinds are the containerlab kinds that are imported as switches.
+var clabSwitchKinds = map[string]bool{
+	"bridge":     true,
+	"ovs-bridge": true,
+}
+
+var (
+	clabAddrPattern  = regexp.MustCompile(`^ip\s+(?:-[46]\s+)?a(?:ddr(?:ess)?)?\s+add\s+(\S+)\s+dev\s+(\S+)`)
+	clabRoutePattern = regexp.MustCompile(`^ip\s+(?:-[46]\s+)?r(?:oute)?\s+(?:add|replace)\s+(\S+)(?:\s+via\s+(\S+))?\s+dev\s+(\S+)`)
+)
+
+func importContainerlab(r io.Reader, b *builder) error {
+	var t clabTopology
+	if err := yaml.NewDecoder(r).Decode(&t); err != nil {
+		return fmt.Errorf("could not decode containerlab topology: %w", err)
+	}
+	b.name = t.Name
+	names := make([]string, 0, len(t.Topology.Nodes))
+	for name := range t.Topology.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	nodes := make(map[string]clabNode)
+	for _, name := range names {
+		n := t.Topology.Nodes[name]
+		// node values take precedence over those of its kind, and then the
+		// topology defaults
+		if n.Kind == "" {
+			n.Kind = t.Topology.Defaults.Kind
+		}
+		kind := t.Topology.Kinds[n.Kind]
+		if n.Image == "" {
+			n.Image = kind.Image
+		}
+		if n.Image == "" {
+			n.Image = t.Topology.Defaults.Image
+		}
+		n.Exec = append(append(append([]string{}, t.Topology.Defaults.Exec...), kind.Exec...), n.Exec...)
+		nodes[name] = n
+
+		labels := make(map[string]string)
+		for _, l := range []map[string]string{t.Topology.Defaults.Labels, kind.Labels, n.Labels} {
+			for k, v := range l {
+				labels[k] = v
+			}
+		}
+		labels["clab.kind"] = n.Kind
+		device := b.options.HostDevice
+		if clabSwitchKinds[n.Kind] {
+			device = b.options.SwitchDevice
+		} else if n.Image != "" {
+			labels["clab.image"] = n.Image
+			b.warn("image %s of node %s is not used, the node is a %s device", n.Image, name, device)
+		}
+		if err := b.addHost(name, device, labels, nil); err != nil {
+			return err
+		}
+	}
+
+	for i, l := range t.Topology.Links {
+		if l.Type != "" && l.Type != "veth" {
+			b.warn("link %d of type %s is not supported", i, l.Type)
+			continue
+		}
+		if len(l.Endpoints) != 2 {
+			return fmt.Errorf("link %d must have two endpoints", i)
+		}
+		endpoints := make([]struct{ node, iface string }, 2)
+		for j, e := range l.Endpoints {
+			switch e.Kind {
+			case yaml.ScalarNode:
+				node, iface, _ := strings.Cut(e.Value, ":")
+				endpoints[j].node, endpoints[j].iface = node, iface
+			case yaml.MappingNode:
+				var endpoint struct {
+					Node      string `yaml:"node"`
+					Interface string `yaml:"interface"`
+				}
+				if err := e.Decode(&endpoint); err != nil {
+					return fmt.Errorf("invalid endpoint of link %d: %w", i, err)
+				}
+				endpoints[j].node, endpoints[j].iface = endpoint.Node, endpoint.Interface
+			default:
+				return fmt.Errorf("invalid endpoint of link %d", i)
+			}
+		}
+		if special := clabSpecial(t.Topology.Nodes, endpoints[0].node, endpoints[1].node); special != "" {
+			b.warn("link %d to %s is not supported", i, special)
+			continue
+		}
+		a, err := b.endpoint(endpoints[0].node, endpoints[0].iface)
+		if err != nil {
+			return err
+		}
+		z, err := b.endpoint(endpoints[1].node, endpoints[1].iface)
+		if err != nil {
+			return err
+		}
+		b.addLink(a, z)
+	}
+
+	// exec commands are not run, but addresses and routes they add to linked
+	// interfaces are kept
+	for _, name := range names {
+		for _, cmd := range nodes[name].Exec {
+			cmd = strings.TrimSpace(cmd)
+			if m := clabAddrPattern.FindStringSubmatch(cmd); m != nil {
+				b.addAddress(name, m[2], m[1])
+				continue
+			}
+			if m := clabRoutePattern.FindStringSubmatch(cmd); m != nil {
+				b.addRoute(name, m[3], m[1], m[2])
+				continue
+			}
+			b.warn("exec command of node %s is not imported: %s", name, cmd)
+		}
+	}
+	return nil
+}
+
+// clabSpecial returns the first of the endpoint nodes that is a special
+// containerlab endpoint rather than a node of the topology, if any.
+func clabSpecial(nodes map[string]clabNode, endpoints ...string) string {
+	for _, e := range endpoints {
+		if _, ok := nodes[e]; !ok && clabSpecialNodes[e] {
+			return e
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// gns3Project is the subset of a GNS3 project file that can be imported.
+type gns3Project struct {
+	Name     string `json:"name"`
+	Topology struct {
+		Nodes []gns3Node `json:"nodes"`
+		Links []gns3Link `json:"links"`
+	} `json:"topology"`
+}
+
+type gns3Node struct {
+	NodeID   string `json:"node_id"`
+	Name     string `json:"name"`
+	NodeType string `json:"node_type"`
+}
+
+type gns3Link struct {
+	Nodes []struct {
+		NodeID        string `json:"node_id"`
+		AdapterNumber int    `json:"adapter_number"`
+		PortNumber    int    `json:"port_number"`
+	} `json:"nodes"`
+	// Filters hold the packet filters of the link, each a list of values.
+	// Delay is given as latency and jitter in ms, and packet loss as a
+	// percentage.
+	Filters map[string][]any `json:"filters"`
+	Suspend bool             `json:"suspend"`
+}
+
+// gns3SwitchTypes are the GNS3 node types that are imported as switches.
+var gns3SwitchTypes = map[string]bool{
+	"ethernet_switch": true,
+	"ethernet_hub":    true,
+}
+
+func importGNS3(r io.Reader, b *builder) error {
+	var p gns3Project
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return fmt.Errorf("could not decode gns3 project: %w", err)
+	}
+	b.name = p.Name
+	names := make(map[string]string)
+	for _, n := range p.Topology.Nodes {
+		labels := map[string]string{"gns3.type": n.NodeType}
+		device, config := b.options.HostDevice, map[string]any(nil)
+		switch {
+		case gns3SwitchTypes[n.NodeType]:
+			device = b.options.SwitchDevice
+		case n.NodeType == "nat":
+			// the gns3 nat node gives egress to the host network
+			device, config = "netns", map[string]any{"nat": true}
+		case n.NodeType == "cloud":
+			b.warn("cloud node %s is not imported", n.Name)
+			continue
+		}
+		if err := b.addHost(n.Name, device, labels, config); err != nil {
+			return err
+		}
+		names[n.NodeID] = n.Name
+	}
+
+	for i, l := range p.Topology.Links {
+		if len(l.Nodes) != 2 {
+			return fmt.Errorf("link %d must have two nodes", i)
+		}
+		endpoints := make([]string, 2)
+		skip := false
+		for j, n := range l.Nodes {
+			name, ok := names[n.NodeID]
+			if !ok {
+				skip = true
+				break
+			}
+			endpoints[j] = name
+		}
+		if skip {
+			b.warn("link %d is to a node that is not imported", i)
+			continue
+		}
+		a, err := b.endpoint(endpoints[0], fmt.Sprintf("e%d-%d", l.Nodes[0].AdapterNumber, l.Nodes[0].PortNumber))
+		if err != nil {
+			return err
+		}
+		z, err := b.endpoint(endpoints[1], fmt.Sprintf("e%d-%d", l.Nodes[1].AdapterNumber, l.Nodes[1].PortNumber))
+		if err != nil {
+			return err
+		}
+		var delay, jitter, loss any
+		if d := l.Filters["delay"]; len(d) > 0 {
+			delay = d[0]
+			if len(d) > 1 {
+				jitter = d[1]
+			}
+		}
+		if pl := l.Filters["packet_loss"]; len(pl) > 0 {
+			loss = pl[0]
+		}
+		for filter := range l.Filters {
+			if filter != "delay" && filter != "packet_loss" {
+				b.warn("%s filter of link %s-%s can not be emulated", filter, endpoints[0], endpoints[1])
+			}
+		}
+		e, err := emulation(delay, jitter, loss)
+		if err != nil {
+			return fmt.Errorf("invalid filters of link %d: %w", i, err)
+		}
+		// gns3 applies filters at a single point on the link, so they are
+		// emulated on the first port only
+		a.Emulation = e
+		a.Up, z.Up = !l.Suspend, !l.Suspend
+		b.addLink(a, z)
+	}
+	return nil
+}
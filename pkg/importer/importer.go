@@ -0,0 +1,306 @@
+// Package importer translates topologies described in the formats of other
+// network emulators into net4me topology documents, which can then be
+// restored like any saved topology.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/ai4networks/net4me/pkg/state"
+)
+
+const (
+	FormatContainerlab = "clab"
+	FormatMininet      = "mininet"
+	FormatGNS3         = "gns3"
+)
+
+// Options control how the nodes of an imported topology are mapped to net4me
+// devices.
+type Options struct {
+	// HostDevice is the device used for end hosts (e.g. dind or netns).
+	HostDevice string
+	// SwitchDevice is the device used for switches and hubs.
+	SwitchDevice string
+}
+
+// DefaultOptions maps hosts to dind sites and switches to ovs bridges.
+var DefaultOptions = Options{
+	HostDevice:   "dind",
+	SwitchDevice: "ovs",
+}
+
+// DetectFormat returns the format implied by the name of the file, or an
+// error if it is not recognised.
+func DetectFormat(path string) (string, error) {
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.HasSuffix(name, ".clab.yml"), strings.HasSuffix(name, ".clab.yaml"):
+		return FormatContainerlab, nil
+	case strings.HasSuffix(name, ".gns3"):
+		return FormatGNS3, nil
+	case strings.HasSuffix(name, ".mn"), strings.HasSuffix(name, ".json"):
+		return FormatMininet, nil
+	}
+	return "", fmt.Errorf("could not detect topology format of %s", path)
+}
+
+// Import reads a topology in the given format and returns it as a document.
+// Anything in the topology that net4me can not emulate (e.g. bandwidth limits)
+// is reported in the returned warnings rather than failing the import.
+func Import(r io.Reader, format string, options Options) (*state.Document, []string, error) {
+	if options.HostDevice == "" {
+		options.HostDevice = DefaultOptions.HostDevice
+	}
+	if options.SwitchDevice == "" {
+		options.SwitchDevice = DefaultOptions.SwitchDevice
+	}
+	b := newBuilder(options)
+	var err error
+	switch format {
+	case FormatContainerlab:
+		err = importContainerlab(r, b)
+	case FormatMininet:
+		err = importMininet(r, b)
+	case FormatGNS3:
+		err = importGNS3(r, b)
+	default:
+		err = fmt.Errorf("unknown topology format: %s", format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return b.document(), b.warnings, nil
+}
+
+// builder collects the hosts, links and routes of an imported topology.
+// Imported node names are sanitised to be usable as device names, so nodes
+// are referenced by their original name until the document is built.
+type builder struct {
+	options  Options
+	name     string
+	names    map[string]string
+	hosts    []state.Host
+	links    []state.Link
+	routes   []state.Route
+	ports    map[string]int
+	warnings []string
+}
+
+func newBuilder(options Options) *builder {
+	return &builder{
+		options:  options,
+		names:    make(map[string]string),
+		hosts:    make([]state.Host, 0),
+		links:    make([]state.Link, 0),
+		routes:   make([]state.Route, 0),
+		ports:    make(map[string]int),
+		warnings: make([]string, 0),
+	}
+}
+
+func (b *builder) warn(format string, args ...any) {
+	b.warnings = append(b.warnings, fmt.Sprintf(format, args...))
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// addHost adds a host for the node with the given original name.
+func (b *builder) addHost(name, device string, labels map[string]string, config map[string]any) error {
+	if _, ok := b.names[name]; ok {
+		return fmt.Errorf("duplicate node %s", name)
+	}
+	sanitised := strings.Trim(invalidNameChars.ReplaceAllString(name, "-"), "-.")
+	if sanitised == "" {
+		return fmt.Errorf("node name %q can not be used as a host name", name)
+	}
+	for _, n := range b.names {
+		if n == sanitised {
+			return fmt.Errorf("nodes %s and %s have the same host name", name, sanitised)
+		}
+	}
+	if sanitised != name {
+		b.warn("node %q is named %s", name, sanitised)
+	}
+	b.names[name] = sanitised
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	if config == nil {
+		config = make(map[string]any)
+	}
+	b.hosts = append(b.hosts, state.Host{
+		Name:    sanitised,
+		Device:  device,
+		Labels:  labels,
+		Config:  config,
+		Running: true,
+	})
+	return nil
+}
+
+// host returns the host of the node with the given original name.
+func (b *builder) host(name string) *state.Host {
+	sanitised, ok := b.names[name]
+	if !ok {
+		return nil
+	}
+	for i := range b.hosts {
+		if b.hosts[i].Name == sanitised {
+			return &b.hosts[i]
+		}
+	}
+	return nil
+}
+
+// endpoint returns a link endpoint on the node. If the port is not named, the
+// next free port of the node is used.
+func (b *builder) endpoint(node, portName string) (state.Endpoint, error) {
+	host := b.host(node)
+	if host == nil {
+		return state.Endpoint{}, fmt.Errorf("link references unknown node %s", node)
+	}
+	if portName == "" {
+		portName = fmt.Sprintf("eth%d", b.ports[node])
+	}
+	b.ports[node]++
+	return state.Endpoint{Host: host.Name, Port: portName, Up: true}, nil
+}
+
+func (b *builder) addLink(a, z state.Endpoint) {
+	b.links = append(b.links, state.Link{A: a, B: z})
+}
+
+// endpointOf returns the endpoint for the named port of the host, if linked.
+func (b *builder) endpointOf(node, portName string) *state.Endpoint {
+	host := b.host(node)
+	if host == nil {
+		return nil
+	}
+	for i := range b.links {
+		for _, e := range []*state.Endpoint{&b.links[i].A, &b.links[i].B} {
+			if e.Host == host.Name && (e.Port == portName || portName == "") {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// addAddress adds the address to the named port of the node, or its first
+// linked port if the port is not named.
+func (b *builder) addAddress(node, portName, address string) {
+	e := b.endpointOf(node, portName)
+	if e == nil {
+		b.warn("address %s of node %s is not on a linked port", address, node)
+		return
+	}
+	e.Addresses = append(e.Addresses, address)
+}
+
+func (b *builder) addRoute(node, portName, destination, gateway string) {
+	if b.endpointOf(node, portName) == nil || portName == "" {
+		b.warn("route to %s of node %s is not via a linked port", destination, node)
+		return
+	}
+	if destination == "default" || destination == "0.0.0.0/0" || destination == "::/0" {
+		destination = ""
+	}
+	b.routes = append(b.routes, state.Route{
+		Host:        b.names[node],
+		Port:        portName,
+		Destination: destination,
+		Gateway:     gateway,
+	})
+}
+
+func (b *builder) document() *state.Document {
+	doc := &state.Document{
+		Version:  state.Version,
+		Topology: b.name,
+		Saved:    time.Now(),
+		Hosts:    b.hosts,
+		Links:    b.links,
+		Routes:   b.routes,
+	}
+	if doc.Topology == "" {
+		doc.Topology = "imported"
+	}
+	return doc
+}
+
+// parseDelay parses a delay such as `10ms` or `1.5s` into µs. Plain numbers
+// are taken to be in ms.
+func parseDelay(v any) (uint32, error) {
+	var s string
+	switch d := v.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		s = strings.TrimSpace(d)
+	case float64:
+		s = strconv.FormatFloat(d, 'f', -1, 64)
+	case int:
+		s = strconv.Itoa(d)
+	default:
+		return 0, fmt.Errorf("invalid delay %v", v)
+	}
+	if s == "" {
+		return 0, nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		s += "ms"
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid delay %s: %w", s, err)
+	}
+	return uint32(d.Microseconds()), nil
+}
+
+// parsePercent parses a percentage given as a number or string, with or
+// without a `%` suffix.
+func parsePercent(v any) (float32, error) {
+	switch p := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return float32(p), nil
+	case int:
+		return float32(p), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(p), "%"), 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid percentage %s: %w", p, err)
+		}
+		return float32(f), nil
+	}
+	return 0, fmt.Errorf("invalid percentage %v", v)
+}
+
+// emulation returns the port emulation for the given delay, jitter and loss,
+// or nil if none of them are set.
+func emulation(delay, jitter, loss any) (*port.Emulation, error) {
+	e := &port.Emulation{}
+	var err error
+	if e.Latency, err = parseDelay(delay); err != nil {
+		return nil, err
+	}
+	if e.Jitter, err = parseDelay(jitter); err != nil {
+		return nil, err
+	}
+	if e.Loss, err = parsePercent(loss); err != nil {
+		return nil, err
+	}
+	if e.Latency == 0 && e.Jitter == 0 && e.Loss == 0 {
+		return nil, nil
+	}
+	return e, nil
+}
@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/ai4networks/net4me/pkg/state"
+)
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		file     string
+		format   string
+		topology string
+		hosts    []state.Host
+		links    []state.Link
+		routes   []state.Route
+		warnings []string
+	}{
+		{
+			file:     "testdata/lab.clab.yml",
+			format:   FormatContainerlab,
+			topology: "lab",
+			hosts: []state.Host{
+				{Name: "h1", Device: "dind", Labels: map[string]string{"clab.kind": "linux", "clab.image": "alpine:3", "role": "client"}, Config: map[string]any{}, Running: true},
+				{Name: "r1", Device: "dind", Labels: map[string]string{"clab.kind": "linux", "clab.image": "alpine:3"}, Config: map[string]any{}, Running: true},
+				{Name: "sw", Device: "ovs", Labels: map[string]string{"clab.kind": "bridge"}, Config: map[string]any{}, Running: true},
+			},
+			links: []state.Link{
+				{
+					A: state.Endpoint{Host: "r1", Port: "eth1", Addresses: []string{"10.0.1.1/24"}, Up: true},
+					B: state.Endpoint{Host: "h1", Port: "eth1", Addresses: []string{"10.0.1.2/24"}, Up: true},
+				},
+				{
+					A: state.Endpoint{Host: "r1", Port: "eth2", Addresses: []string{"10.0.2.1/24"}, Up: true},
+					B: state.Endpoint{Host: "sw", Port: "p1", Up: true},
+				},
+			},
+			routes: []state.Route{{Host: "h1", Port: "eth1", Gateway: "10.0.1.1"}},
+			warnings: []string{
+				"image alpine:3 of node h1 is not used, the node is a dind device",
+				"image alpine:3 of node r1 is not used, the node is a dind device",
+				"link 2 to host is not supported",
+				"link 3 to mgmt-net is not supported",
+				"link 4 of type macvlan is not supported",
+				"exec command of node r1 is not imported: sysctl -w net.ipv4.ip_forward=1",
+			},
+		},
+		{
+			file:     "testdata/topo.mn",
+			format:   FormatMininet,
+			topology: "imported",
+			hosts: []state.Host{
+				{Name: "s1", Device: "ovs", Labels: map[string]string{"mininet.type": "switch"}, Config: map[string]any{"controller_ip": "tcp:192.168.1.10:6633"}, Running: true},
+				{Name: "r1", Device: "dind", Labels: map[string]string{"mininet.type": "router"}, Config: map[string]any{}, Running: true, Forwarding: state.Forwarding{IPv4: true}},
+				{Name: "h1", Device: "dind", Labels: map[string]string{"mininet.type": "host"}, Config: map[string]any{}, Running: true},
+				{Name: "h2", Device: "dind", Labels: map[string]string{"mininet.type": "host"}, Config: map[string]any{}, Running: true},
+			},
+			links: []state.Link{
+				{
+					A: state.Endpoint{Host: "h1", Port: "eth0", Addresses: []string{"10.0.0.1/8"}, Up: true, Emulation: &port.Emulation{Latency: 10000, Loss: 1}},
+					B: state.Endpoint{Host: "s1", Port: "eth0", Up: true, Emulation: &port.Emulation{Latency: 10000, Loss: 1}},
+				},
+				{
+					A: state.Endpoint{Host: "h2", Port: "eth0", Addresses: []string{"10.1.0.2/24"}, Up: true, Emulation: &port.Emulation{Latency: 5000, Jitter: 1000}},
+					B: state.Endpoint{Host: "r1", Port: "r1-eth1", Up: true, Emulation: &port.Emulation{Latency: 5000, Jitter: 1000}},
+				},
+			},
+			routes: []state.Route{{Host: "h1", Port: "eth0", Gateway: "10.0.0.254"}},
+			warnings: []string{
+				"controller c1 is not a remote controller, so is not imported",
+				"bandwidth of link h1-s1 (100 Mbit/s) can not be emulated",
+			},
+		},
+		{
+			file:     "testdata/project.gns3",
+			format:   FormatGNS3,
+			topology: "Branch Office",
+			hosts: []state.Host{
+				{Name: "PC-1", Device: "dind", Labels: map[string]string{"gns3.type": "vpcs"}, Config: map[string]any{}, Running: true},
+				{Name: "Switch1", Device: "ovs", Labels: map[string]string{"gns3.type": "ethernet_switch"}, Config: map[string]any{}, Running: true},
+				{Name: "NAT1", Device: "netns", Labels: map[string]string{"gns3.type": "nat"}, Config: map[string]any{"nat": true}, Running: true},
+			},
+			links: []state.Link{
+				{
+					A: state.Endpoint{Host: "PC-1", Port: "e0-0", Up: true, Emulation: &port.Emulation{Latency: 20000, Jitter: 5000, Loss: 2}},
+					B: state.Endpoint{Host: "Switch1", Port: "e0-1", Up: true},
+				},
+				{
+					A: state.Endpoint{Host: "Switch1", Port: "e0-2"},
+					B: state.Endpoint{Host: "NAT1", Port: "e0-0"},
+				},
+			},
+			routes: []state.Route{},
+			warnings: []string{
+				`node "PC 1" is named PC-1`,
+				"cloud node Cloud1 is not imported",
+				"bpf filter of link PC 1-Switch1 can not be emulated",
+				"link 2 is to a node that is not imported",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			format, err := DetectFormat(tt.file)
+			if err != nil || format != tt.format {
+				t.Fatalf("detected format %s (%v), want %s", format, err, tt.format)
+			}
+			f, err := os.Open(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			doc, warnings, err := Import(f, format, DefaultOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.Version != state.Version || doc.Topology != tt.topology {
+				t.Errorf("got version %d and topology %s", doc.Version, doc.Topology)
+			}
+			if !reflect.DeepEqual(doc.Hosts, tt.hosts) {
+				t.Errorf("hosts:\ngot  %+v\nwant %+v", doc.Hosts, tt.hosts)
+			}
+			if !reflect.DeepEqual(doc.Links, tt.links) {
+				t.Errorf("links:\ngot  %+v\nwant %+v", doc.Links, tt.links)
+			}
+			if !reflect.DeepEqual(doc.Routes, tt.routes) {
+				t.Errorf("routes:\ngot  %+v\nwant %+v", doc.Routes, tt.routes)
+			}
+			if !reflect.DeepEqual(warnings, tt.warnings) {
+				t.Errorf("warnings:\ngot  %q\nwant %q", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		topology string
+	}{
+		{name: "unknown format", format: "eve-ng", topology: "{}"},
+		{name: "clab unknown node", format: FormatContainerlab, topology: "topology:\n  nodes:\n    a: {}\n  links:\n    - endpoints: [\"a:eth1\", \"b:eth1\"]\n"},
+		{name: "clab one endpoint", format: FormatContainerlab, topology: "topology:\n  nodes:\n    a: {}\n  links:\n    - endpoints: [\"a:eth1\"]\n"},
+		{name: "clab duplicate host name", format: FormatContainerlab, topology: "topology:\n  nodes:\n    a b: {}\n    a-b: {}\n"},
+		{name: "mininet invalid ip base", format: FormatMininet, topology: `{"application": {"ipBase": "10.0.0.0"}}`},
+		{name: "mininet invalid delay", format: FormatMininet, topology: `{"hosts": ["a", "b"], "links": [{"src": "a", "dest": "b", "delay": "soon"}]}`},
+		{name: "gns3 invalid json", format: FormatGNS3, topology: `{"topology": [}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Import(strings.NewReader(tt.topology), tt.format, DefaultOptions); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestSpecialEndpointNode(t *testing.T) {
+	// a node named after a special endpoint is linked as any other node
+	topology := "topology:\n  nodes:\n    a: {}\n    host: {}\n  links:\n    - endpoints: [\"a:eth1\", \"host:eth1\"]\n"
+	doc, _, err := Import(strings.NewReader(topology), FormatContainerlab, DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Links) != 1 || doc.Links[0].B.Host != "host" {
+		t.Fatalf("got links %+v", doc.Links)
+	}
+}
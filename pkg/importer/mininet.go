@@ -0,0 +1,199 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// mininetTopology is a mininet topology as saved by MiniEdit, or generated by
+// a script in the same shape. Nodes may also be given by name alone, and link
+// parameters at the top level of the link rather than in its options.
+type mininetTopology struct {
+	Application struct {
+		IPBase string `json:"ipBase"`
+	} `json:"application"`
+	Controllers []mininetNode `json:"controllers"`
+	Hosts       []mininetNode `json:"hosts"`
+	Switches    []mininetNode `json:"switches"`
+	Links       []mininetLink `json:"links"`
+}
+
+type mininetNode struct {
+	Name string         `json:"name"`
+	Opts map[string]any `json:"opts"`
+}
+
+func (n *mininetNode) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		n.Name = name
+		return nil
+	}
+	type node mininetNode
+	return json.Unmarshal(b, (*node)(n))
+}
+
+// hostname returns the name of the node, preferring that of its options.
+func (n mininetNode) hostname() string {
+	if name, ok := n.Opts["hostname"].(string); ok && name != "" {
+		return name
+	}
+	return n.Name
+}
+
+func (n mininetNode) opt(key string) string {
+	switch v := n.Opts[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+type mininetLink struct {
+	Src       string         `json:"src"`
+	Dest      string         `json:"dest"`
+	Node1     string         `json:"node1"`
+	Node2     string         `json:"node2"`
+	IntfName1 string         `json:"intfName1"`
+	IntfName2 string         `json:"intfName2"`
+	Params1   map[string]any `json:"params1"`
+	Params2   map[string]any `json:"params2"`
+	Opts      map[string]any `json:"opts"`
+	BW        any            `json:"bw"`
+	Delay     any            `json:"delay"`
+	Jitter    any            `json:"jitter"`
+	Loss      any            `json:"loss"`
+}
+
+// param returns the link parameter from the link options if set, otherwise
+// from the link itself.
+func (l mininetLink) param(key string, value any) any {
+	if v, ok := l.Opts[key]; ok {
+		return v
+	}
+	return value
+}
+
+func importMininet(r io.Reader, b *builder) error {
+	var t mininetTopology
+	if err := json.NewDecoder(r).Decode(&t); err != nil {
+		return fmt.Errorf("could not decode mininet topology: %w", err)
+	}
+	prefixBits := 8
+	if t.Application.IPBase != "" {
+		base, err := netip.ParsePrefix(t.Application.IPBase)
+		if err != nil {
+			return fmt.Errorf("invalid ip base %s: %w", t.Application.IPBase, err)
+		}
+		prefixBits = base.Bits()
+	}
+
+	controllers := make(map[string]string)
+	for _, c := range t.Controllers {
+		if c.opt("controllerType") != "remote" || c.opt("remoteIP") == "" {
+			b.warn("controller %s is not a remote controller, so is not imported", c.hostname())
+			continue
+		}
+		protocol, port := c.opt("controllerProtocol"), c.opt("remotePort")
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		if port == "" {
+			port = "6653"
+		}
+		controllers[c.hostname()] = fmt.Sprintf("%s:%s:%s", protocol, c.opt("remoteIP"), port)
+	}
+
+	for _, s := range t.Switches {
+		name := s.hostname()
+		labels := map[string]string{"mininet.type": "switch"}
+		if s.opt("switchType") == "legacyRouter" {
+			// legacy routers are linux hosts that forward between their ports
+			labels["mininet.type"] = "router"
+			if err := b.addHost(name, b.options.HostDevice, labels, nil); err != nil {
+				return err
+			}
+			b.host(name).Forwarding.IPv4 = true
+			continue
+		}
+		config := make(map[string]any)
+		if names, ok := s.Opts["controllers"].([]any); ok {
+			for _, c := range names {
+				if address, ok := controllers[fmt.Sprint(c)]; ok {
+					config["controller_ip"] = address
+					break
+				}
+			}
+		}
+		if err := b.addHost(name, b.options.SwitchDevice, labels, config); err != nil {
+			return err
+		}
+	}
+	for _, h := range t.Hosts {
+		if err := b.addHost(h.hostname(), b.options.HostDevice, map[string]string{"mininet.type": "host"}, nil); err != nil {
+			return err
+		}
+	}
+
+	for i, l := range t.Links {
+		src, dst := l.Src, l.Dest
+		if src == "" {
+			src, dst = l.Node1, l.Node2
+		}
+		a, err := b.endpoint(src, l.IntfName1)
+		if err != nil {
+			return fmt.Errorf("invalid link %d: %w", i, err)
+		}
+		z, err := b.endpoint(dst, l.IntfName2)
+		if err != nil {
+			return fmt.Errorf("invalid link %d: %w", i, err)
+		}
+		// as with mininet traffic control links, the parameters are applied to
+		// both ends of the link
+		e, err := emulation(l.param("delay", l.Delay), l.param("jitter", l.Jitter), l.param("loss", l.Loss))
+		if err != nil {
+			return fmt.Errorf("invalid link %d: %w", i, err)
+		}
+		a.Emulation, z.Emulation = e, e
+		if bw := l.param("bw", l.BW); bw != nil {
+			b.warn("bandwidth of link %s-%s (%v Mbit/s) can not be emulated", src, dst, bw)
+		}
+		if ip, ok := l.Params1["ip"].(string); ok {
+			a.Addresses = append(a.Addresses, withPrefix(ip, prefixBits))
+		}
+		if ip, ok := l.Params2["ip"].(string); ok {
+			z.Addresses = append(z.Addresses, withPrefix(ip, prefixBits))
+		}
+		b.addLink(a, z)
+	}
+
+	for _, h := range append(t.Hosts, t.Switches...) {
+		name := h.hostname()
+		if ip := h.opt("ip"); ip != "" {
+			if b.host(name).Device == b.options.SwitchDevice {
+				continue
+			}
+			b.addAddress(name, "", withPrefix(ip, prefixBits))
+		}
+		if route := strings.Fields(strings.TrimPrefix(h.opt("defaultRoute"), "via ")); len(route) > 0 {
+			if e := b.endpointOf(name, ""); e != nil {
+				b.addRoute(name, e.Port, "default", route[0])
+			}
+		}
+	}
+	return nil
+}
+
+// withPrefix adds the prefix length to the address if it does not have one.
+func withPrefix(ip string, bits int) string {
+	if strings.Contains(ip, "/") {
+		return ip
+	}
+	return fmt.Sprintf("%s/%d", ip, bits)
+}
@@ -12,9 +12,11 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func Graph(writer api.WriteAPIBlocking) error {
+// Graph exports the hosts and links of the topology to influx as the `nodes`
+// and `edges` measurements.
+func Graph(t *topology.Topology, writer api.WriteAPIBlocking) error {
 	nodePoints := make([]*write.Point, 0)
-	for _, h := range topology.Hosts() {
+	for _, h := range t.Hosts() {
 		if h.State() == topology.HostStateRunning {
 			stats, err := h.Stats()
 			if err != nil {
@@ -25,7 +27,7 @@ func Graph(writer api.WriteAPIBlocking) error {
 					"node": h.ID(),
 				},
 				map[string]interface{}{
-					"topology":      topology.ID(),
+					"topology":      t.ID(),
 					"id":            h.ID(),
 					"title":         fmt.Sprintf("Name: %s", h.Name()),
 					"subtitle":      fmt.Sprintf("Device: %s", h.Device()),
@@ -48,13 +50,13 @@ func Graph(writer api.WriteAPIBlocking) error {
 	}
 
 	linkPoints := make([]*write.Point, 0)
-	for _, l := range topology.Links() {
+	for _, l := range t.DiscoverLinks() {
 		linkPoints = append(linkPoints, influxdb2.NewPoint("edges",
 			map[string]string{
 				"edges": l.SelfPort().Attrs().Name,
 			},
 			map[string]interface{}{
-				"topology":      topology.ID(),
+				"topology":      t.ID(),
 				"id":            l.SelfPort().Attrs().Index,
 				"source":        l.SelfHost().ID(),
 				"target":        l.PeerHost().ID(),
@@ -76,9 +78,12 @@ func Graph(writer api.WriteAPIBlocking) error {
 	return nil
 }
 
-func RunExporter(URL, token, org, bucket string, interval int) (<-chan error, error) {
+// RunExporter periodically exports the topology to influx. All points are
+// tagged with the name of the topology, so that the metrics of topologies
+// sharing a bucket can be told apart.
+func RunExporter(t *topology.Topology, URL, token, org, bucket string, interval int) (<-chan error, error) {
 	errCh := make(chan error)
-	client := influxdb2.NewClientWithOptions(URL, token, influxdb2.DefaultOptions().SetBatchSize(20).AddDefaultTag("net4me", "true").AddDefaultTag("topology", topology.ID()))
+	client := influxdb2.NewClientWithOptions(URL, token, influxdb2.DefaultOptions().SetBatchSize(20).AddDefaultTag("net4me", "true").AddDefaultTag("topology", t.Name()))
 	alive, err := client.Ping(context.Background())
 	if err != nil {
 		return errCh, fmt.Errorf("failed to ping influx instance: %w", err)
@@ -90,7 +95,7 @@ func RunExporter(URL, token, org, bucket string, interval int) (<-chan error, er
 	go func() {
 		defer close(errCh)
 		for {
-			if err := Graph(writeAPI); err != nil {
+			if err := Graph(t, writeAPI); err != nil {
 				errCh <- err
 				return
 			}
@@ -0,0 +1,46 @@
+package influx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/ai4networks/net4me/pkg/traffic"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+)
+
+// WriteTraffic exports the results of traffic flows to influx as points of
+// the `traffic` measurement, tagged by topology, source, target and protocol.
+func WriteTraffic(URL, token, org, bucket string, results ...*traffic.Result) error {
+	client := influxdb2.NewClientWithOptions(URL, token, influxdb2.DefaultOptions().AddDefaultTag("net4me", "true").AddDefaultTag("topology", topology.Name()))
+	defer client.Close()
+	points := make([]*write.Point, 0, len(results))
+	for _, r := range results {
+		points = append(points, influxdb2.NewPoint("traffic",
+			map[string]string{
+				"source":   r.Source,
+				"target":   r.Target,
+				"protocol": r.Protocol,
+			},
+			map[string]interface{}{
+				"address":          r.Address,
+				"streams":          r.Streams,
+				"dscp":             r.DSCP,
+				"duration":         r.Duration.Seconds(),
+				"bytes_sent":       r.BytesSent,
+				"bytes_received":   r.BytesReceived,
+				"throughput":       r.Throughput,
+				"packets_sent":     r.PacketsSent,
+				"packets_received": r.PacketsReceived,
+				"loss":             r.Loss,
+				"jitter":           r.Jitter.Seconds(),
+			},
+			r.Start.Add(r.Duration),
+		))
+	}
+	if err := client.WriteAPIBlocking(org, bucket).WritePoint(context.Background(), points...); err != nil {
+		return fmt.Errorf("failed to write traffic data to influx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package node
+
+// Configured is implemented by nodes that can report the add config that would
+// recreate them, e.g. when saving a topology to be restored elsewhere. Not all
+// device types can support this, so callers should check if a node implements
+// this interface before use.
+type Configured interface {
+	// Config returns the config that, given to the Add function of the node's
+	// manager, creates an equivalent node.
+	Config() (map[string]any, error)
+}
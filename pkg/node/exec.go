@@ -0,0 +1,38 @@
+package node
+
+import (
+	"context"
+	"io"
+)
+
+// ExecOptions configures a command executed within a node.
+type ExecOptions struct {
+	// Container is the container within the node to run the command in. If
+	// empty, the command is run in the node itself.
+	Container  string
+	Cmd        []string
+	Env        []string
+	WorkingDir string
+	User       string
+	// TTY allocates a pseudo-terminal for the command, in which case all
+	// output is written to Stdout.
+	TTY bool
+	// Stdin is attached to the command if set. It is closed on the command's
+	// side once read to the end.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Resize receives terminal size changes (width, height) for TTY commands.
+	Resize <-chan [2]uint
+}
+
+// Execer is implemented by nodes that support running commands within them,
+// or within containers running inside them. Not all device types can support
+// this, so callers should check if a node implements this interface before
+// use.
+type Execer interface {
+	// Exec runs the command, streaming its output as it is produced, and
+	// returns the command's exit code once it completes. Cancelling the
+	// context stops streaming, but may not stop the command itself.
+	Exec(ctx context.Context, options ExecOptions) (int, error)
+}
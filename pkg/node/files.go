@@ -0,0 +1,19 @@
+package node
+
+import "io"
+
+// FileCopier is implemented by nodes that support copying files into and out
+// of their filesystem, or the filesystem of a container running within them.
+// Not all device types can support this, so callers should check if a node
+// implements this interface before use.
+type FileCopier interface {
+	// ArchiveTo extracts the tar archive into the dst directory, which must
+	// already exist. If container is empty, the node's own filesystem is the
+	// target, otherwise it is the named container within the node.
+	ArchiveTo(container, dst string, archive io.Reader) error
+	// ArchiveFrom returns a tar archive of the file or directory at src,
+	// stored within the archive using the base name of src. If container is
+	// empty, the node's own filesystem is the source, otherwise it is the
+	// named container within the node.
+	ArchiveFrom(container, src string) (io.ReadCloser, error)
+}
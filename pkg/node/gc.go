@@ -0,0 +1,35 @@
+package node
+
+// Orphan is a resource created by net4me that is no longer in use by any node,
+// such as a container left behind when a command was interrupted.
+type Orphan struct {
+	Device string `json:"device"`
+	// Kind is the type of resource, e.g. container or volume.
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// GCOptions control which orphans a Collector looks for.
+type GCOptions struct {
+	// Topology limits the search to the resources of the named topology.
+	// Resources that are not specific to a topology (e.g. snapshots) are
+	// only searched when All is set.
+	Topology string
+	All      bool
+	// Volumes includes resources holding data that is kept on purpose, such
+	// as the volumes of persistent sites that have been removed.
+	Volumes bool
+}
+
+// Collector is implemented by managers that can find the resources they
+// created that are no longer in use. Not all device types can support this, so
+// callers should check if a manager implements this interface before use.
+type Collector interface {
+	// Orphans returns the unused resources of the manager.
+	Orphans(GCOptions) ([]Orphan, error)
+
+	// RemoveOrphan removes a resource returned by Orphans.
+	RemoveOrphan(Orphan) error
+}
@@ -0,0 +1,48 @@
+package node
+
+// Labelled is implemented by nodes that persist the labels they were added
+// with. Not all device types can support this, so callers should check if a
+// node implements this interface before use.
+type Labelled interface {
+	// Labels returns the labels of the node, including those added by the
+	// node manager itself.
+	Labels() (map[string]string, error)
+}
+
+const (
+	// TopologyLabel is the label holding the name of the topology that a node
+	// belongs to.
+	TopologyLabel = "net4me.topology"
+
+	// DefaultTopology is the topology of nodes that are not labelled with one,
+	// such as those created before topologies were named.
+	DefaultTopology = "default"
+)
+
+// TopologyOf returns the name of the topology that the node belongs to. Nodes
+// that are not labelled belong to the default topology.
+func TopologyOf(n Node) string {
+	if l, ok := n.(Labelled); ok {
+		if labels, err := l.Labels(); err == nil && labels[TopologyLabel] != "" {
+			return labels[TopologyLabel]
+		}
+	}
+	return DefaultTopology
+}
+
+// FilterByTopology keeps the nodes that belong to any of the given topologies.
+func FilterByTopology(topologies ...string) NodeFilter {
+	return func(nodes []Node) []Node {
+		filtered := make([]Node, 0)
+		for _, n := range nodes {
+			topology := TopologyOf(n)
+			for _, t := range topologies {
+				if topology == t {
+					filtered = append(filtered, n)
+					break
+				}
+			}
+		}
+		return filtered
+	}
+}
@@ -0,0 +1,16 @@
+package node
+
+// Resolvable is implemented by nodes that support configuring their name
+// resolution. Not all device types can support this, so callers should check
+// if a node implements this interface before use.
+type Resolvable interface {
+	// SetResolvers replaces the nameservers and search domains used by the node
+	// for name resolution. If the configuration can not be applied, an error
+	// will be returned.
+	SetResolvers(nameservers []string, search []string) error
+
+	// RestoreResolvers restores the name resolution configuration the node
+	// had before the first call to SetResolvers. If the configuration was not
+	// replaced, this does nothing.
+	RestoreResolvers() error
+}
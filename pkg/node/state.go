@@ -8,3 +8,23 @@ const (
 	NodeStateError   NodeState = "error"
 	NodeStateRunning NodeState = "running"
 )
+
+// NodeHealth is the health of a running node, for devices where running is not
+// the same as being ready for use.
+type NodeHealth string
+
+const (
+	NodeHealthStarting  NodeHealth = "starting"
+	NodeHealthHealthy   NodeHealth = "healthy"
+	NodeHealthUnhealthy NodeHealth = "unhealthy"
+)
+
+// HealthChecker is implemented by nodes that, once running, take time to
+// become ready for use or can stop responding while still running. For
+// example, a site is running once its inner docker engine is started, but is
+// only healthy once the engine responds to requests.
+type HealthChecker interface {
+	// Health returns the health of the node, which is only meaningful while
+	// the node is running.
+	Health() NodeHealth
+}
@@ -0,0 +1,35 @@
+package node
+
+import (
+	"context"
+	"io"
+)
+
+// WorkloadService is the state of a single container of a deployed workload.
+type WorkloadService struct {
+	Service   string `json:"service"`
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	State     string `json:"state"`
+	Status    string `json:"status"`
+}
+
+// WorkloadRunner is implemented by nodes that can run Docker Compose projects
+// on an inner container engine. Not all device types can support this, so
+// callers should check if a node implements this interface before use.
+type WorkloadRunner interface {
+	// DeployWorkload copies the project directory to the node and brings the
+	// compose project up, using the compose file given relative to the
+	// directory. The environment is used for variable interpolation in the
+	// compose file. Deploying an existing project updates it in place.
+	DeployWorkload(project, dir, file string, env map[string]string) error
+	// WorkloadStatus returns the state of every container in the project.
+	WorkloadStatus(project string) ([]WorkloadService, error)
+	// WorkloadLogs writes the logs of every container in the project to the
+	// writer, with each line prefixed by the service name. If follow is set,
+	// logs are streamed until the context is cancelled.
+	WorkloadLogs(ctx context.Context, project string, follow bool, tail string, w io.Writer) error
+	// RemoveWorkload tears the project down, removing its containers,
+	// networks and volumes, along with the copied project directory.
+	RemoveWorkload(project string) error
+}
@@ -0,0 +1,121 @@
+package dind
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/mitchellh/mapstructure"
+)
+
+// Config returns the add config of the site, rebuilt from its container and
+// labels. Limits changed since the site was added are reported as they are
+// now. GPUs are given by the devices assigned, rather than the request that
+// assigned them.
+func (n *Node) Config() (map[string]any, error) {
+	n.manager.lock.RLock()
+	defer n.manager.lock.RUnlock()
+	c, err := n.manager.clientDocker.ContainerInspect(context.Background(), n.id)
+	if err != nil {
+		return nil, fmt.Errorf("could not inspect dind container: %w", err)
+	}
+	labels := c.Config.Labels
+	config := map[string]any{
+		"isolated": c.HostConfig.NetworkMode.IsNone(),
+	}
+
+	if v := labels[daemonLabel]; v != "" {
+		var daemon DaemonConfig
+		if err := json.Unmarshal([]byte(v), &daemon); err != nil {
+			return nil, fmt.Errorf("could not decode site daemon config: %w", err)
+		}
+		daemonConfig, err := configMap(daemon)
+		if err != nil {
+			return nil, err
+		}
+		if len(daemonConfig) > 0 {
+			config["daemon"] = daemonConfig
+		}
+	}
+	if v := labels[commandLabel]; v != "" {
+		var command []string
+		if err := json.Unmarshal([]byte(v), &command); err != nil {
+			return nil, fmt.Errorf("could not decode site command: %w", err)
+		}
+		config["command"] = command
+	}
+
+	if labels[gpuLabel] == gpuAll {
+		config["gpu"] = true
+	} else if labels[gpuLabel] != "" {
+		config["gpus"] = gpuInfo(labels)
+	} else {
+		for _, request := range c.HostConfig.DeviceRequests {
+			if request.Count == -1 {
+				config["gpu"] = true
+			}
+		}
+	}
+
+	resources, err := configMap(containerLimits(c.HostConfig.Resources))
+	if err != nil {
+		return nil, err
+	}
+	if len(resources) > 0 {
+		config["resources"] = resources
+	}
+
+	if volume := labels["net4me.volume"]; volume != "" {
+		config["volume"] = volume
+	}
+	return config, nil
+}
+
+// containerLimits converts docker container resources back to site limits.
+func containerLimits(res container.Resources) Resources {
+	r := Resources{
+		CPUPeriod:   res.CPUPeriod,
+		CPUQuota:    res.CPUQuota,
+		CPUShares:   res.CPUShares,
+		CPUSetCPUs:  res.CpusetCpus,
+		CPUSetMems:  res.CpusetMems,
+		BlkioWeight: res.BlkioWeight,
+	}
+	if res.NanoCPUs > 0 {
+		r.CPUs = float64(res.NanoCPUs) / 1e9
+	}
+	for _, limit := range []struct {
+		value  int64
+		target *string
+	}{
+		{res.Memory, &r.Memory},
+		{res.MemorySwap, &r.MemorySwap},
+		{res.MemoryReservation, &r.MemoryReservation},
+	} {
+		if limit.value != 0 {
+			*limit.target = strconv.FormatInt(limit.value, 10)
+		}
+	}
+	if res.PidsLimit != nil && *res.PidsLimit > 0 {
+		r.PidsLimit = *res.PidsLimit
+	}
+	if len(res.BlkioWeightDevice) > 0 {
+		r.BlkioDeviceWeights = make(map[string]uint16)
+		for _, wd := range res.BlkioWeightDevice {
+			r.BlkioDeviceWeights[wd.Path] = wd.Weight
+		}
+	}
+	return r
+}
+
+// configMap converts a config struct to a map keyed as the add config is, so
+// that it can be decoded again by Add.
+func configMap(v any) (map[string]any, error) {
+	m := make(map[string]any)
+	if err := mapstructure.Decode(v, &m); err != nil {
+		return nil, fmt.Errorf("could not encode config: %w", err)
+	}
+	return m, nil
+}
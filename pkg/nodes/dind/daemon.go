@@ -0,0 +1,233 @@
+package dind
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+const (
+	daemonLabel  = "net4me.daemon"
+	commandLabel = "net4me.command"
+)
+
+// AddressPool is a pool that the site's dockerd allocates network subnets
+// from, e.g. base `10.10.0.0/16` with size 24.
+type AddressPool struct {
+	Base string `mapstructure:"base" json:"base"`
+	Size int    `mapstructure:"size" json:"size"`
+}
+
+// DaemonConfig is the configuration of a site's inner dockerd, written to its
+// daemon.json before the engine is started. Config holds raw daemon.json
+// contents, with the remaining fields setting their equivalent keys on top.
+// Registries given here are added to those of the shared net4me registry.
+// Keys must not conflict with flags of the dind command (e.g. `hosts`), or
+// dockerd will fail to start.
+type DaemonConfig struct {
+	Config              map[string]any    `mapstructure:"config,omitempty" json:"config,omitempty"`
+	StorageDriver       string            `mapstructure:"storageDriver,omitempty" json:"storage_driver,omitempty"`
+	InsecureRegistries  []string          `mapstructure:"insecureRegistries,omitempty" json:"insecure_registries,omitempty"`
+	RegistryMirrors     []string          `mapstructure:"registryMirrors,omitempty" json:"registry_mirrors,omitempty"`
+	DefaultAddressPools []AddressPool     `mapstructure:"defaultAddressPools,omitempty" json:"default_address_pools,omitempty"`
+	LogDriver           string            `mapstructure:"logDriver,omitempty" json:"log_driver,omitempty"`
+	LogOpts             map[string]string `mapstructure:"logOpts,omitempty" json:"log_opts,omitempty"`
+	CgroupParent        string            `mapstructure:"cgroupParent,omitempty" json:"cgroup_parent,omitempty"`
+}
+
+// merge returns the daemon.json contents of the config applied on top of the
+// base contents. List values of registries are combined rather than replaced.
+func (d DaemonConfig) merge(base map[string]any) map[string]any {
+	config := make(map[string]any)
+	for k, v := range base {
+		config[k] = v
+	}
+	for k, v := range d.Config {
+		config[k] = v
+	}
+	appendList := func(key string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		list := make([]string, 0)
+		switch existing := config[key].(type) {
+		case []string:
+			list = append(list, existing...)
+		case []any:
+			for _, e := range existing {
+				list = append(list, fmt.Sprint(e))
+			}
+		}
+		for _, v := range values {
+			if !slices.Contains(list, v) {
+				list = append(list, v)
+			}
+		}
+		config[key] = list
+	}
+	appendList("insecure-registries", d.InsecureRegistries)
+	appendList("registry-mirrors", d.RegistryMirrors)
+	if d.StorageDriver != "" {
+		config["storage-driver"] = d.StorageDriver
+	}
+	if len(d.DefaultAddressPools) > 0 {
+		config["default-address-pools"] = d.DefaultAddressPools
+	}
+	if d.LogDriver != "" {
+		config["log-driver"] = d.LogDriver
+	}
+	if len(d.LogOpts) > 0 {
+		config["log-opts"] = d.LogOpts
+	}
+	if d.CgroupParent != "" {
+		config["cgroup-parent"] = d.CgroupParent
+	}
+	return config
+}
+
+// siteLabels encodes the per-site engine configuration as container labels,
+// so that it is available whenever the site is started.
+func siteLabels(daemon DaemonConfig, command []string) (map[string]string, error) {
+	labels := make(map[string]string)
+	b, err := json.Marshal(daemon)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode daemon config: %w", err)
+	}
+	labels[daemonLabel] = string(b)
+	if len(command) > 0 {
+		b, err := json.Marshal(command)
+		if err != nil {
+			return nil, fmt.Errorf("could not encode command: %w", err)
+		}
+		labels[commandLabel] = string(b)
+	}
+	return labels, nil
+}
+
+// siteConfig returns the engine configuration of the site from its labels,
+// defaulting to the manager's command.
+func (n *Node) siteConfig(c types.ContainerJSON) (DaemonConfig, []string, error) {
+	var daemon DaemonConfig
+	if v := c.Config.Labels[daemonLabel]; v != "" {
+		if err := json.Unmarshal([]byte(v), &daemon); err != nil {
+			return daemon, nil, fmt.Errorf("could not decode site daemon config: %w", err)
+		}
+	}
+	command := n.manager.dind.command
+	if v := c.Config.Labels[commandLabel]; v != "" {
+		if err := json.Unmarshal([]byte(v), &command); err != nil {
+			return daemon, nil, fmt.Errorf("could not decode site command: %w", err)
+		}
+	}
+	return daemon, command, nil
+}
+
+// dockerdProcess is a dockerd process running in the site container.
+type dockerdProcess struct {
+	pid int
+	// elapsed is the time since the process started, if it is known
+	elapsed time.Duration
+}
+
+// dockerdPIDs returns the (host) PIDs of the dockerd processes running in the
+// site container.
+func (n *Node) dockerdPIDs() ([]int, error) {
+	processes, err := n.dockerdProcesses()
+	if err != nil {
+		return nil, err
+	}
+	pids := make([]int, 0, len(processes))
+	for _, p := range processes {
+		pids = append(pids, p.pid)
+	}
+	return pids, nil
+}
+
+// dockerdProcesses returns the dockerd processes of the site container.
+// Processes are matched by their executable name, so any command line used to
+// start the engine is found.
+func (n *Node) dockerdProcesses() ([]dockerdProcess, error) {
+	resp, err := n.manager.clientDocker.ContainerTop(context.Background(), n.id, []string{"-o", "pid,ppid,etimes,args"})
+	if err != nil {
+		return nil, err
+	}
+	return parseDockerdProcesses(resp.Titles, resp.Processes)
+}
+
+// parseDockerdProcesses finds the dockerd processes in a process list of the
+// site container. The list also holds the processes of containers run by the
+// site, so a dockerd descending from a container shim or another dockerd
+// belongs to a nested engine and is skipped.
+func parseDockerdProcesses(titles []string, list [][]string) ([]dockerdProcess, error) {
+	commandIndex, pidIndex, ppidIndex, elapsedIndex := -1, -1, -1, -1
+	for i, title := range titles {
+		switch title {
+		case "COMMAND", "CMD":
+			commandIndex = i
+		case "PID":
+			pidIndex = i
+		case "PPID":
+			ppidIndex = i
+		case "ELAPSED":
+			elapsedIndex = i
+		}
+	}
+	if commandIndex == -1 || pidIndex == -1 {
+		return nil, fmt.Errorf("could not read process list of site")
+	}
+	type process struct {
+		dockerdProcess
+		parent     int
+		executable string
+	}
+	processes := make(map[int]process, len(list))
+	order := make([]int, 0, len(list))
+	for _, fields := range list {
+		pid, err := strconv.Atoi(fields[pidIndex])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse pid of process: %w", err)
+		}
+		p := process{dockerdProcess: dockerdProcess{pid: pid}}
+		if args := strings.Fields(fields[commandIndex]); len(args) > 0 {
+			p.executable = path.Base(args[0])
+		}
+		if ppidIndex != -1 {
+			p.parent, _ = strconv.Atoi(fields[ppidIndex])
+		}
+		if elapsedIndex != -1 {
+			if seconds, err := strconv.Atoi(fields[elapsedIndex]); err == nil {
+				p.elapsed = time.Duration(seconds) * time.Second
+			}
+		}
+		processes[pid] = p
+		order = append(order, pid)
+	}
+	nested := func(p process) bool {
+		// bound the walk in case of a cycle in a racy process list
+		for i := 0; i < len(processes); i++ {
+			parent, ok := processes[p.parent]
+			if !ok {
+				return false
+			}
+			if parent.executable == "dockerd" || strings.HasPrefix(parent.executable, "containerd-shim") {
+				return true
+			}
+			p = parent
+		}
+		return false
+	}
+	dockerds := make([]dockerdProcess, 0)
+	for _, pid := range order {
+		if p := processes[pid]; p.executable == "dockerd" && !nested(p) {
+			dockerds = append(dockerds, p.dockerdProcess)
+		}
+	}
+	return dockerds, nil
+}
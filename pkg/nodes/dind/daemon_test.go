@@ -0,0 +1,149 @@
+package dind
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDaemonConfigMerge(t *testing.T) {
+	tests := []struct {
+		name   string
+		daemon DaemonConfig
+		base   map[string]any
+		want   map[string]any
+	}{
+		{name: "empty", want: map[string]any{}},
+		{
+			name: "base only",
+			base: map[string]any{"insecure-registries": []string{"registry:5000"}},
+			want: map[string]any{"insecure-registries": []string{"registry:5000"}},
+		},
+		{
+			name:   "raw config overrides base",
+			daemon: DaemonConfig{Config: map[string]any{"debug": true, "mtu": 1400}},
+			base:   map[string]any{"mtu": 1500},
+			want:   map[string]any{"debug": true, "mtu": 1400},
+		},
+		{
+			name:   "registries combined",
+			daemon: DaemonConfig{InsecureRegistries: []string{"registry:5000", "other:5000"}, RegistryMirrors: []string{"http://mirror"}},
+			base:   map[string]any{"insecure-registries": []string{"registry:5000"}},
+			want: map[string]any{
+				"insecure-registries": []string{"registry:5000", "other:5000"},
+				"registry-mirrors":    []string{"http://mirror"},
+			},
+		},
+		{
+			name:   "registries combined with decoded config",
+			daemon: DaemonConfig{Config: map[string]any{"insecure-registries": []any{"raw:5000"}}, InsecureRegistries: []string{"other:5000"}},
+			want:   map[string]any{"insecure-registries": []string{"raw:5000", "other:5000"}},
+		},
+		{
+			name: "fields override raw config",
+			daemon: DaemonConfig{
+				Config:              map[string]any{"storage-driver": "overlay2"},
+				StorageDriver:       "vfs",
+				DefaultAddressPools: []AddressPool{{Base: "10.10.0.0/16", Size: 24}},
+				LogDriver:           "local",
+				LogOpts:             map[string]string{"max-size": "10m"},
+				CgroupParent:        "net4me",
+			},
+			want: map[string]any{
+				"storage-driver":        "vfs",
+				"default-address-pools": []AddressPool{{Base: "10.10.0.0/16", Size: 24}},
+				"log-driver":            "local",
+				"log-opts":              map[string]string{"max-size": "10m"},
+				"cgroup-parent":         "net4me",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.daemon.merge(tt.base); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDockerdProcesses(t *testing.T) {
+	titles := []string{"PID", "PPID", "ELAPSED", "COMMAND"}
+	tests := []struct {
+		name      string
+		titles    []string
+		processes [][]string
+		want      []dockerdProcess
+		wantErr   bool
+	}{
+		{
+			name: "engine",
+			processes: [][]string{
+				{"100", "90", "30", "docker-init -- dockerd-entrypoint.sh"},
+				{"110", "100", "20", "/usr/local/bin/dockerd --host=unix:///var/run/docker.sock"},
+				{"120", "110", "19", "containerd --config /var/run/docker/containerd/containerd.toml"},
+			},
+			want: []dockerdProcess{{pid: 110, elapsed: 20 * time.Second}},
+		},
+		{
+			name: "nested engine",
+			processes: [][]string{
+				{"110", "90", "20", "dockerd"},
+				{"120", "110", "19", "containerd"},
+				{"130", "1", "10", "/usr/bin/containerd-shim-runc-v2 -namespace moby"},
+				{"140", "130", "10", "docker-init -- dockerd-entrypoint.sh"},
+				{"150", "140", "9", "dockerd"},
+			},
+			want: []dockerdProcess{{pid: 110, elapsed: 20 * time.Second}},
+		},
+		{
+			name: "child of engine",
+			processes: [][]string{
+				{"110", "90", "20", "dockerd"},
+				{"111", "110", "1", "dockerd --version"},
+			},
+			want: []dockerdProcess{{pid: 110, elapsed: 20 * time.Second}},
+		},
+		{
+			name:      "no engine",
+			processes: [][]string{{"100", "90", "30", "sleep infinity"}},
+			want:      []dockerdProcess{},
+		},
+		{
+			name:      "without ppid",
+			titles:    []string{"PID", "CMD"},
+			processes: [][]string{{"110", "dockerd"}},
+			want:      []dockerdProcess{{pid: 110}},
+		},
+		{
+			name:    "missing pid",
+			titles:  []string{"CMD"},
+			wantErr: true,
+		},
+		{
+			name:      "bad pid",
+			processes: [][]string{{"x", "90", "20", "dockerd"}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.titles == nil {
+				tt.titles = titles
+			}
+			got, err := parseDockerdProcesses(tt.titles, tt.processes)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
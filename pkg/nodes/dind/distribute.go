@@ -0,0 +1,211 @@
+package dind
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/image"
+)
+
+// imageTags expands the given images (IDs or references) into the tags to be
+// distributed. A reference is distributed as given, whereas an image ID is
+// distributed with all of its tags.
+func (m *Manager) imageTags(images []string) ([]string, error) {
+	tags := make([]string, 0)
+	for _, i := range images {
+		inspect, _, err := m.clientDocker.ImageInspectWithRaw(context.Background(), i)
+		if err != nil {
+			return nil, fmt.Errorf("could not find image %s: %w", i, err)
+		}
+		if slices.Contains(inspect.RepoTags, i) {
+			tags = append(tags, i)
+			continue
+		}
+		if len(inspect.RepoTags) == 0 {
+			return nil, fmt.Errorf("image %s has no tags to distribute", i)
+		}
+		tags = append(tags, inspect.RepoTags...)
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags), nil
+}
+
+// registryPath returns the repository path and tag for the reference within
+// the shared registry, i.e. the reference without its domain.
+func registryPath(tag string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(tag)
+	if err != nil {
+		return "", err
+	}
+	named = reference.TagNameOnly(named)
+	tagged, ok := named.(reference.Tagged)
+	if !ok {
+		return "", fmt.Errorf("image reference %s has no tag", tag)
+	}
+	return fmt.Sprintf("%s:%s", reference.Path(named), tagged.Tag()), nil
+}
+
+// pushToRegistry pushes the tags from the host engine to the shared registry,
+// returning the registry path for each tag. Layers already held by the
+// registry are not pushed again.
+func (m *Manager) pushToRegistry(tags []string) (map[string]string, error) {
+	paths := make(map[string]string)
+	for _, tag := range tags {
+		p, err := registryPath(tag)
+		if err != nil {
+			return nil, err
+		}
+		local := fmt.Sprintf("127.0.0.1:%d/%s", m.registry.port, p)
+		if err := m.clientDocker.ImageTag(context.Background(), tag, local); err != nil {
+			return nil, fmt.Errorf("could not tag image %s for registry: %w", tag, err)
+		}
+		resp, err := m.clientDocker.ImagePush(context.Background(), local, image.PushOptions{
+			RegistryAuth: base64.URLEncoding.EncodeToString([]byte("{}")),
+		})
+		if err == nil {
+			err = progressError(resp)
+			resp.Close()
+		}
+		m.clientDocker.ImageRemove(context.Background(), local, image.RemoveOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("could not push image %s to registry: %w", tag, err)
+		}
+		paths[tag] = p
+	}
+	return paths, nil
+}
+
+// pullFromRegistry pulls the images from the shared registry into the site's
+// inner engine, tagging them with their original references.
+func (n *Node) pullFromRegistry(paths map[string]string) error {
+	engine, err := n.Engine()
+	if err != nil {
+		return err
+	}
+	defer engine.Close()
+	for tag, p := range paths {
+		remote := fmt.Sprintf("%s/%s", n.manager.registry.address, p)
+		resp, err := engine.ImagePull(context.Background(), remote, image.PullOptions{})
+		if err != nil {
+			return fmt.Errorf("could not pull image %s from registry: %w", tag, err)
+		}
+		err = progressError(resp)
+		resp.Close()
+		if err != nil {
+			return fmt.Errorf("could not pull image %s from registry: %w", tag, err)
+		}
+		if err := engine.ImageTag(context.Background(), remote, tag); err != nil {
+			return fmt.Errorf("could not tag image %s in site: %w", tag, err)
+		}
+		if _, err := engine.ImageRemove(context.Background(), remote, image.RemoveOptions{}); err != nil {
+			return fmt.Errorf("could not remove registry tag of image %s in site: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// loadImages streams the images from the host engine into the site's inner
+// engine. The saved archive holds every tag requested, so all are applied when
+// loaded.
+func (n *Node) loadImages(tags []string) error {
+	engine, err := n.Engine()
+	if err != nil {
+		return err
+	}
+	defer engine.Close()
+	archive, err := n.manager.clientDocker.ImageSave(context.Background(), tags)
+	if err != nil {
+		return fmt.Errorf("could not save images: %w", err)
+	}
+	defer archive.Close()
+	resp, err := engine.ImageLoad(context.Background(), archive, true)
+	if err != nil {
+		return fmt.Errorf("could not load images into site: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.JSON {
+		return progressError(resp.Body)
+	}
+	return nil
+}
+
+// Distribute copies images from the host engine into the inner engine of each
+// of the given sites, with up to workers sites being loaded at once. When the
+// shared registry is enabled, images are pushed to it once and pulled by each
+// site, so layers a site already holds are not transferred again. Sites that
+// can not reach the registry (e.g. isolated sites) are sent the full image
+// archive instead. All sites are attempted, with the errors of any failed
+// sites being returned together.
+func (m *Manager) Distribute(images []string, sites []node.Node, workers int) error {
+	nodes := make([]*Node, 0, len(sites))
+	for _, site := range sites {
+		n, ok := site.(*Node)
+		if !ok || n.manager != m {
+			return fmt.Errorf("site %s is not managed by the dind manager", site.ID())
+		}
+		nodes = append(nodes, n)
+	}
+	tags, err := m.imageTags(images)
+	if err != nil {
+		return err
+	}
+	var paths map[string]string
+	if m.registry != nil {
+		if paths, err = m.pushToRegistry(tags); err != nil {
+			return err
+		}
+	}
+
+	errs := make([]error, 0)
+	lock := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	limit := make(chan struct{}, max(workers, 1))
+	for _, n := range nodes {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(n *Node) {
+			defer wg.Done()
+			defer func() { <-limit }()
+			err := func() error {
+				container, err := m.clientDocker.ContainerInspect(context.Background(), n.id)
+				if err != nil {
+					return err
+				}
+				if paths != nil && !container.HostConfig.NetworkMode.IsNone() {
+					return n.pullFromRegistry(paths)
+				}
+				return n.loadImages(tags)
+			}()
+			if err != nil {
+				name, _ := n.Name()
+				lock.Lock()
+				errs = append(errs, fmt.Errorf("site %s: %w", name, err))
+				lock.Unlock()
+			}
+		}(n)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Images lists the tagged images of the host engine that can be distributed
+// to sites.
+func (m *Manager) Images() ([]image.Summary, error) {
+	images, err := m.clientDocker.ImageList(context.Background(), image.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	tagged := make([]image.Summary, 0, len(images))
+	for _, i := range images {
+		if len(i.RepoTags) > 0 {
+			tagged = append(tagged, i)
+		}
+	}
+	return tagged, nil
+}
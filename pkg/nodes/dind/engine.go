@@ -0,0 +1,59 @@
+package dind
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/neaas/nescript"
+	ds "github.com/neaas/nescript/docker"
+)
+
+// socketPath returns the host path of the site's inner docker engine socket.
+func (n *Node) socketPath(name string) string {
+	return path.Join(n.manager.socketDir, name, "docker.sock")
+}
+
+// Engine returns a client connected to the inner docker engine of the site,
+// using the socket that is shared with the host. The engine must be running
+// for requests made with the client to succeed. The caller should close the
+// client when done.
+func (n *Node) Engine() (*client.Client, error) {
+	name, err := n.Name()
+	if err != nil {
+		return nil, fmt.Errorf("could not get site name: %w", err)
+	}
+	return n.engine(name)
+}
+
+// engine returns a client of the inner docker engine of the site with the given
+// name, without looking the name up.
+func (n *Node) engine(name string) (*client.Client, error) {
+	c, err := client.NewClientWithOpts(
+		client.WithHost(fmt.Sprintf("unix://%s", n.socketPath(name))),
+		client.WithAPIVersionNegotiation(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to site docker engine: %w", err)
+	}
+	return c, nil
+}
+
+// run executes a command in the site container, returning an error including
+// the command's stderr if it does not exit successfully.
+func (n *Node) run(env []string, name string, args ...string) (string, error) {
+	cmd := nescript.NewCmd(name, args...).WithEnv(env...)
+	process, err := cmd.Exec(ds.Executor(n.manager.clientDocker, n.id, ""))
+	if err != nil {
+		return "", err
+	}
+	result, err := process.Result()
+	if err != nil {
+		return "", err
+	}
+	if result.ExitCode != 0 {
+		return result.StdOut, fmt.Errorf("%s exited with code %d: %s", name, result.ExitCode, strings.TrimSpace(result.StdErr))
+	}
+	return result.StdOut, nil
+}
@@ -0,0 +1,115 @@
+package dind
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+func (n *Node) Exec(ctx context.Context, options node.ExecOptions) (int, error) {
+	if len(options.Cmd) == 0 {
+		return -1, fmt.Errorf("command is required")
+	}
+	engine, target := n.manager.clientDocker, n.id
+	if options.Container != "" {
+		inner, err := n.Engine()
+		if err != nil {
+			return -1, err
+		}
+		defer inner.Close()
+		engine, target = inner, options.Container
+	}
+	return execute(ctx, engine, target, options)
+}
+
+// execute runs the command in the container using the engine, streaming its
+// input and output, and returns its exit code.
+func execute(ctx context.Context, engine *client.Client, containerID string, options node.ExecOptions) (int, error) {
+	exec, err := engine.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		User:         options.User,
+		Tty:          options.TTY,
+		AttachStdin:  options.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          options.Env,
+		WorkingDir:   options.WorkingDir,
+		Cmd:          options.Cmd,
+	})
+	if err != nil {
+		return -1, fmt.Errorf("could not create exec: %w", err)
+	}
+	resp, err := engine.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{Tty: options.TTY})
+	if err != nil {
+		return -1, fmt.Errorf("could not attach to exec: %w", err)
+	}
+	defer resp.Close()
+
+	if options.Resize != nil {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case size, ok := <-options.Resize:
+					if !ok {
+						return
+					}
+					engine.ContainerExecResize(ctx, exec.ID, container.ResizeOptions{Width: size[0], Height: size[1]})
+				}
+			}
+		}()
+	}
+	if options.Stdin != nil {
+		go func() {
+			io.Copy(resp.Conn, options.Stdin)
+			resp.CloseWrite()
+		}()
+	}
+	stdout, stderr := options.Stdout, options.Stderr
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	done := make(chan error, 1)
+	go func() {
+		if options.TTY {
+			_, err := io.Copy(stdout, resp.Reader)
+			done <- err
+			return
+		}
+		_, err := stdcopy.StdCopy(stdout, stderr, resp.Reader)
+		done <- err
+	}()
+	select {
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	case err := <-done:
+		if err != nil {
+			return -1, fmt.Errorf("could not stream exec output: %w", err)
+		}
+	}
+	// the output stream can close just before the engine records the exit
+	for {
+		inspect, err := engine.ContainerExecInspect(context.Background(), exec.ID)
+		if err != nil {
+			return -1, fmt.Errorf("could not inspect exec: %w", err)
+		}
+		if !inspect.Running {
+			return inspect.ExitCode, nil
+		}
+		select {
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
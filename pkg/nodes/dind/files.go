@@ -0,0 +1,54 @@
+package dind
+
+import (
+	"context"
+	"io"
+
+	"github.com/docker/docker/api/types"
+)
+
+func (n *Node) ArchiveTo(container, dst string, archive io.Reader) error {
+	if container == "" {
+		n.manager.lock.RLock()
+		defer n.manager.lock.RUnlock()
+		return n.manager.clientDocker.CopyToContainer(context.Background(), n.id, dst, archive, types.CopyToContainerOptions{})
+	}
+	engine, err := n.Engine()
+	if err != nil {
+		return err
+	}
+	defer engine.Close()
+	return engine.CopyToContainer(context.Background(), container, dst, archive, types.CopyToContainerOptions{})
+}
+
+func (n *Node) ArchiveFrom(container, src string) (io.ReadCloser, error) {
+	if container == "" {
+		n.manager.lock.RLock()
+		defer n.manager.lock.RUnlock()
+		archive, _, err := n.manager.clientDocker.CopyFromContainer(context.Background(), n.id, src)
+		return archive, err
+	}
+	engine, err := n.Engine()
+	if err != nil {
+		return nil, err
+	}
+	archive, _, err := engine.CopyFromContainer(context.Background(), container, src)
+	if err != nil {
+		engine.Close()
+		return nil, err
+	}
+	return &engineReadCloser{ReadCloser: archive, engine: engine}, nil
+}
+
+// engineReadCloser closes the engine client once the stream read from it is
+// closed.
+type engineReadCloser struct {
+	io.ReadCloser
+	engine io.Closer
+}
+
+func (e *engineReadCloser) Close() error {
+	err := e.ReadCloser.Close()
+	e.engine.Close()
+	return err
+}
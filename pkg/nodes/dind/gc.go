@@ -0,0 +1,130 @@
+package dind
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// Orphans returns the site containers that are not running (e.g. created but
+// never started), and when searching all topologies, the volume copy helpers,
+// incomplete snapshots and, if requested, the volumes of removed persistent
+// sites. Registry containers are never orphans.
+func (m *Manager) Orphans(options node.GCOptions) ([]node.Orphan, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	orphans := make([]node.Orphan, 0)
+	containers, err := m.clientDocker.ContainerList(context.Background(), container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", "net4me=true")),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container list: %w", err)
+	}
+	// mounted holds the volumes used by any container, running or not
+	mounted := make(map[string]bool)
+	for _, c := range containers {
+		for _, mp := range c.Mounts {
+			if mp.Type == mount.TypeVolume {
+				mounted[mp.Name] = true
+			}
+		}
+		orphan := node.Orphan{Device: m.Device(), Kind: "container", ID: c.ID}
+		if len(c.Names) > 0 {
+			orphan.Name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		switch {
+		case c.Labels["net4me.role"] == "registry":
+			continue
+		case c.Labels["net4me.role"] == "storage":
+			if !options.All {
+				continue
+			}
+			orphan.Reason = "volume copy helper was left behind"
+		case c.Labels["net4me.device"] == "dind":
+			topology := c.Labels[node.TopologyLabel]
+			if topology == "" {
+				topology = node.DefaultTopology
+			}
+			if c.State == "running" || (!options.All && topology != options.Topology) {
+				continue
+			}
+			orphan.Reason = fmt.Sprintf("site container is %s", c.State)
+		default:
+			if !options.All {
+				continue
+			}
+			orphan.Reason = "container is neither a site nor a helper"
+		}
+		orphans = append(orphans, orphan)
+	}
+	if !options.All {
+		return orphans, nil
+	}
+
+	images, err := m.clientDocker.ImageList(context.Background(), image.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", snapshotLabel)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshot images: %w", err)
+	}
+	volumes, err := m.clientDocker.VolumeList(context.Background(), volume.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", "net4me=true")),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	snapshotImages := make(map[string]bool)
+	for _, i := range images {
+		snapshotImages[i.Labels[snapshotLabel]] = true
+	}
+	volumeNames := make(map[string]bool)
+	for _, v := range volumes.Volumes {
+		volumeNames[v.Name] = true
+		orphan := node.Orphan{Device: m.Device(), Kind: "volume", ID: v.Name, Name: v.Name}
+		if snapshot := v.Labels[snapshotLabel]; snapshot != "" {
+			if !snapshotImages[snapshot] {
+				orphan.Reason = fmt.Sprintf("volume of snapshot %s has no image", snapshot)
+				orphans = append(orphans, orphan)
+			}
+		} else if site := v.Labels["net4me.site"]; site != "" && options.Volumes && !mounted[v.Name] {
+			orphan.Reason = fmt.Sprintf("volume of persistent site %s is not used by any site", site)
+			orphans = append(orphans, orphan)
+		}
+	}
+	for _, i := range images {
+		if !volumeNames[i.Labels[snapshotVolumeLabel]] {
+			orphans = append(orphans, node.Orphan{
+				Device: m.Device(),
+				Kind:   "image",
+				ID:     i.ID,
+				Name:   fmt.Sprintf("%s:%s", snapshotRepository, i.Labels[snapshotLabel]),
+				Reason: fmt.Sprintf("image of snapshot %s has no volume", i.Labels[snapshotLabel]),
+			})
+		}
+	}
+	return orphans, nil
+}
+
+// RemoveOrphan removes a container, volume or image returned by Orphans.
+func (m *Manager) RemoveOrphan(o node.Orphan) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	switch o.Kind {
+	case "container":
+		return m.clientDocker.ContainerRemove(context.Background(), o.ID, container.RemoveOptions{Force: true, RemoveVolumes: true})
+	case "volume":
+		return m.clientDocker.VolumeRemove(context.Background(), o.ID, false)
+	case "image":
+		_, err := m.clientDocker.ImageRemove(context.Background(), o.ID, image.RemoveOptions{})
+		return err
+	}
+	return fmt.Errorf("unknown orphan kind: %s", o.Kind)
+}
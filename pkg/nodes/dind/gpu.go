@@ -0,0 +1,309 @@
+package dind
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+const (
+	gpuLabel       = "net4me.gpus"
+	gpuSharedLabel = "net4me.gpus.shared"
+	// gpuAll is the value of the gpu label of sites given every gpu.
+	gpuAll = "all"
+)
+
+// GPUDevice is an assignable GPU, or MIG slice of a GPU, on the host. MIG
+// slices reference the UUID of their parent GPU.
+type GPUDevice struct {
+	Index  string `mapstructure:"index" json:"index"`
+	UUID   string `mapstructure:"uuid" json:"uuid"`
+	Name   string `mapstructure:"name" json:"name"`
+	Parent string `mapstructure:"parent" json:"parent,omitempty"`
+}
+
+// GPUInventory lists the GPUs of the host that can be assigned to sites.
+type GPUInventory interface {
+	GPUs() ([]GPUDevice, error)
+}
+
+// StaticGPUInventory is a fixed list of GPUs, e.g. given in the manager
+// config for hosts without nvidia-smi, or to fake devices when testing.
+type StaticGPUInventory []GPUDevice
+
+func (s StaticGPUInventory) GPUs() ([]GPUDevice, error) {
+	return s, nil
+}
+
+// NvidiaSMIInventory lists the GPUs and MIG slices reported by `nvidia-smi -L`.
+type NvidiaSMIInventory struct{}
+
+var (
+	smiGPUPattern = regexp.MustCompile(`^GPU (\d+): (.+) \(UUID: (GPU-[^)]+)\)`)
+	smiMIGPattern = regexp.MustCompile(`^\s+MIG (.+) Device\s+(\d+): \(UUID: (MIG-[^)]+)\)`)
+)
+
+func (NvidiaSMIInventory) GPUs() ([]GPUDevice, error) {
+	out, err := exec.Command("nvidia-smi", "-L").Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list gpus with nvidia-smi: %w", err)
+	}
+	devices := make([]GPUDevice, 0)
+	var parent *GPUDevice
+	for _, line := range strings.Split(string(out), "\n") {
+		if m := smiGPUPattern.FindStringSubmatch(line); m != nil {
+			devices = append(devices, GPUDevice{Index: m[1], Name: m[2], UUID: m[3]})
+			parent = &devices[len(devices)-1]
+			continue
+		}
+		if m := smiMIGPattern.FindStringSubmatch(line); m != nil && parent != nil {
+			devices = append(devices, GPUDevice{
+				Index:  parent.Index + ":" + m[2],
+				Name:   parent.Name + " MIG " + m[1],
+				UUID:   m[3],
+				Parent: parent.UUID,
+			})
+		}
+	}
+	return devices, nil
+}
+
+// GPUConfig requests GPUs for a site. Either a Count of GPUs is allocated from
+// those not yet assigned, or the specific Devices (by index, UUID, or MIG
+// index/UUID such as `0:1`) are assigned. Unless Shared is set, devices are
+// assigned exclusively, and can not be given to any other site.
+//
+// Sites run privileged, so every /dev/nvidia* device node of the host is
+// visible within a site whatever its assignment. The assignment decides which
+// devices the container runtime sets up for the site (e.g. for CUDA), and
+// prevents sites being given the same device, but it is not enforced against
+// a site that opens other device nodes directly.
+type GPUConfig struct {
+	Count        int      `mapstructure:"count"`
+	Devices      []string `mapstructure:"devices"`
+	Capabilities []string `mapstructure:"capabilities"`
+	Driver       string   `mapstructure:"driver"`
+	Shared       bool     `mapstructure:"shared"`
+}
+
+// gpuAllocation is the assignment of devices to sites, keyed by device UUID.
+// Sites given every gpu share them, so are kept separately.
+type gpuAllocation struct {
+	exclusive map[string]string
+	shared    map[string][]string
+	all       []string
+}
+
+// gpuAllocations rebuilds the current allocations from the labels of the
+// existing sites, so allocations are tracked across runs of net4me.
+func (m *Manager) gpuAllocations() (*gpuAllocation, error) {
+	containers, err := m.clientDocker.ContainerList(context.Background(), container.ListOptions{
+		All: true,
+		Filters: filters.NewArgs(
+			filters.Arg("label", "net4me.device=dind"),
+			filters.Arg("label", gpuLabel),
+		),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sites with gpus: %w", err)
+	}
+	labels := make([]map[string]string, 0, len(containers))
+	for _, c := range containers {
+		labels = append(labels, c.Labels)
+	}
+	return newGPUAllocation(labels), nil
+}
+
+// newGPUAllocation builds the allocations from the labels of the sites.
+func newGPUAllocation(sites []map[string]string) *gpuAllocation {
+	a := &gpuAllocation{
+		exclusive: make(map[string]string),
+		shared:    make(map[string][]string),
+	}
+	for _, labels := range sites {
+		site := labels["net4me.device.name"]
+		if labels[gpuLabel] == gpuAll {
+			a.all = append(a.all, site)
+			continue
+		}
+		for _, id := range strings.Split(labels[gpuLabel], ",") {
+			if id == "" {
+				continue
+			}
+			if labels[gpuSharedLabel] == "true" {
+				a.shared[id] = append(a.shared[id], site)
+			} else {
+				a.exclusive[id] = site
+			}
+		}
+	}
+	return a
+}
+
+// conflict returns the site holding an allocation that prevents the device
+// being assigned. A GPU conflicts with its own MIG slices, and vice versa, and
+// every device is shared by the sites given all gpus.
+func (a *gpuAllocation) conflict(device GPUDevice, inventory []GPUDevice, shared bool) string {
+	if !shared && len(a.all) > 0 {
+		return a.all[0]
+	}
+	related := []string{device.UUID}
+	if device.Parent != "" {
+		related = append(related, device.Parent)
+	}
+	for _, d := range inventory {
+		if d.Parent == device.UUID {
+			related = append(related, d.UUID)
+		}
+	}
+	for _, id := range related {
+		if site, ok := a.exclusive[id]; ok {
+			return site
+		}
+		if !shared && len(a.shared[id]) > 0 {
+			return a.shared[id][0]
+		}
+	}
+	return ""
+}
+
+// resolveGPU finds the device in the inventory matching the identifier.
+func resolveGPU(id string, inventory []GPUDevice) (GPUDevice, error) {
+	for _, d := range inventory {
+		if d.UUID == id || d.Index == id {
+			return d, nil
+		}
+	}
+	return GPUDevice{}, fmt.Errorf("gpu not found: %s", id)
+}
+
+// allocateAllGPUs checks that every gpu can be given to a site, i.e. that no
+// gpu is assigned exclusively to another site. The manager's gpu lock must be
+// held until the site is created with the gpuAll label.
+func (m *Manager) allocateAllGPUs() error {
+	allocation, err := m.gpuAllocations()
+	if err != nil {
+		return err
+	}
+	return allocation.checkAll()
+}
+
+func (a *gpuAllocation) checkAll() error {
+	for id, site := range a.exclusive {
+		return fmt.Errorf("gpu %s is assigned exclusively to site %s", id, site)
+	}
+	return nil
+}
+
+// allocateGPUs selects the devices for the request, returning the device
+// request for the site container and the UUIDs of the assigned devices. The
+// manager's gpu lock must be held until the site is created with the
+// returned assignment labels.
+func (m *Manager) allocateGPUs(c *GPUConfig) (*container.DeviceRequest, []string, error) {
+	if c.Count == 0 && len(c.Devices) == 0 {
+		return nil, nil, nil
+	}
+	if m.gpus == nil {
+		return nil, nil, fmt.Errorf("no gpu inventory available")
+	}
+	inventory, err := m.gpus.GPUs()
+	if err != nil {
+		return nil, nil, err
+	}
+	allocation, err := m.gpuAllocations()
+	if err != nil {
+		return nil, nil, err
+	}
+	return selectGPUs(c, inventory, allocation)
+}
+
+// selectGPUs selects the devices of the inventory for the request, given the
+// current allocation.
+func selectGPUs(c *GPUConfig, inventory []GPUDevice, allocation *gpuAllocation) (*container.DeviceRequest, []string, error) {
+	if c.Count != 0 && len(c.Devices) > 0 {
+		return nil, nil, fmt.Errorf("gpu count and devices can not both be set")
+	}
+	request := &container.DeviceRequest{
+		Driver:       c.Driver,
+		Capabilities: [][]string{c.Capabilities},
+	}
+	if request.Driver == "" {
+		request.Driver = "nvidia"
+	}
+	if len(c.Capabilities) == 0 {
+		request.Capabilities = [][]string{{"gpu"}}
+	}
+
+	selected := make([]GPUDevice, 0)
+	if len(c.Devices) > 0 {
+		for _, id := range c.Devices {
+			device, err := resolveGPU(id, inventory)
+			if err != nil {
+				return nil, nil, err
+			}
+			if site := allocation.conflict(device, inventory, c.Shared); site != "" {
+				return nil, nil, fmt.Errorf("gpu %s is already assigned to site %s", id, site)
+			}
+			selected = append(selected, device)
+		}
+	} else {
+		count := c.Count
+		if count < 0 {
+			count = 0
+			for _, d := range inventory {
+				if d.Parent == "" {
+					count++
+				}
+			}
+		}
+		for _, d := range inventory {
+			if len(selected) == count {
+				break
+			}
+			// count requests are given whole gpus
+			if d.Parent != "" || allocation.conflict(d, inventory, c.Shared) != "" {
+				continue
+			}
+			selected = append(selected, d)
+		}
+		if len(selected) < count {
+			return nil, nil, fmt.Errorf("only %d of %d requested gpus are available", len(selected), count)
+		}
+	}
+	ids := make([]string, 0, len(selected))
+	for _, d := range selected {
+		ids = append(ids, d.UUID)
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	request.DeviceIDs = ids
+	return request, ids, nil
+}
+
+// gpuInfo reports the gpus assigned to a site from its labels.
+func gpuInfo(labels map[string]string) map[string]any {
+	if labels[gpuLabel] == gpuAll {
+		return map[string]any{
+			"devices": []string{gpuAll},
+			"shared":  true,
+		}
+	}
+	ids := make([]string, 0)
+	for _, id := range strings.Split(labels[gpuLabel], ",") {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	shared, _ := strconv.ParseBool(labels[gpuSharedLabel])
+	return map[string]any{
+		"devices": ids,
+		"shared":  shared,
+	}
+}
@@ -0,0 +1,103 @@
+package dind
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+var testInventory = StaticGPUInventory{
+	{Index: "0", UUID: "GPU-a", Name: "A100"},
+	{Index: "1", UUID: "GPU-b", Name: "A100"},
+	{Index: "1:1", UUID: "MIG-b1", Name: "A100 MIG 1g.10gb", Parent: "GPU-b"},
+	{Index: "1:2", UUID: "MIG-b2", Name: "A100 MIG 1g.10gb", Parent: "GPU-b"},
+}
+
+// site returns the labels of a site assigned the gpus.
+func site(name, gpus string, shared bool) map[string]string {
+	labels := map[string]string{"net4me.device.name": name, gpuLabel: gpus}
+	if shared {
+		labels[gpuSharedLabel] = "true"
+	}
+	return labels
+}
+
+func TestSelectGPUs(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  GPUConfig
+		sites   []map[string]string
+		want    []string
+		wantErr bool
+	}{
+		{name: "count", config: GPUConfig{Count: 1}, want: []string{"GPU-a"}},
+		{name: "count of all", config: GPUConfig{Count: -1}, want: []string{"GPU-a", "GPU-b"}},
+		{name: "count skips assigned", config: GPUConfig{Count: 1}, sites: []map[string]string{site("s1", "GPU-a", false)}, want: []string{"GPU-b"}},
+		{name: "count skips gpu with assigned slice", config: GPUConfig{Count: 1}, sites: []map[string]string{site("s1", "MIG-b1", false)}, want: []string{"GPU-a"}},
+		{name: "count unavailable", config: GPUConfig{Count: 2}, sites: []map[string]string{site("s1", "GPU-a", false)}, wantErr: true},
+		{name: "device by index", config: GPUConfig{Devices: []string{"1"}}, want: []string{"GPU-b"}},
+		{name: "device by uuid", config: GPUConfig{Devices: []string{"GPU-a"}}, want: []string{"GPU-a"}},
+		{name: "mig slices", config: GPUConfig{Devices: []string{"1:2", "MIG-b1"}}, want: []string{"MIG-b1", "MIG-b2"}},
+		{name: "duplicate devices", config: GPUConfig{Devices: []string{"0", "GPU-a"}}, want: []string{"GPU-a"}},
+		{name: "unknown device", config: GPUConfig{Devices: []string{"7"}}, wantErr: true},
+		{name: "count and devices", config: GPUConfig{Count: 1, Devices: []string{"0"}}, wantErr: true},
+		{name: "exclusive device assigned", config: GPUConfig{Devices: []string{"0"}}, sites: []map[string]string{site("s1", "GPU-a", false)}, wantErr: true},
+		{name: "slice of assigned gpu", config: GPUConfig{Devices: []string{"1:1"}}, sites: []map[string]string{site("s1", "GPU-b", false)}, wantErr: true},
+		{name: "gpu of assigned slice", config: GPUConfig{Devices: []string{"1"}}, sites: []map[string]string{site("s1", "MIG-b2", false)}, wantErr: true},
+		{name: "other slice of gpu", config: GPUConfig{Devices: []string{"1:2"}}, sites: []map[string]string{site("s1", "MIG-b1", false)}, want: []string{"MIG-b2"}},
+		{name: "shared with shared", config: GPUConfig{Devices: []string{"0"}, Shared: true}, sites: []map[string]string{site("s1", "GPU-a", true)}, want: []string{"GPU-a"}},
+		{name: "exclusive with shared", config: GPUConfig{Devices: []string{"0"}}, sites: []map[string]string{site("s1", "GPU-a", true)}, wantErr: true},
+		{name: "shared with exclusive", config: GPUConfig{Devices: []string{"0"}, Shared: true}, sites: []map[string]string{site("s1", "GPU-a", false)}, wantErr: true},
+		{name: "exclusive with all gpus site", config: GPUConfig{Count: 1}, sites: []map[string]string{site("s1", gpuAll, true)}, wantErr: true},
+		{name: "shared with all gpus site", config: GPUConfig{Devices: []string{"0"}, Shared: true}, sites: []map[string]string{site("s1", gpuAll, true)}, want: []string{"GPU-a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventory, err := testInventory.GPUs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			request, ids, err := selectGPUs(&tt.config, inventory, newGPUAllocation(tt.sites))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", ids)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(ids, tt.want) || !slices.Equal(request.DeviceIDs, tt.want) {
+				t.Fatalf("got %v, want %v", ids, tt.want)
+			}
+			if request.Driver != "nvidia" || !slices.Equal(request.Capabilities[0], []string{"gpu"}) {
+				t.Fatalf("unexpected device request %+v", request)
+			}
+		})
+	}
+}
+
+func TestAllGPUs(t *testing.T) {
+	if err := newGPUAllocation(nil).checkAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := newGPUAllocation([]map[string]string{site("s1", "GPU-a", true), site("s2", gpuAll, true)}).checkAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := newGPUAllocation([]map[string]string{site("s1", "MIG-b1", false)}).checkAll(); err == nil {
+		t.Fatal("expected an error when a gpu is assigned exclusively")
+	}
+	info := gpuInfo(site("s1", gpuAll, true))
+	if !slices.Equal(info["devices"].([]string), []string{gpuAll}) || info["shared"] != true {
+		t.Fatalf("unexpected gpu info %v", info)
+	}
+}
+
+func TestSetGPUInventory(t *testing.T) {
+	m := &Manager{lock: &sync.RWMutex{}}
+	m.SetGPUInventory(testInventory)
+	devices, err := m.gpus.GPUs()
+	if err != nil || len(devices) != len(testInventory) {
+		t.Fatalf("got %v (%v)", devices, err)
+	}
+}
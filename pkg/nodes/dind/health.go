@@ -0,0 +1,93 @@
+package dind
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/node"
+)
+
+// ReadinessConfig configures how long a started site is given for its inner
+// docker engine to respond on its socket, and how often it is checked. The
+// interval between checks starts at Backoff and doubles up to MaxBackoff.
+type ReadinessConfig struct {
+	// Timeout is in seconds.
+	Timeout int `mapstructure:"timeout"`
+	// Backoff and MaxBackoff are in milliseconds.
+	Backoff    int `mapstructure:"backoff"`
+	MaxBackoff int `mapstructure:"maxBackoff"`
+}
+
+// pingTimeout is the time allowed for a single ping of the inner engine.
+const pingTimeout = 2 * time.Second
+
+func defaultReadinessConfig() map[string]any {
+	return map[string]any{
+		"timeout":    60,
+		"backoff":    100,
+		"maxBackoff": 2000,
+	}
+}
+
+func (c ReadinessConfig) timeout() time.Duration {
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// ping checks that the inner engine of the site with the given name responds
+// to requests on its socket.
+func (n *Node) ping(name string) error {
+	c, err := n.engine(name)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	_, err = c.Ping(ctx)
+	return err
+}
+
+// waitReady waits for the inner engine of the site with the given name to
+// respond to a ping, as the dockerd process exists well before it serves
+// requests. If the engine does not respond within the readiness timeout of the
+// manager, an error is returned.
+func (n *Node) waitReady(name string) error {
+	readiness := n.manager.readiness
+	deadline := time.Now().Add(readiness.timeout())
+	backoff := time.Duration(readiness.Backoff) * time.Millisecond
+	for {
+		err := n.ping(name)
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("docker-in-docker engine did not become ready within %s: %w", readiness.timeout(), err)
+		}
+		time.Sleep(backoff)
+		backoff = min(2*backoff, time.Duration(readiness.MaxBackoff)*time.Millisecond)
+	}
+}
+
+// Health returns healthy if the inner engine of the site responds to a ping.
+// Otherwise, the site is starting until its dockerd process has been running
+// for longer than the readiness timeout, after which it is unhealthy.
+func (n *Node) Health() node.NodeHealth {
+	name, err := n.Name()
+	if err != nil {
+		return node.NodeHealthUnhealthy
+	}
+	if err := n.ping(name); err == nil {
+		return node.NodeHealthHealthy
+	}
+	processes, err := n.dockerdProcesses()
+	if err != nil || len(processes) == 0 {
+		return node.NodeHealthUnhealthy
+	}
+	for _, p := range processes {
+		if p.elapsed > n.manager.readiness.timeout() {
+			return node.NodeHealthUnhealthy
+		}
+	}
+	return node.NodeHealthStarting
+}
@@ -0,0 +1,13 @@
+package dind
+
+import "context"
+
+func (n *Node) Labels() (map[string]string, error) {
+	n.manager.lock.RLock()
+	defer n.manager.lock.RUnlock()
+	container, err := n.manager.clientDocker.ContainerInspect(context.Background(), n.id)
+	if err != nil {
+		return nil, err
+	}
+	return container.Config.Labels, nil
+}
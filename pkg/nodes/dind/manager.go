@@ -6,12 +6,15 @@ import (
 	"maps"
 	"os"
 	"path"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/ai4networks/net4me/pkg/node"
 	"github.com/docker/cli/opts"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/client"
@@ -23,18 +26,59 @@ type Manager struct {
 	lock         *sync.RWMutex
 	dind         *DinD
 	socketDir    string
+	isolated     bool
+	registry     *registry
+	gpus         GPUInventory
+	gpuLock      *sync.Mutex
+	readiness    ReadinessConfig
 	clientDocker *client.Client
 }
 
 type ManagerConfig struct {
-	Host       string   `mapstructure:"host"`
-	Image      string   `mapstructure:"image"`
-	Command    []string `mapstructure:"command"`
-	AlwaysPull bool     `mapstructure:"alwaysPull"`
+	Host       string         `mapstructure:"host"`
+	Image      string         `mapstructure:"image"`
+	Command    []string       `mapstructure:"command"`
+	AlwaysPull bool           `mapstructure:"alwaysPull"`
+	Isolated   bool           `mapstructure:"isolated"`
+	Registry   RegistryConfig `mapstructure:"registry"`
+	GPUs       []GPUDevice    `mapstructure:"gpus"`
+	// Readiness configures the wait for a started site's engine.
+	Readiness ReadinessConfig `mapstructure:"readiness"`
 }
 
+// AddConfig is the per-site configuration. When Isolated is set, the site is
+// not attached to any docker network, so the only way traffic can leave the
+// site is via the emulated links added to it. When not set, the manager
+// default is used.
+//
+// GPU attaches every GPU of the host to the site, sharing them with any other
+// site given every GPU, whereas GPUs requests specific (or a number of) GPUs.
+// Both are tracked, so that no GPU is given both to a site exclusively and to
+// another site. See GPUConfig for what the assignment does and does not
+// isolate.
 type AddConfig struct {
-	GPU bool `mapstructure:"gpu"`
+	GPU       bool       `mapstructure:"gpu"`
+	GPUs      *GPUConfig `mapstructure:"gpus"`
+	Isolated  *bool      `mapstructure:"isolated"`
+	Resources Resources  `mapstructure:"resources"`
+	// Daemon configures the site's inner dockerd, and Command replaces the
+	// manager's command used to start it.
+	Daemon  DaemonConfig `mapstructure:"daemon"`
+	Command []string     `mapstructure:"command"`
+	// Persistent keeps the inner docker data root in a named volume that
+	// outlives the site, using Volume as its name if given. Snapshot creates
+	// the site from a snapshot, with a persistent copy of its data root.
+	Persistent bool   `mapstructure:"persistent"`
+	Volume     string `mapstructure:"volume"`
+	Snapshot   string `mapstructure:"snapshot"`
+}
+
+// SetGPUInventory replaces the inventory that GPUs are assigned from, e.g. to
+// use a fake set of devices when testing.
+func (m *Manager) SetGPUInventory(inventory GPUInventory) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.gpus = inventory
 }
 
 func (m *Manager) Device() string {
@@ -49,13 +93,31 @@ func (m *Manager) Setup(config map[string]any) error {
 		"image":      "docker:dind",
 		"command":    []string{"dockerd-entrypoint.sh"},
 		"alwaysPull": false,
+		"isolated":   false,
+		"readiness":  defaultReadinessConfig(),
 	}
 	maps.Copy(defaultConfig, config)
+	if readiness, ok := config["readiness"].(map[string]any); ok {
+		// fill in the readiness settings that are not configured
+		defaultReadiness := defaultReadinessConfig()
+		maps.Copy(defaultReadiness, readiness)
+		defaultConfig["readiness"] = defaultReadiness
+	}
 	c := ManagerConfig{}
 	if err := mapstructure.Decode(defaultConfig, &c); err != nil {
 		return fmt.Errorf("failed to decode dind manager config: %w", err)
 	}
 	m.dind = NewDinD(c.Image, c.Command, c.AlwaysPull)
+	m.isolated = c.Isolated
+	if c.Readiness.Timeout <= 0 || c.Readiness.Backoff <= 0 || c.Readiness.MaxBackoff < c.Readiness.Backoff {
+		return fmt.Errorf("invalid dind readiness config: timeout and backoff must be positive, and maxBackoff at least backoff")
+	}
+	m.readiness = c.Readiness
+	if len(c.GPUs) > 0 {
+		m.gpus = StaticGPUInventory(c.GPUs)
+	} else if m.gpus == nil {
+		m.gpus = NvidiaSMIInventory{}
+	}
 	clientDocker, err := client.NewClientWithOpts(client.WithHost(c.Host), client.WithAPIVersionNegotiation())
 	if err != nil {
 		return fmt.Errorf("failed to create docker client: %w", err)
@@ -64,6 +126,9 @@ func (m *Manager) Setup(config map[string]any) error {
 	if err := m.PullDinD(); err != nil {
 		return fmt.Errorf("failed to find or pull docker-in-docker image: %w", err)
 	}
+	if err := m.setupRegistry(c.Registry); err != nil {
+		return fmt.Errorf("failed to setup shared registry: %w", err)
+	}
 	if path, err := os.MkdirTemp("", "net4me-dind-socket-*"); err != nil {
 		return fmt.Errorf("failed to create temporary directory for dind sockets: %w", err)
 	} else {
@@ -103,7 +168,6 @@ func (m *Manager) Color() string {
 
 func (m *Manager) Nodes(nodeFilters ...node.NodeFilter) ([]node.Node, error) {
 	m.lock.RLock()
-	defer m.lock.RUnlock()
 	nodes := make([]node.Node, 0)
 	containers, err := m.clientDocker.ContainerList(context.Background(), container.ListOptions{
 		Filters: filters.NewArgs(
@@ -111,12 +175,17 @@ func (m *Manager) Nodes(nodeFilters ...node.NodeFilter) ([]node.Node, error) {
 			filters.Arg("label", "net4me.device=dind"),
 		),
 	})
+	// filters may inspect the nodes, which takes the lock again
+	m.lock.RUnlock()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get container list: %w", err)
 	}
 	for _, container := range containers {
 		nodes = append(nodes, m.newNode(container.ID))
 	}
+	for _, filter := range nodeFilters {
+		nodes = filter(nodes)
+	}
 	return nodes, nil
 }
 
@@ -137,35 +206,118 @@ func (m *Manager) Add(name string, labels map[string]string, config map[string]a
 		"net4me.device.name": name,
 	}
 	maps.Copy(labels, defaultLabels)
+	engineLabels, err := siteLabels(c.Daemon, c.Command)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(labels, engineLabels)
 
+	networkMode := container.NetworkMode("default")
+	if (c.Isolated == nil && m.isolated) || (c.Isolated != nil && *c.Isolated) {
+		networkMode = "none"
+	}
+
+	imageName := m.dind.imageName
+	mounts := make([]mount.Mount, 0)
+	volumeName := c.Volume
+	if volumeName == "" && (c.Persistent || c.Snapshot != "") {
+		volumeName = siteVolumeName(name)
+	}
+	// a volume restored from a snapshot is removed if the site is not started
+	restored := false
+	defer func() {
+		if restored {
+			m.clientDocker.VolumeRemove(context.Background(), volumeName, true)
+		}
+	}()
+	if volumeName != "" {
+		volumeLabels := map[string]string{"net4me": "true", "net4me.site": name}
+		if c.Snapshot != "" {
+			snapshot, err := m.FindSnapshot(c.Snapshot)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := m.clientDocker.VolumeInspect(context.Background(), volumeName); err == nil {
+				return nil, fmt.Errorf("volume %s already exists, so can not be restored into", volumeName)
+			}
+			if err := m.ensureVolume(volumeName, volumeLabels); err != nil {
+				return nil, err
+			}
+			restored = true
+			if err := m.copyVolume(mount.Mount{Type: mount.TypeVolume, Source: snapshot.Volume}, "", volumeName); err != nil {
+				return nil, fmt.Errorf("could not restore snapshot %s: %w", c.Snapshot, err)
+			}
+			inspect, _, err := m.clientDocker.ImageInspectWithRaw(context.Background(), snapshot.Image)
+			if err != nil {
+				return nil, fmt.Errorf("could not inspect snapshot %s: %w", c.Snapshot, err)
+			}
+			if inspect.Config != nil {
+				clearSnapshotLabels(labels, inspect.Config.Labels)
+			}
+			imageName = snapshot.Image
+		}
+		if err := m.ensureVolume(volumeName, volumeLabels); err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, mount.Mount{Type: mount.TypeVolume, Source: volumeName, Target: dockerRoot})
+		labels["net4me.volume"] = volumeName
+	}
+
+	resources, err := c.Resources.containerResources()
+	if err != nil {
+		return nil, fmt.Errorf("invalid resources for dind container %s: %w", name, err)
+	}
 	deviceRequests := make([]container.DeviceRequest, 0)
+	if c.GPU && c.GPUs != nil {
+		return nil, fmt.Errorf("gpu and gpus can not both be set")
+	}
+	if c.GPU || c.GPUs != nil {
+		m.gpuLock.Lock()
+		defer m.gpuLock.Unlock()
+	}
 	if c.GPU {
+		if err := m.allocateAllGPUs(); err != nil {
+			return nil, fmt.Errorf("could not assign gpus to dind container %s: %w", name, err)
+		}
 		gpuOpts := &opts.GpuOpts{}
 		gpuOpts.Set("all")
 		deviceRequests = append(deviceRequests, gpuOpts.Value()...)
+		labels[gpuLabel] = gpuAll
+		labels[gpuSharedLabel] = "true"
 	}
+	if c.GPUs != nil {
+		request, ids, err := m.allocateGPUs(c.GPUs)
+		if err != nil {
+			return nil, fmt.Errorf("could not assign gpus to dind container %s: %w", name, err)
+		}
+		if request != nil {
+			deviceRequests = append(deviceRequests, *request)
+			labels[gpuLabel] = strings.Join(ids, ",")
+			labels[gpuSharedLabel] = strconv.FormatBool(c.GPUs.Shared)
+		}
+	}
+	resources.DeviceRequests = deviceRequests
 
 	resp, err := m.clientDocker.ContainerCreate(
 		context.Background(),
 		&container.Config{
 			Hostname: name,
-			Image:    m.dind.imageName,
+			Image:    imageName,
 			Labels:   labels,
 			// NetworkDisabled: true,
 			Entrypoint: strslice.StrSlice{"tail"},
 			Cmd:        strslice.StrSlice{"-f", "/dev/null"},
 		},
 		&container.HostConfig{
-			AutoRemove: true,
-			// NetworkMode: "none",
-			Privileged: true,
+			AutoRemove:  true,
+			NetworkMode: networkMode,
+			Privileged:  true,
 			Binds: []string{
 				path.Join(m.socketDir, name) + ":/var/run/",
 				"/:/host",
 			},
-			Resources: container.Resources{
-				DeviceRequests: deviceRequests,
-			},
+			Mounts:    mounts,
+			Resources: resources,
 		},
 		&network.NetworkingConfig{},
 		&v1.Platform{},
@@ -179,8 +331,12 @@ func (m *Manager) Add(name string, labels map[string]string, config map[string]a
 		resp.ID,
 		container.StartOptions{},
 	); err != nil {
+		if restored {
+			m.clientDocker.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+		}
 		return nil, fmt.Errorf("could not start dind container %s: %w", name, err)
 	}
+	restored = false
 	return m.newNode(resp.ID), nil
 }
 
@@ -197,6 +353,7 @@ func (m *Manager) Remove(n node.Node) error {
 
 func init() {
 	node.RegisterManager(&Manager{
-		lock: &sync.RWMutex{},
+		lock:    &sync.RWMutex{},
+		gpuLock: &sync.Mutex{},
 	})
 }
@@ -4,10 +4,8 @@ import (
 	"context"
 	"fmt"
 	"path"
-	"strconv"
 	"strings"
 	"syscall"
-	"time"
 
 	"github.com/ai4networks/net4me/pkg/node"
 	"github.com/ai4networks/net4me/pkg/port"
@@ -51,99 +49,68 @@ func (n *Node) Device() string {
 	return n.manager.Device()
 }
 
+// Start starts the inner docker engine of the site and waits for it to become
+// ready. The manager is not locked while waiting, so that a slow site does not
+// hold up the manager.
 func (n *Node) Start() error {
+	name, err := n.start()
+	if err != nil || name == "" {
+		return err
+	}
+	return n.waitReady(name)
+}
+
+// start starts the dockerd process of the site, returning the name of the site
+// or an empty name if the site has no command.
+func (n *Node) start() (string, error) {
 	n.manager.lock.RLock()
 	defer n.manager.lock.RUnlock()
-	if len(n.manager.dind.command) == 0 {
-		return nil
+	container, err := n.manager.clientDocker.ContainerInspect(context.Background(), n.id)
+	if err != nil {
+		return "", err
+	}
+	daemon, command, err := n.siteConfig(container)
+	if err != nil {
+		return "", err
+	}
+	if len(command) == 0 {
+		return "", nil
+	}
+	if err := n.writeDaemonConfig(daemon.merge(n.manager.registryDaemonConfig(container.HostConfig.NetworkMode))); err != nil {
+		return "", err
 	}
 	var cmd *nescript.Cmd
-	if len(n.manager.dind.command) == 1 {
-		cmd = nescript.NewCmd(n.manager.dind.command[0])
+	if len(command) == 1 {
+		cmd = nescript.NewCmd(command[0])
 	} else {
-		cmd = nescript.NewCmd(n.manager.dind.command[0], n.manager.dind.command[1:]...)
+		cmd = nescript.NewCmd(command[0], command[1:]...)
 	}
 	if _, err := cmd.Exec(ds.Executor(n.manager.clientDocker, n.id, "")); err != nil {
-		return err
-	}
-	checkCounter := 0
-	for {
-		if n.Running() {
-			break
-		}
-		checkCounter := checkCounter + 1
-		if checkCounter > 10 {
-			return fmt.Errorf("docker-in-docker container did not start in time")
-		}
-		time.Sleep(500)
+		return "", err
 	}
-	return nil
+	return strings.TrimPrefix(container.Name, "/"), nil
 }
 
 func (n *Node) Stop() error {
 	n.manager.lock.RLock()
 	defer n.manager.lock.RUnlock()
-	resp, err := n.manager.clientDocker.ContainerTop(
-		context.Background(),
-		n.id,
-		make([]string, 0),
-	)
+	pids, err := n.dockerdPIDs()
 	if err != nil {
-		return err
+		return fmt.Errorf("could not find docker process in dind: %w", err)
 	}
-	if len(resp.Processes) == 0 {
-		return nil
-	}
-	commandIndex := -1
-	pidIndex := -1
-	for i, title := range resp.Titles {
-		if title == "CMD" {
-			commandIndex = i
-		}
-		if title == "PID" {
-			pidIndex = i
-		}
-	}
-	if commandIndex == -1 || pidIndex == -1 {
-		return fmt.Errorf("could not find docker process in dind")
-	}
-	for _, process := range resp.Processes {
-		if strings.Contains(process[commandIndex], "dockerd --host=unix:///var/run/docker.sock") {
-			pid, err := strconv.Atoi(process[pidIndex])
-			if err != nil {
-				return fmt.Errorf("could not find docker process in dind")
-			}
-			return syscall.Kill(pid, syscall.SIGTERM)
+	for _, pid := range pids {
+		if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+			return fmt.Errorf("could not stop docker process in dind: %w", err)
 		}
 	}
 	return nil
 }
 
+// Running returns true if a dockerd process is running in the site. A running
+// site is not necessarily ready for use, see Health.
 func (n *Node) Running() bool {
-	resp, err := n.manager.clientDocker.ContainerTop(
-		context.Background(),
-		n.id,
-		make([]string, 0),
-	)
-	if err != nil {
-		return false
-	}
-	commandIndex := -1
-	for i, title := range resp.Titles {
-		if title == "CMD" {
-			commandIndex = i
-			break
-		}
-	}
-	if commandIndex == -1 {
-		return false
-	}
-	for _, process := range resp.Processes {
-		if strings.Contains(process[commandIndex], "dockerd --host=unix:///var/run/docker.sock") {
-			return true
-		}
-	}
-	return false
+	pids, err := n.dockerdPIDs()
+	return err == nil && len(pids) > 0
 }
 
 func (n *Node) Info() (map[string]any, error) {
@@ -153,12 +120,22 @@ func (n *Node) Info() (map[string]any, error) {
 	if err != nil {
 		return nil, err
 	}
+	daemon, command, err := n.siteConfig(container)
+	if err != nil {
+		return nil, err
+	}
 	return map[string]any{
-		"id":      container.ID,
-		"name":    container.Name,
-		"created": container.Created,
-		"state":   container.State.Status,
-		"socket":  path.Join(n.manager.socketDir, container.Name, "docker.sock"),
+		"id":        container.ID,
+		"name":      container.Name,
+		"created":   container.Created,
+		"state":     container.State.Status,
+		"socket":    path.Join(n.manager.socketDir, container.Name, "docker.sock"),
+		"network":   string(container.HostConfig.NetworkMode),
+		"resources": effectiveResources(container.HostConfig.Resources),
+		"gpus":      gpuInfo(container.Config.Labels),
+		"daemon":    daemon,
+		"command":   command,
+		"volume":    container.Config.Labels["net4me.volume"],
 	}, nil
 }
 
@@ -179,7 +156,7 @@ func (n *Node) Ports() ([]port.Port, error) {
 func (n *Node) PortAdd(p port.Port) error {
 	n.manager.lock.RLock()
 	defer n.manager.lock.RUnlock()
-	if err := port.TakePort(n.NetNs(), p); err != nil {
+	if err := port.TopologyPool(node.TopologyOf(n)).Take(n.NetNs(), p); err != nil {
 		return err
 	}
 	return nil
@@ -188,7 +165,7 @@ func (n *Node) PortAdd(p port.Port) error {
 func (n *Node) PortRemove(p port.Port) error {
 	n.manager.lock.RLock()
 	defer n.manager.lock.RUnlock()
-	if err := port.GivePort(n.NetNs(), p); err != nil {
+	if err := port.TopologyPool(node.TopologyOf(n)).Give(n.NetNs(), p); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,204 @@
+package dind
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	registryContainerName = "net4me-registry"
+	mirrorContainerName   = "net4me-mirror"
+	registryInternalPort  = "5000/tcp"
+)
+
+// RegistryConfig configures the net4me managed registries shared by all sites.
+// The registry holds images distributed from the host engine, and is only
+// published on the host's loopback address. If an upstream Mirror URL is set,
+// a second registry is run as a pull-through cache of it, and sites use it as
+// a registry mirror.
+type RegistryConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Image      string `mapstructure:"image"`
+	Port       int    `mapstructure:"port"`
+	Mirror     string `mapstructure:"mirror"`
+	MirrorPort int    `mapstructure:"mirrorPort"`
+}
+
+// registry is the state of the running shared registries. The addresses are
+// those reachable from sites attached to the default docker network.
+type registry struct {
+	port          int
+	address       string
+	mirrorAddress string
+}
+
+// setupRegistry ensures the shared registry (and mirror when configured) are
+// running on the host engine.
+func (m *Manager) setupRegistry(c RegistryConfig) error {
+	if !c.Enabled {
+		m.registry = nil
+		return nil
+	}
+	if c.Image == "" {
+		c.Image = "registry:2"
+	}
+	if c.Port == 0 {
+		c.Port = 5000
+	}
+	if c.MirrorPort == 0 {
+		c.MirrorPort = c.Port + 1
+	}
+	if !m.siteImageExists(c.Image) {
+		if err := m.siteImagePull(c.Image); err != nil {
+			return fmt.Errorf("failed to pull registry image: %w", err)
+		}
+	}
+	r := &registry{port: c.Port}
+	address, err := m.ensureRegistry(registryContainerName, c.Image, c.Port, nil)
+	if err != nil {
+		return err
+	}
+	r.address = address
+	if c.Mirror != "" {
+		address, err := m.ensureRegistry(mirrorContainerName, c.Image, c.MirrorPort, []string{"REGISTRY_PROXY_REMOTEURL=" + c.Mirror})
+		if err != nil {
+			return err
+		}
+		r.mirrorAddress = address
+	}
+	m.registry = r
+	return nil
+}
+
+// ensureRegistry creates (or starts) a registry container, returning the
+// address it can be reached at from the default docker network. Registry
+// storage is kept in a named volume, so cached layers outlive the container.
+func (m *Manager) ensureRegistry(name, imageName string, port int, env []string) (string, error) {
+	containers, err := m.clientDocker.ContainerList(context.Background(), container.ListOptions{
+		All: true,
+		Filters: filters.NewArgs(
+			filters.Arg("label", "net4me.role=registry"),
+			filters.Arg("name", "^/"+name+"$"),
+		),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to find registry container: %w", err)
+	}
+	id := ""
+	if len(containers) > 0 {
+		id = containers[0].ID
+	} else {
+		resp, err := m.clientDocker.ContainerCreate(
+			context.Background(),
+			&container.Config{
+				Image: imageName,
+				Env:   env,
+				Labels: map[string]string{
+					"net4me":      "true",
+					"net4me.role": "registry",
+				},
+				ExposedPorts: nat.PortSet{registryInternalPort: struct{}{}},
+			},
+			&container.HostConfig{
+				RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyUnlessStopped},
+				PortBindings: nat.PortMap{
+					registryInternalPort: []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: strconv.Itoa(port)}},
+				},
+				Binds: []string{name + ":/var/lib/registry"},
+			},
+			&network.NetworkingConfig{},
+			&v1.Platform{},
+			name,
+		)
+		if err != nil {
+			return "", fmt.Errorf("could not create registry container %s: %w", name, err)
+		}
+		id = resp.ID
+	}
+	if err := m.clientDocker.ContainerStart(context.Background(), id, container.StartOptions{}); err != nil {
+		return "", fmt.Errorf("could not start registry container %s: %w", name, err)
+	}
+	inspect, err := m.clientDocker.ContainerInspect(context.Background(), id)
+	if err != nil {
+		return "", fmt.Errorf("could not inspect registry container %s: %w", name, err)
+	}
+	if inspect.NetworkSettings == nil || inspect.NetworkSettings.Networks["bridge"] == nil {
+		return "", fmt.Errorf("registry container %s is not attached to the default network", name)
+	}
+	return inspect.NetworkSettings.Networks["bridge"].IPAddress + ":5000", nil
+}
+
+// registryDaemonConfig returns the dockerd configuration for the site, pointing it at
+// the shared registries. Sites not attached to the default network can not
+// reach the registries, so are given no configuration.
+func (m *Manager) registryDaemonConfig(networkMode container.NetworkMode) map[string]any {
+	config := make(map[string]any)
+	if m.registry == nil || networkMode.IsNone() {
+		return config
+	}
+	insecure := []string{m.registry.address}
+	if m.registry.mirrorAddress != "" {
+		insecure = append(insecure, m.registry.mirrorAddress)
+		config["registry-mirrors"] = []string{"http://" + m.registry.mirrorAddress}
+	}
+	config["insecure-registries"] = insecure
+	return config
+}
+
+// writeDaemonConfig writes the daemon.json of the site, to be read by dockerd
+// when it is next started. An empty config removes any daemon.json left from
+// previous settings.
+func (n *Node) writeDaemonConfig(config map[string]any) error {
+	if len(config) == 0 {
+		if _, err := n.run(nil, "rm", "-f", "/etc/docker/daemon.json"); err != nil {
+			return fmt.Errorf("could not remove daemon.json in site: %w", err)
+		}
+		return nil
+	}
+	b, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err := n.run(
+		[]string{"DAEMON_JSON=" + string(b)},
+		"sh", "-c", `mkdir -p /etc/docker && printf '%s' "$DAEMON_JSON" > /etc/docker/daemon.json`,
+	); err != nil {
+		return fmt.Errorf("could not write daemon.json in site: %w", err)
+	}
+	return nil
+}
+
+// progressError reads a docker json message stream (e.g. from a pull or push)
+// to the end, returning the first error reported in the stream.
+func progressError(r io.Reader) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var message struct {
+			Error       string `json:"error"`
+			ErrorDetail struct {
+				Message string `json:"message"`
+			} `json:"errorDetail"`
+		}
+		if err := decoder.Decode(&message); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if message.ErrorDetail.Message != "" {
+			return fmt.Errorf("%s", message.ErrorDetail.Message)
+		}
+		if message.Error != "" {
+			return fmt.Errorf("%s", message.Error)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package dind
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/neaas/nescript"
+	ds "github.com/neaas/nescript/docker"
+)
+
+// resolvConfBackup is where the original resolv.conf of the site container is
+// kept while it is replaced by SetResolvers.
+const resolvConfBackup = "/etc/resolv.conf.net4me"
+
+// SetResolvers replaces the resolv.conf of the site container. Containers run
+// within the site inherit this configuration from the inner docker engine. The
+// original resolv.conf is kept, unless already replaced, for RestoreResolvers.
+func (n *Node) SetResolvers(nameservers []string, search []string) error {
+	n.manager.lock.RLock()
+	defer n.manager.lock.RUnlock()
+	var conf strings.Builder
+	for _, ns := range nameservers {
+		conf.WriteString(fmt.Sprintf("nameserver %s\n", ns))
+	}
+	if len(search) > 0 {
+		conf.WriteString(fmt.Sprintf("search %s\n", strings.Join(search, " ")))
+	}
+	// resolv.conf is bind mounted by docker, so it is written to rather than
+	// replaced
+	cmd := nescript.NewCmd("sh", "-c", `[ -e "$BACKUP" ] || cp /etc/resolv.conf "$BACKUP"; printf '%s' "$RESOLV_CONF" > /etc/resolv.conf`).
+		WithEnv("RESOLV_CONF="+conf.String(), "BACKUP="+resolvConfBackup)
+	process, err := cmd.Exec(ds.Executor(n.manager.clientDocker, n.id, ""))
+	if err != nil {
+		return fmt.Errorf("could not write resolv.conf in site: %w", err)
+	}
+	result, err := process.Result()
+	if err != nil {
+		return fmt.Errorf("could not write resolv.conf in site: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("could not write resolv.conf in site: %s", strings.TrimSpace(result.StdErr))
+	}
+	return nil
+}
+
+// RestoreResolvers restores the resolv.conf of the site container kept by
+// SetResolvers.
+func (n *Node) RestoreResolvers() error {
+	n.manager.lock.RLock()
+	defer n.manager.lock.RUnlock()
+	if _, err := n.run([]string{"BACKUP=" + resolvConfBackup}, "sh", "-c", `if [ -e "$BACKUP" ]; then cat "$BACKUP" > /etc/resolv.conf && rm "$BACKUP"; fi`); err != nil {
+		return fmt.Errorf("could not restore resolv.conf in site: %w", err)
+	}
+	return nil
+}
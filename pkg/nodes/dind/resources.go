@@ -0,0 +1,142 @@
+package dind
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/blkiodev"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+)
+
+// defaultCPUPeriod is the CFS period (µs) used when a CPU limit is given
+// without a period, matching the docker cli.
+const defaultCPUPeriod = 100000
+
+// Resources are the resource limits of a site container. Memory values accept
+// human readable sizes (e.g. `512m`, `2g`). Zero values leave the limit unset
+// when creating a site, and unchanged when updating a running site.
+type Resources struct {
+	// CPUs is the number of CPUs the site may use, as a shorthand for setting
+	// the CPU quota relative to the CPU period. It can not be set with CPUQuota.
+	CPUs       float64 `mapstructure:"cpus,omitempty" json:"cpus,omitempty"`
+	CPUPeriod  int64   `mapstructure:"cpuPeriod,omitempty" json:"cpu_period,omitempty"`
+	CPUQuota   int64   `mapstructure:"cpuQuota,omitempty" json:"cpu_quota,omitempty"`
+	CPUShares  int64   `mapstructure:"cpuShares,omitempty" json:"cpu_shares,omitempty"`
+	CPUSetCPUs string  `mapstructure:"cpuset,omitempty" json:"cpuset,omitempty"`
+	CPUSetMems string  `mapstructure:"cpusetMems,omitempty" json:"cpuset_mems,omitempty"`
+
+	Memory            string `mapstructure:"memory,omitempty" json:"memory,omitempty"`
+	MemorySwap        string `mapstructure:"memorySwap,omitempty" json:"memory_swap,omitempty"`
+	MemoryReservation string `mapstructure:"memoryReservation,omitempty" json:"memory_reservation,omitempty"`
+
+	PidsLimit int64 `mapstructure:"pidsLimit,omitempty" json:"pids_limit,omitempty"`
+
+	// BlkioWeight is the relative block IO weight (10 to 1000) of the site,
+	// with BlkioDeviceWeights overriding it for specific devices (e.g.
+	// `/dev/sda`).
+	BlkioWeight        uint16            `mapstructure:"blkioWeight,omitempty" json:"blkio_weight,omitempty"`
+	BlkioDeviceWeights map[string]uint16 `mapstructure:"blkioDeviceWeights,omitempty" json:"blkio_device_weights,omitempty"`
+}
+
+// containerResources converts the limits to docker container resources.
+func (r Resources) containerResources() (container.Resources, error) {
+	res := container.Resources{
+		CPUPeriod:  r.CPUPeriod,
+		CPUQuota:   r.CPUQuota,
+		CPUShares:  r.CPUShares,
+		CpusetCpus: r.CPUSetCPUs,
+		CpusetMems: r.CPUSetMems,
+	}
+	if r.CPUs > 0 {
+		if r.CPUQuota > 0 {
+			return res, fmt.Errorf("cpus and cpu quota can not both be set")
+		}
+		if res.CPUPeriod == 0 {
+			res.CPUPeriod = defaultCPUPeriod
+		}
+		res.CPUQuota = int64(r.CPUs * float64(res.CPUPeriod))
+	} else if res.CPUQuota > 0 && res.CPUPeriod == 0 {
+		res.CPUPeriod = defaultCPUPeriod
+	}
+	for _, limit := range []struct {
+		value  string
+		target *int64
+	}{
+		{r.Memory, &res.Memory},
+		{r.MemorySwap, &res.MemorySwap},
+		{r.MemoryReservation, &res.MemoryReservation},
+	} {
+		value, target := limit.value, limit.target
+		if value == "" {
+			continue
+		}
+		if value == "-1" {
+			*target = -1
+			continue
+		}
+		bytes, err := units.RAMInBytes(value)
+		if err != nil {
+			return res, fmt.Errorf("invalid memory size %s: %w", value, err)
+		}
+		*target = bytes
+	}
+	if r.PidsLimit != 0 {
+		res.PidsLimit = &r.PidsLimit
+	}
+	if r.BlkioWeight != 0 && (r.BlkioWeight < 10 || r.BlkioWeight > 1000) {
+		return res, fmt.Errorf("blkio weight must be between 10 and 1000")
+	}
+	res.BlkioWeight = r.BlkioWeight
+	for device, weight := range r.BlkioDeviceWeights {
+		res.BlkioWeightDevice = append(res.BlkioWeightDevice, &blkiodev.WeightDevice{Path: device, Weight: weight})
+	}
+	return res, nil
+}
+
+// SetResources updates the resource limits of the running site. Only the
+// limits that are set are changed.
+func (n *Node) SetResources(r Resources) error {
+	res, err := r.containerResources()
+	if err != nil {
+		return err
+	}
+	n.manager.lock.RLock()
+	defer n.manager.lock.RUnlock()
+	if _, err := n.manager.clientDocker.ContainerUpdate(context.Background(), n.id, container.UpdateConfig{
+		Resources: res,
+	}); err != nil {
+		return fmt.Errorf("could not update site resources: %w", err)
+	}
+	return nil
+}
+
+// effectiveResources reports the limits currently applied to the container.
+func effectiveResources(res container.Resources) map[string]any {
+	effective := map[string]any{
+		"cpu_period":         res.CPUPeriod,
+		"cpu_quota":          res.CPUQuota,
+		"cpu_shares":         res.CPUShares,
+		"cpuset":             res.CpusetCpus,
+		"cpuset_mems":        res.CpusetMems,
+		"memory":             res.Memory,
+		"memory_swap":        res.MemorySwap,
+		"memory_reservation": res.MemoryReservation,
+		"blkio_weight":       res.BlkioWeight,
+	}
+	if res.CPUQuota > 0 && res.CPUPeriod > 0 {
+		effective["cpus"] = float64(res.CPUQuota) / float64(res.CPUPeriod)
+	}
+	if res.NanoCPUs > 0 {
+		effective["cpus"] = float64(res.NanoCPUs) / 1e9
+	}
+	if res.PidsLimit != nil {
+		effective["pids_limit"] = *res.PidsLimit
+	}
+	weights := make(map[string]uint16)
+	for _, wd := range res.BlkioWeightDevice {
+		weights[wd.Path] = wd.Weight
+	}
+	effective["blkio_device_weights"] = weights
+	return effective
+}
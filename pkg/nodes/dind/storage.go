@@ -0,0 +1,232 @@
+package dind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/api/types/volume"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// dockerRoot is the data root of the inner docker engine in a site.
+	dockerRoot = "/var/lib/docker"
+	// snapshotRepository is the image repository site snapshots are committed
+	// to, with the snapshot name as the tag.
+	snapshotRepository = "net4me/snapshot"
+
+	snapshotLabel       = "net4me.snapshot"
+	snapshotSiteLabel   = "net4me.snapshot.site"
+	snapshotVolumeLabel = "net4me.snapshot.volume"
+)
+
+var snapshotNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+
+// siteVolumeName is the default name of the volume holding the inner docker
+// data root of a persistent site.
+func siteVolumeName(site string) string {
+	return fmt.Sprintf("net4me-%s-docker", site)
+}
+
+// clearSnapshotLabels blanks the net4me labels of a snapshot image that the
+// site restored from it does not set itself. Docker merges the labels of the
+// image into the container and they can not be removed, so without this the
+// site would inherit the gpus, command and volume of the snapshotted site.
+func clearSnapshotLabels(labels map[string]string, imageLabels map[string]string) {
+	for k := range imageLabels {
+		if _, ok := labels[k]; !ok && strings.HasPrefix(k, "net4me.") {
+			labels[k] = ""
+		}
+	}
+}
+
+// ensureVolume creates the named volume if it does not already exist.
+func (m *Manager) ensureVolume(name string, labels map[string]string) error {
+	if _, err := m.clientDocker.VolumeInspect(context.Background(), name); err == nil {
+		return nil
+	}
+	if _, err := m.clientDocker.VolumeCreate(context.Background(), volume.CreateOptions{
+		Name:   name,
+		Labels: labels,
+	}); err != nil {
+		return fmt.Errorf("could not create volume %s: %w", name, err)
+	}
+	return nil
+}
+
+// copyVolume copies the contents of the source mount into the target volume,
+// using a short lived container of the site image. This preserves ownership,
+// permissions and the special files used by inner storage drivers.
+func (m *Manager) copyVolume(source mount.Mount, volumesFrom string, target string) error {
+	source.Target = "/from"
+	mounts := []mount.Mount{{Type: mount.TypeVolume, Source: target, Target: "/to"}}
+	from := "/from"
+	hostConfig := &container.HostConfig{}
+	if volumesFrom != "" {
+		hostConfig.VolumesFrom = []string{volumesFrom}
+		from = dockerRoot
+	} else {
+		mounts = append(mounts, source)
+	}
+	hostConfig.Mounts = mounts
+	resp, err := m.clientDocker.ContainerCreate(
+		context.Background(),
+		&container.Config{
+			Image:      m.dind.imageName,
+			Labels:     map[string]string{"net4me": "true", "net4me.role": "storage"},
+			Entrypoint: strslice.StrSlice{"sh", "-c"},
+			Cmd:        strslice.StrSlice{fmt.Sprintf("cp -a %s/. /to/", from)},
+		},
+		hostConfig,
+		&network.NetworkingConfig{},
+		&v1.Platform{},
+		"",
+	)
+	if err != nil {
+		return fmt.Errorf("could not create volume copy container: %w", err)
+	}
+	defer m.clientDocker.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+	waitC, errC := m.clientDocker.ContainerWait(context.Background(), resp.ID, container.WaitConditionNextExit)
+	if err := m.clientDocker.ContainerStart(context.Background(), resp.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("could not start volume copy container: %w", err)
+	}
+	select {
+	case err := <-errC:
+		return fmt.Errorf("volume copy failed: %w", err)
+	case result := <-waitC:
+		if result.StatusCode != 0 {
+			return fmt.Errorf("volume copy exited with code %d", result.StatusCode)
+		}
+	}
+	return nil
+}
+
+// Snapshot is a saved state of a site, made up of a committed image of the
+// site container and a volume holding a copy of its inner docker data root.
+type Snapshot struct {
+	Name    string    `json:"name"`
+	Site    string    `json:"site"`
+	Image   string    `json:"image"`
+	Volume  string    `json:"volume"`
+	Created time.Time `json:"created"`
+}
+
+// Snapshot saves the state of the site under the given name. The inner engine
+// is stopped while the snapshot is taken so its data is consistent, and is
+// restarted afterwards if it was running. An error restarting the engine is
+// returned along with the snapshot.
+func (m *Manager) Snapshot(n *Node, name string) (snapshot *Snapshot, err error) {
+	if !snapshotNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid snapshot name %q", name)
+	}
+	if _, err := m.FindSnapshot(name); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists", name)
+	}
+	site, err := n.Name()
+	if err != nil {
+		return nil, err
+	}
+	running := n.Running()
+	if running {
+		if err := n.Stop(); err != nil {
+			return nil, fmt.Errorf("could not stop site engine for snapshot: %w", err)
+		}
+		for i := 0; n.Running(); i++ {
+			if i > 100 {
+				return nil, fmt.Errorf("site engine did not stop in time for snapshot")
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		defer func() {
+			if startErr := n.Start(); startErr != nil {
+				err = errors.Join(err, fmt.Errorf("could not restart site engine after snapshot: %w", startErr))
+			}
+		}()
+	}
+
+	snapshotVolume := fmt.Sprintf("net4me-snapshot-%s", name)
+	if err := m.ensureVolume(snapshotVolume, map[string]string{
+		"net4me":      "true",
+		snapshotLabel: name,
+	}); err != nil {
+		return nil, err
+	}
+	if err := m.copyVolume(mount.Mount{}, n.id, snapshotVolume); err != nil {
+		m.clientDocker.VolumeRemove(context.Background(), snapshotVolume, true)
+		return nil, err
+	}
+	ref := fmt.Sprintf("%s:%s", snapshotRepository, name)
+	if _, err := m.clientDocker.ContainerCommit(context.Background(), n.id, container.CommitOptions{
+		Reference: ref,
+		Comment:   fmt.Sprintf("net4me snapshot of site %s", site),
+		Pause:     true,
+		Changes: []string{
+			fmt.Sprintf("LABEL %s=%s %s=%s %s=%s", snapshotLabel, name, snapshotSiteLabel, site, snapshotVolumeLabel, snapshotVolume),
+		},
+	}); err != nil {
+		m.clientDocker.VolumeRemove(context.Background(), snapshotVolume, true)
+		return nil, fmt.Errorf("could not commit site container: %w", err)
+	}
+	return m.FindSnapshot(name)
+}
+
+// Snapshots lists all site snapshots.
+func (m *Manager) Snapshots() ([]*Snapshot, error) {
+	images, err := m.clientDocker.ImageList(context.Background(), image.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", snapshotLabel)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	snapshots := make([]*Snapshot, 0, len(images))
+	for _, i := range images {
+		snapshots = append(snapshots, &Snapshot{
+			Name:    i.Labels[snapshotLabel],
+			Site:    i.Labels[snapshotSiteLabel],
+			Image:   fmt.Sprintf("%s:%s", snapshotRepository, i.Labels[snapshotLabel]),
+			Volume:  i.Labels[snapshotVolumeLabel],
+			Created: time.Unix(i.Created, 0),
+		})
+	}
+	return snapshots, nil
+}
+
+// FindSnapshot returns the snapshot with the given name.
+func (m *Manager) FindSnapshot(name string) (*Snapshot, error) {
+	snapshots, err := m.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range snapshots {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("snapshot not found: %s", name)
+}
+
+// RemoveSnapshot deletes the image and volume of a snapshot. Sites restored
+// from the snapshot are unaffected, as they hold their own copy of the data.
+func (m *Manager) RemoveSnapshot(name string) error {
+	s, err := m.FindSnapshot(name)
+	if err != nil {
+		return err
+	}
+	if _, err := m.clientDocker.ImageRemove(context.Background(), s.Image, image.RemoveOptions{}); err != nil {
+		return fmt.Errorf("could not remove snapshot image: %w", err)
+	}
+	if err := m.clientDocker.VolumeRemove(context.Background(), s.Volume, false); err != nil {
+		return fmt.Errorf("could not remove snapshot volume: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package dind
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestClearSnapshotLabels(t *testing.T) {
+	tests := []struct {
+		name       string
+		image      map[string]string
+		labels     map[string]string
+		sites      []map[string]string
+		wantGPUs   []string
+		wantLabels map[string]string
+	}{
+		{
+			name: "gpu site",
+			image: map[string]string{
+				"net4me.device.name":  "source",
+				gpuLabel:              "GPU-a",
+				gpuSharedLabel:        "false",
+				commandLabel:          `["dockerd"]`,
+				"net4me.volume":       "net4me-source-docker",
+				snapshotLabel:         "snap",
+				"org.example.unowned": "kept",
+			},
+			labels: map[string]string{
+				"net4me.device.name": "restored",
+				"net4me.volume":      "net4me-restored-docker",
+			},
+			sites: []map[string]string{site("source", "GPU-a", false)},
+			wantLabels: map[string]string{
+				"net4me.device.name":  "restored",
+				gpuLabel:              "",
+				gpuSharedLabel:        "",
+				commandLabel:          "",
+				"net4me.volume":       "net4me-restored-docker",
+				snapshotLabel:         "",
+				"org.example.unowned": "kept",
+			},
+		},
+		{
+			name: "all gpus site restored with gpus",
+			image: map[string]string{
+				"net4me.device.name": "source",
+				gpuLabel:             gpuAll,
+				gpuSharedLabel:       "true",
+			},
+			labels: map[string]string{
+				"net4me.device.name": "restored",
+				gpuLabel:             "GPU-b",
+				gpuSharedLabel:       "true",
+			},
+			wantGPUs: []string{"GPU-b"},
+			wantLabels: map[string]string{
+				"net4me.device.name": "restored",
+				gpuLabel:             "GPU-b",
+				gpuSharedLabel:       "true",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearSnapshotLabels(tt.labels, tt.image)
+			// docker merges the image labels into the container
+			merged := maps.Clone(tt.image)
+			maps.Copy(merged, tt.labels)
+			if !maps.Equal(merged, tt.wantLabels) {
+				t.Errorf("labels = %v, want %v", merged, tt.wantLabels)
+			}
+
+			before := newGPUAllocation(tt.sites)
+			after := newGPUAllocation(append(tt.sites, merged))
+			if len(after.all) != len(before.all) {
+				t.Errorf("sites with all gpus = %v, want %v", after.all, before.all)
+			}
+			if len(after.exclusive) != len(before.exclusive) {
+				t.Errorf("exclusive allocations = %v, want %v", after.exclusive, before.exclusive)
+			}
+			extra := 0
+			for id, sites := range after.shared {
+				extra += len(sites) - len(before.shared[id])
+			}
+			if extra != len(tt.wantGPUs) {
+				t.Errorf("shared allocations = %v, want %d more than %v", after.shared, len(tt.wantGPUs), before.shared)
+			}
+			for _, id := range tt.wantGPUs {
+				if after.shared[id][len(after.shared[id])-1] != "restored" {
+					t.Errorf("gpu %s allocations = %v, want restored site", id, after.shared[id])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package dind
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/transfer"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// workloadDir is the directory in the site container that compose projects
+// are copied to, with each project in a sub-directory.
+const workloadDir = "/net4me/workloads"
+
+var projectNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func (n *Node) DeployWorkload(project, dir, file string, env map[string]string) error {
+	if !projectNamePattern.MatchString(project) {
+		return fmt.Errorf("invalid project name %q", project)
+	}
+	// a site that has only just been started may not be serving requests yet
+	name, err := n.Name()
+	if err != nil {
+		return err
+	}
+	if err := n.waitReady(name); err != nil {
+		return err
+	}
+	projectDir := path.Join(workloadDir, project)
+	if _, err := n.run([]string{"DIR=" + projectDir}, "sh", "-c", `rm -rf "$DIR" && mkdir -p "$DIR"`); err != nil {
+		return fmt.Errorf("could not prepare project directory in site: %w", err)
+	}
+	n.manager.lock.RLock()
+	archive := transfer.Tar(dir, "")
+	err = n.manager.clientDocker.CopyToContainer(context.Background(), n.id, projectDir, archive, types.CopyToContainerOptions{})
+	archive.Close()
+	n.manager.lock.RUnlock()
+	if err != nil {
+		return fmt.Errorf("could not copy project to site: %w", err)
+	}
+	vars := make([]string, 0, len(env))
+	for k, v := range env {
+		vars = append(vars, fmt.Sprintf("%s=%s", k, v))
+	}
+	slices.Sort(vars)
+	if _, err := n.run(
+		vars,
+		"docker", "compose",
+		"--project-name", project,
+		"--project-directory", projectDir,
+		"--file", path.Join(projectDir, file),
+		"up", "--detach", "--remove-orphans",
+	); err != nil {
+		return fmt.Errorf("could not bring up project %s: %w", project, err)
+	}
+	return nil
+}
+
+// projectContainers lists all containers (including stopped) belonging to the
+// compose project on the inner engine.
+func (n *Node) projectContainers(project string) ([]types.Container, error) {
+	engine, err := n.Engine()
+	if err != nil {
+		return nil, err
+	}
+	defer engine.Close()
+	containers, err := engine.ContainerList(context.Background(), container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", "com.docker.compose.project="+project)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list project containers: %w", err)
+	}
+	return containers, nil
+}
+
+func (n *Node) WorkloadStatus(project string) ([]node.WorkloadService, error) {
+	containers, err := n.projectContainers(project)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]node.WorkloadService, 0, len(containers))
+	for _, c := range containers {
+		name := ""
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		services = append(services, node.WorkloadService{
+			Service:   c.Labels["com.docker.compose.service"],
+			Container: name,
+			Image:     c.Image,
+			State:     c.State,
+			Status:    c.Status,
+		})
+	}
+	return services, nil
+}
+
+func (n *Node) WorkloadLogs(ctx context.Context, project string, follow bool, tail string, w io.Writer) error {
+	containers, err := n.projectContainers(project)
+	if err != nil {
+		return err
+	}
+	if len(containers) == 0 {
+		return fmt.Errorf("project %s has no containers", project)
+	}
+	engine, err := n.Engine()
+	if err != nil {
+		return err
+	}
+	defer engine.Close()
+	lock := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	errs := make(chan error, len(containers))
+	for _, c := range containers {
+		wg.Add(1)
+		go func(c types.Container) {
+			defer wg.Done()
+			inspect, err := engine.ContainerInspect(ctx, c.ID)
+			if err != nil {
+				errs <- err
+				return
+			}
+			logs, err := engine.ContainerLogs(ctx, c.ID, container.LogsOptions{
+				ShowStdout: true,
+				ShowStderr: true,
+				Follow:     follow,
+				Tail:       tail,
+			})
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer logs.Close()
+			out := &prefixWriter{prefix: c.Labels["com.docker.compose.service"] + " | ", lock: lock, w: w}
+			if inspect.Config.Tty {
+				_, err = io.Copy(out, logs)
+			} else {
+				_, err = stdcopy.StdCopy(out, out, logs)
+			}
+			out.flush()
+			if err != nil && ctx.Err() == nil {
+				errs <- err
+			}
+		}(c)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+func (n *Node) RemoveWorkload(project string) error {
+	projectDir := path.Join(workloadDir, project)
+	if _, err := n.run(nil, "docker", "compose", "--project-name", project, "down", "--volumes", "--remove-orphans"); err != nil {
+		return fmt.Errorf("could not tear down project %s: %w", project, err)
+	}
+	if _, err := n.run(nil, "rm", "-rf", projectDir); err != nil {
+		return fmt.Errorf("could not remove project directory from site: %w", err)
+	}
+	return nil
+}
+
+// prefixWriter writes complete lines to the underlying writer, each prefixed,
+// so that output from multiple sources can be interleaved line by line.
+type prefixWriter struct {
+	prefix  string
+	lock    *sync.Mutex
+	w       io.Writer
+	partial []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.partial = append(p.partial, b...)
+	consumed := 0
+	for {
+		i := bytes.IndexByte(p.partial[consumed:], '\n')
+		if i < 0 {
+			break
+		}
+		if err := p.line(p.partial[consumed : consumed+i+1]); err != nil {
+			return 0, err
+		}
+		consumed += i + 1
+	}
+	p.partial = p.partial[consumed:]
+	return len(b), nil
+}
+
+func (p *prefixWriter) line(b []byte) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	_, err := fmt.Fprintf(p.w, "%s%s", p.prefix, b)
+	return err
+}
+
+// flush writes any remaining partial line.
+func (p *prefixWriter) flush() {
+	if len(p.partial) > 0 {
+		p.line(append(p.partial, '\n'))
+		p.partial = nil
+	}
+}
@@ -0,0 +1,104 @@
+package netns
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/neaas/neslink"
+	"github.com/vishvananda/netlink"
+)
+
+// Orphans returns the labels of namespaces that no longer exist, and when
+// searching all topologies, the host side of NAT uplinks whose namespace no
+// longer exists. Only uplinks created by net4me are considered, see natPort.
+func (m *Manager) Orphans(options node.GCOptions) ([]node.Orphan, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	orphans := make([]node.Orphan, 0)
+	entries, err := os.ReadDir(path.Join(m.nsDir, labelsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not list network namespace labels: %w", err)
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || m.exists(name) {
+			continue
+		}
+		if !options.All && node.TopologyOf(m.newNode(name)) != options.Topology {
+			continue
+		}
+		orphans = append(orphans, node.Orphan{
+			Device: m.Device(),
+			Kind:   "labels",
+			ID:     name,
+			Name:   name,
+			Reason: "labels of a network namespace that does not exist",
+		})
+	}
+	if !options.All {
+		return orphans, nil
+	}
+	ports, err := port.Ports(m.hostNetNs)
+	if err != nil {
+		return nil, fmt.Errorf("could not list host ports: %w", err)
+	}
+	for _, p := range ports {
+		name, ok := strings.CutPrefix(p.Attrs().Name, natHostPortName(""))
+		if !ok || m.exists(name) || !m.natPort(p) {
+			continue
+		}
+		orphans = append(orphans, node.Orphan{
+			Device: m.Device(),
+			Kind:   "nat",
+			ID:     name,
+			Name:   p.Attrs().Name,
+			Reason: "nat uplink of a network namespace that does not exist",
+		})
+	}
+	return orphans, nil
+}
+
+// natPort returns true if the port is the host side of a NAT uplink created by
+// net4me: a veth given the NAT alias, or, as created before uplinks were
+// given the alias, one whose NAT rules are present.
+func (m *Manager) natPort(p port.Port) bool {
+	if _, ok := p.(*netlink.Veth); !ok {
+		return false
+	}
+	if p.Attrs().Alias == natPortAlias {
+		return true
+	}
+	cidrs, err := port.PortAddresses(m.hostNetNs, p, netlink.FAMILY_V4)
+	if err != nil {
+		return false
+	}
+	present := false
+	neslink.Do(m.hostNetNs, neslink.NAGeneric("check-host-nat", func() error {
+		present = natRulesPresent(p.Attrs().Name, cidrs)
+		return nil
+	}))
+	return present
+}
+
+// RemoveOrphan removes the labels or nat uplink returned by Orphans.
+func (m *Manager) RemoveOrphan(o node.Orphan) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	switch o.Kind {
+	case "labels":
+		return os.Remove(m.labelsPath(o.ID))
+	case "nat":
+		return m.natRemove(m.newNode(o.ID))
+	}
+	return fmt.Errorf("unknown orphan kind: %s", o.Kind)
+}
+
+// exists returns true if the namespace with the given name is mounted.
+func (m *Manager) exists(name string) bool {
+	info, err := os.Stat(path.Join(m.nsDir, name))
+	return err == nil && !info.IsDir()
+}
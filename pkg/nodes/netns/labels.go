@@ -0,0 +1,44 @@
+package netns
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+)
+
+// labelsDir is the directory, within the namespace mount directory, that holds
+// the labels of each namespace as a json file. Being a directory, it is not
+// mistaken for a namespace.
+const labelsDir = ".labels"
+
+func (m *Manager) labelsPath(name string) string {
+	return path.Join(m.nsDir, labelsDir, name+".json")
+}
+
+func (m *Manager) writeLabels(name string, labels map[string]string) error {
+	if err := os.MkdirAll(path.Join(m.nsDir, labelsDir), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.labelsPath(name), data, 0o644)
+}
+
+// Labels returns the labels the namespace was added with. Namespaces that were
+// added without labels have none.
+func (n *Node) Labels() (map[string]string, error) {
+	labels := make(map[string]string)
+	data, err := os.ReadFile(n.manager.labelsPath(n.name))
+	if os.IsNotExist(err) {
+		return labels, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read labels of network namespace %s: %w", n.name, err)
+	}
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("could not decode labels of network namespace %s: %w", n.name, err)
+	}
+	return labels, nil
+}
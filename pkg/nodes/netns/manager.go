@@ -0,0 +1,163 @@
+package netns
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/mitchellh/mapstructure"
+	"github.com/neaas/neslink"
+)
+
+type Manager struct {
+	lock      *sync.RWMutex
+	natLock   *sync.Mutex
+	nsDir     string
+	hostNetNs neslink.NsProvider
+}
+
+type ManagerConfig struct {
+	Dir string `mapstructure:"dir"`
+}
+
+type AddConfig struct {
+	NAT bool `mapstructure:"nat"`
+	// Uplink is the prefix of the NAT uplink. If not set, a free /30 is
+	// allocated from natUplinkRange.
+	Uplink string `mapstructure:"uplink"`
+}
+
+func (m *Manager) Device() string {
+	return "netns"
+}
+
+func (m *Manager) Setup(config map[string]any) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	defaultConfig := map[string]any{
+		"dir": path.Join(os.TempDir(), "net4me-netns"),
+	}
+	maps.Copy(defaultConfig, config)
+	c := ManagerConfig{}
+	if err := mapstructure.Decode(defaultConfig, &c); err != nil {
+		return fmt.Errorf("failed to decode netns manager config: %w", err)
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create netns mount directory: %w", err)
+	}
+	m.nsDir = c.Dir
+	m.hostNetNs = neslink.NPProcess(os.Getpid())
+	return nil
+}
+
+func (m *Manager) Info() (map[string]any, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return map[string]any{
+		"dir": m.nsDir,
+	}, nil
+}
+
+func (m *Manager) Icon() string {
+	return "gf-service"
+}
+
+func (m *Manager) Color() string {
+	return "MediumSeaGreen"
+}
+
+func (m *Manager) Nodes(nodeFilters ...node.NodeFilter) ([]node.Node, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	entries, err := os.ReadDir(m.nsDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not list network namespaces: %w", err)
+	}
+	nodes := make([]node.Node, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		nodes = append(nodes, m.newNode(entry.Name()))
+	}
+	for _, filter := range nodeFilters {
+		nodes = filter(nodes)
+	}
+	return nodes, nil
+}
+
+func (m *Manager) Add(name string, labels map[string]string, config map[string]any) (node.Node, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	c := AddConfig{}
+	if err := mapstructure.Decode(config, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode netns add config: %w", err)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if len(name) > 12 {
+		return nil, fmt.Errorf("name must be at most 12 characters")
+	}
+	if err := neslink.Do(
+		m.hostNetNs,
+		neslink.NANewNsAt(m.nsDir, name),
+		neslink.LASetUp(neslink.LPName("lo")),
+	); err != nil {
+		return nil, fmt.Errorf("could not create network namespace %s: %w", name, err)
+	}
+	n := m.newNode(name)
+	nodeLabels := map[string]string{
+		"net4me":             "true",
+		"net4me.device":      "netns",
+		"net4me.device.name": name,
+	}
+	maps.Copy(nodeLabels, labels)
+	if err := m.writeLabels(name, nodeLabels); err != nil {
+		m.remove(n)
+		return nil, fmt.Errorf("could not label network namespace %s: %w", name, err)
+	}
+	if c.NAT {
+		if err := m.natAdd(n, c.Uplink); err != nil {
+			m.remove(n)
+			return nil, fmt.Errorf("could not setup nat for network namespace %s: %w", name, err)
+		}
+	}
+	return n, nil
+}
+
+func (m *Manager) Remove(n node.Node) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.remove(n.(*Node))
+}
+
+// remove removes the namespace along with its NAT uplink and labels. Each is
+// removed even if removing another fails, so that nothing is left behind.
+func (m *Manager) remove(n *Node) error {
+	errs := make([]error, 0)
+	if err := m.natRemove(n); err != nil {
+		errs = append(errs, fmt.Errorf("could not remove nat for network namespace %s: %w", n.name, err))
+	}
+	if err := neslink.Do(
+		m.hostNetNs,
+		neslink.NADeleteNamedAt(m.nsDir, n.name),
+	); err != nil {
+		errs = append(errs, fmt.Errorf("could not remove network namespace %s: %w", n.name, err))
+	}
+	if err := os.Remove(m.labelsPath(n.name)); err != nil && !os.IsNotExist(err) {
+		errs = append(errs, fmt.Errorf("could not remove labels of network namespace %s: %w", n.name, err))
+	}
+	return errors.Join(errs...)
+}
+
+func init() {
+	node.RegisterManager(&Manager{
+		lock:    &sync.RWMutex{},
+		natLock: &sync.Mutex{},
+	})
+}
@@ -0,0 +1,295 @@
+package netns
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os/exec"
+	"strings"
+
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/neaas/neslink"
+	"github.com/vishvananda/netlink"
+)
+
+// natUplinkName is the name of the port within a NAT enabled namespace that
+// connects it to the host network namespace.
+const natUplinkName = "uplink"
+
+// natUplinkRange is the range from which NAT uplinks are allocated, within the
+// shared address space of carrier-grade NAT so as not to clash with networks
+// of the host.
+const natUplinkRange = "100.64.0.0/16"
+
+// natPortAlias is the alias of the host side of NAT uplinks, marking them as
+// created by net4me.
+const natPortAlias = "net4me-nat"
+
+// natHostPortName returns the name of the port in the host network namespace
+// that connects to the NAT enabled namespace with the given name.
+func natHostPortName(name string) string {
+	return "gw-" + name
+}
+
+// iptables runs the iptables command with the given arguments in the network
+// namespace of the calling thread.
+func iptables(args ...string) error {
+	out, err := exec.Command("iptables", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("iptables %s: %w (%s)", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// natRules returns the iptables rules added to the host network namespace for
+// the NAT enabled namespace. The same rules are used for adding (-A/-I) and
+// deleting (-D) so that they are always removed symmetrically.
+func natRules(hostPort, uplink string) [][]string {
+	return [][]string{
+		{"-t", "nat", "POSTROUTING", "-s", uplink, "!", "-o", hostPort, "-j", "MASQUERADE"},
+		{"FORWARD", "-i", hostPort, "-j", "ACCEPT"},
+		{"FORWARD", "-o", hostPort, "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"},
+	}
+}
+
+// withOp inserts the iptables operation before the chain name of the rule.
+func withOp(op string, rule []string) []string {
+	if rule[0] == "-t" {
+		return append([]string{rule[0], rule[1], op}, rule[2:]...)
+	}
+	return append([]string{op}, rule...)
+}
+
+// natAdd gives the namespace controlled egress to the host network. A port pair
+// is created between the host network namespace and the node namespace using
+// the given uplink prefix, or if empty, a free /30 of natUplinkRange. An uplink
+// that overlaps an address of the host network namespace is rejected. The
+// first address of the prefix is used in the host network namespace, and the
+// second in the node namespace. Traffic leaving the node via the uplink is
+// masqueraded twice: once by the node, so that the emulated networks behind it
+// are hidden from the host, and once by the host, so that traffic can egress
+// the host network. Only return traffic may enter the node from the host. If
+// any step fails, the host side of the uplink is removed again.
+func (m *Manager) natAdd(n *Node, uplink string) (err error) {
+	// the lock is held until the uplink is addressed, so that concurrently
+	// added namespaces are not given the same uplink
+	m.natLock.Lock()
+	defer m.natLock.Unlock()
+	used, err := m.hostPrefixes()
+	if err != nil {
+		return fmt.Errorf("could not list addresses of host: %w", err)
+	}
+	var prefix netip.Prefix
+	if uplink == "" {
+		if prefix, err = allocateUplink(netip.MustParsePrefix(natUplinkRange), used); err != nil {
+			return err
+		}
+	} else {
+		prefix, err = netip.ParsePrefix(uplink)
+		if err != nil || !prefix.Addr().Is4() || prefix.Bits() > 30 {
+			return fmt.Errorf("uplink must be an ipv4 prefix of at least 4 addresses: %s", uplink)
+		}
+		prefix = prefix.Masked()
+		for _, u := range used {
+			if prefix.Overlaps(u) {
+				return fmt.Errorf("uplink %s overlaps %s, which is in use on the host", prefix, u)
+			}
+		}
+	}
+	hostAddr := prefix.Addr().Next()
+	nodeAddr := hostAddr.Next()
+	hostPort := natHostPortName(n.name)
+	tempPort := "gt-" + n.name
+	defer func() {
+		if err != nil {
+			if removeErr := m.natRemove(n); removeErr != nil {
+				err = fmt.Errorf("%w (rollback failed: %w)", err, removeErr)
+			}
+		}
+	}()
+	if err := neslink.Do(
+		m.hostNetNs,
+		neslink.LANewVeth(hostPort, tempPort),
+		neslink.LASetAlias(neslink.LPName(hostPort), natPortAlias),
+		neslink.NASetLinkNs(neslink.LPName(tempPort), n.NetNs()),
+		neslink.LAAddAddr(neslink.LPName(hostPort), fmt.Sprintf("%s/%d", hostAddr, prefix.Bits())),
+		neslink.LASetUp(neslink.LPName(hostPort)),
+		neslink.NAGeneric("add-host-nat", func() error {
+			for _, rule := range natRules(hostPort, prefix.String()) {
+				if err := iptables(withOp("-I", rule)...); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	); err != nil {
+		return fmt.Errorf("could not setup host side of nat uplink: %w", err)
+	}
+	if err := port.SetForwarding(m.hostNetNs, netlink.FAMILY_V4, true); err != nil {
+		return fmt.Errorf("could not enable forwarding on host for nat: %w", err)
+	}
+	if err := neslink.Do(
+		n.NetNs(),
+		neslink.LASetName(neslink.LPName(tempPort), natUplinkName),
+		neslink.LAAddAddr(neslink.LPName(natUplinkName), fmt.Sprintf("%s/%d", nodeAddr, prefix.Bits())),
+		neslink.LASetUp(neslink.LPName(natUplinkName)),
+		neslink.NAGeneric("add-node-nat", func() error {
+			return iptables("-t", "nat", "-A", "POSTROUTING", "-o", natUplinkName, "-j", "MASQUERADE")
+		}),
+	); err != nil {
+		return fmt.Errorf("could not setup node side of nat uplink: %w", err)
+	}
+	uplinkPort, err := port.FromName(n.NetNs(), natUplinkName)
+	if err != nil {
+		return fmt.Errorf("could not find nat uplink: %w", err)
+	}
+	if err := port.PortAddDefaultRoute(n.NetNs(), uplinkPort, hostAddr.String()); err != nil {
+		return fmt.Errorf("could not add default route via nat uplink: %w", err)
+	}
+	if err := port.SetForwarding(n.NetNs(), netlink.FAMILY_V4, true); err != nil {
+		return fmt.Errorf("could not enable forwarding for nat: %w", err)
+	}
+	return nil
+}
+
+// hostPrefixes returns the prefixes of the ipv4 addresses in the host network
+// namespace.
+func (m *Manager) hostPrefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0)
+	if err := neslink.Do(
+		m.hostNetNs,
+		neslink.NAGeneric("list-host-addresses", func() error {
+			addrs, err := netlink.AddrList(nil, netlink.FAMILY_V4)
+			if err != nil {
+				return err
+			}
+			for _, addr := range addrs {
+				if prefix, err := netip.ParsePrefix(addr.IPNet.String()); err == nil {
+					prefixes = append(prefixes, prefix.Masked())
+				}
+			}
+			return nil
+		}),
+	); err != nil {
+		return nil, err
+	}
+	return prefixes, nil
+}
+
+// allocateUplink returns the first /30 of the given range that does not
+// overlap any of the used prefixes.
+func allocateUplink(within netip.Prefix, used []netip.Prefix) (netip.Prefix, error) {
+	within = within.Masked()
+	for addr := within.Addr(); within.Contains(addr); {
+		candidate := netip.PrefixFrom(addr, 30)
+		free := true
+		for _, u := range used {
+			if candidate.Overlaps(u) {
+				free = false
+				break
+			}
+		}
+		if free {
+			return candidate, nil
+		}
+		for i := 0; i < 4; i++ {
+			addr = addr.Next()
+		}
+	}
+	return netip.Prefix{}, fmt.Errorf("no free nat uplink in %s", within)
+}
+
+// natRemove removes the host side of the NAT uplink for the namespace, if it
+// exists. The node side of the uplink is removed along with the namespace.
+// Rules that are not present (e.g. flushed by a firewall reload, or never
+// added as natAdd failed) are skipped, and the port is removed regardless of
+// any error removing the rules.
+func (m *Manager) natRemove(n *Node) error {
+	hostPort := natHostPortName(n.name)
+	hostPortLink, err := port.FromName(m.hostNetNs, hostPort)
+	if err != nil {
+		return nil
+	}
+	errs := make([]error, 0)
+	cidrs, err := port.PortAddresses(m.hostNetNs, hostPortLink, netlink.FAMILY_V4)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if err := neslink.Do(
+		m.hostNetNs,
+		neslink.NAGeneric("del-host-nat", func() error {
+			for _, cidr := range cidrs {
+				prefix, err := netip.ParsePrefix(cidr)
+				if err != nil {
+					continue
+				}
+				for _, rule := range natRules(hostPort, prefix.Masked().String()) {
+					if iptables(withOp("-C", rule)...) != nil {
+						continue
+					}
+					if err := iptables(withOp("-D", rule)...); err != nil {
+						errs = append(errs, err)
+					}
+				}
+			}
+			return nil
+		}),
+	); err != nil {
+		errs = append(errs, err)
+	}
+	if err := neslink.Do(
+		m.hostNetNs,
+		neslink.LADelete(neslink.LPName(hostPort)),
+	); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// natRulesPresent returns true if the iptables rules of a NAT uplink are
+// present for the host side port with the given addresses. It must be called
+// in the host network namespace.
+func natRulesPresent(hostPort string, cidrs []string) bool {
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			continue
+		}
+		present := true
+		for _, rule := range natRules(hostPort, prefix.Masked().String()) {
+			if err := iptables(withOp("-C", rule)...); err != nil {
+				present = false
+				break
+			}
+		}
+		if present {
+			return true
+		}
+	}
+	return false
+}
+
+// natEnabled returns true if the node has a NAT uplink to the host network.
+func (n *Node) natEnabled() bool {
+	_, err := port.FromName(n.NetNs(), natUplinkName)
+	return err == nil
+}
+
+// natUplink returns the uplink prefix of a NAT enabled node, found from the
+// address of the host side of the uplink.
+func (n *Node) natUplink() (string, error) {
+	hostPortLink, err := port.FromName(n.manager.hostNetNs, natHostPortName(n.name))
+	if err != nil {
+		return "", fmt.Errorf("could not find host side of nat uplink: %w", err)
+	}
+	cidrs, err := port.PortAddresses(n.manager.hostNetNs, hostPortLink, netlink.FAMILY_V4)
+	if err != nil {
+		return "", err
+	}
+	for _, cidr := range cidrs {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			return prefix.Masked().String(), nil
+		}
+	}
+	return "", fmt.Errorf("host side of nat uplink has no address")
+}
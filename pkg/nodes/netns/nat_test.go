@@ -0,0 +1,63 @@
+package netns
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+)
+
+func TestAllocateUplink(t *testing.T) {
+	prefixes := func(s ...string) []netip.Prefix {
+		p := make([]netip.Prefix, len(s))
+		for i := range s {
+			p[i] = netip.MustParsePrefix(s[i])
+		}
+		return p
+	}
+	tests := []struct {
+		name    string
+		within  string
+		used    []netip.Prefix
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", within: "100.64.0.0/16", want: "100.64.0.0/30"},
+		{name: "first used", within: "100.64.0.0/16", used: prefixes("100.64.0.0/30"), want: "100.64.0.4/30"},
+		{name: "gap", within: "100.64.0.0/16", used: prefixes("100.64.0.0/30", "100.64.0.8/30"), want: "100.64.0.4/30"},
+		{name: "wider host network", within: "100.64.0.0/16", used: prefixes("100.64.0.0/24"), want: "100.64.1.0/30"},
+		{name: "unrelated", within: "100.64.0.0/16", used: prefixes("10.0.0.0/8"), want: "100.64.0.0/30"},
+		{name: "full", within: "100.64.0.0/29", used: prefixes("100.64.0.0/30", "100.64.0.4/30"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := allocateUplink(netip.MustParsePrefix(tt.within), tt.used)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNatPort(t *testing.T) {
+	m := &Manager{}
+	marked := &netlink.Veth{}
+	marked.Name, marked.Alias = "gw-site1", natPortAlias
+	bridge := &netlink.Bridge{}
+	bridge.Name, bridge.Alias = "gw-site1", natPortAlias
+	if !m.natPort(marked) {
+		t.Error("veth with the nat alias is not a nat port")
+	}
+	if m.natPort(bridge) {
+		t.Error("bridge is a nat port")
+	}
+}
@@ -0,0 +1,143 @@
+package netns
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/neaas/neslink"
+	"github.com/vishvananda/netlink"
+)
+
+type Node struct {
+	manager *Manager
+	name    string
+}
+
+func (m *Manager) newNode(name string) *Node {
+	return &Node{
+		manager: m,
+		name:    name,
+	}
+}
+
+func (n *Node) ID() string {
+	return n.name
+}
+
+func (n *Node) Manager() node.Manager {
+	return n.manager
+}
+
+func (n *Node) Name() (string, error) {
+	return n.name, nil
+}
+
+func (n *Node) Device() string {
+	return n.manager.Device()
+}
+
+func (n *Node) Start() error {
+	if err := neslink.Do(
+		n.NetNs(),
+		neslink.LASetUp(neslink.LPName("lo")),
+	); err != nil {
+		return fmt.Errorf("could not set loopback up in network namespace %s: %w", n.name, err)
+	}
+	return nil
+}
+
+func (n *Node) Stop() error {
+	if err := neslink.Do(
+		n.NetNs(),
+		neslink.LASetDown(neslink.LPName("lo")),
+	); err != nil {
+		return fmt.Errorf("could not set loopback down in network namespace %s: %w", n.name, err)
+	}
+	return nil
+}
+
+func (n *Node) Running() bool {
+	var link netlink.Link
+	if err := neslink.Do(
+		n.NetNs(),
+		neslink.NAGetLink(neslink.LPName("lo"), &link),
+	); err != nil {
+		return false
+	}
+	return link.Attrs().Flags&net.FlagUp != 0
+}
+
+func (n *Node) Info() (map[string]any, error) {
+	nsPath := path.Join(n.manager.nsDir, n.name)
+	if _, err := os.Stat(nsPath); err != nil {
+		return nil, fmt.Errorf("could not find network namespace %s: %w", n.name, err)
+	}
+	return map[string]any{
+		"name": n.name,
+		"path": nsPath,
+		"nat":  n.natEnabled(),
+	}, nil
+}
+
+func (n *Node) Config() (map[string]any, error) {
+	config := map[string]any{
+		"nat": n.natEnabled(),
+	}
+	if n.natEnabled() {
+		uplink, err := n.natUplink()
+		if err != nil {
+			return nil, err
+		}
+		config["uplink"] = uplink
+	}
+	return config, nil
+}
+
+func (n *Node) NetNs() neslink.NsProvider {
+	return neslink.NPNameAt(n.manager.nsDir, n.name)
+}
+
+func (n *Node) Ports() ([]port.Port, error) {
+	ports, err := port.Ports(n.NetNs(), port.FilterHasTypeIn("veth"), port.FilterHasNameNotIn(natUplinkName))
+	if err != nil {
+		return nil, err
+	}
+	return ports, nil
+}
+
+func (n *Node) PortAdd(p port.Port) error {
+	if err := port.TopologyPool(node.TopologyOf(n)).Take(n.NetNs(), p); err != nil {
+		return fmt.Errorf("could not take port from pool: %w", err)
+	}
+	return nil
+}
+
+func (n *Node) PortRemove(p port.Port) error {
+	if err := port.TopologyPool(node.TopologyOf(n)).Give(n.NetNs(), p); err != nil {
+		return fmt.Errorf("could not give port back to pool: %w", err)
+	}
+	return nil
+}
+
+func (n *Node) Stats() (map[string]any, error) {
+	ports, err := n.Ports()
+	if err != nil {
+		return nil, fmt.Errorf("could not get ports for stats: %w", err)
+	}
+	var rxBytes, txBytes uint64
+	for _, p := range ports {
+		rxBytes += p.Attrs().Statistics.RxBytes
+		txBytes += p.Attrs().Statistics.TxBytes
+	}
+	return map[string]any{
+		"mainstat":      float64(txBytes),
+		"secondarystat": float64(rxBytes),
+
+		"rx_bytes": rxBytes,
+		"tx_bytes": txBytes,
+	}, nil
+}
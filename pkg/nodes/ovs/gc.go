@@ -0,0 +1,99 @@
+package ovs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/port"
+)
+
+// portLabel is the external id set on the bridge ports added by net4me.
+const portLabel = "net4me"
+
+// Orphans returns the ports of bridges whose network device no longer exists,
+// as happens when a link is destroyed without first being removed from the
+// bridge. Only bridges labelled by net4me, and the ports it added to them, are
+// considered.
+func (m *Manager) Orphans(options node.GCOptions) ([]node.Orphan, error) {
+	filters := make([]node.NodeFilter, 0)
+	if !options.All {
+		filters = append(filters, node.FilterByTopology(options.Topology))
+	}
+	nodes, err := m.Nodes(filters...)
+	if err != nil {
+		return nil, err
+	}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	devices, err := port.Ports(m.workingNetNs)
+	if err != nil {
+		return nil, fmt.Errorf("could not list network devices: %w", err)
+	}
+	deviceNames := make(map[string]bool)
+	for _, d := range devices {
+		deviceNames[d.Attrs().Name] = true
+	}
+	orphans := make([]node.Orphan, 0)
+	for _, n := range nodes {
+		bridgeName, err := n.Name()
+		if err != nil {
+			continue
+		}
+		if labels, err := n.(*Node).Labels(); err != nil || labels["net4me"] != "true" {
+			continue
+		}
+		portNames, err := m.clientOvS.VSwitch.ListPorts(bridgeName)
+		if err != nil {
+			return nil, fmt.Errorf("could not list ports of bridge %s: %w", bridgeName, err)
+		}
+		orphans = append(orphans, orphanPorts(m.Device(), bridgeName, portNames, deviceNames, m.markedPort)...)
+	}
+	return orphans, nil
+}
+
+// orphanPorts returns the ports of the bridge that have no network device,
+// keeping only those added by net4me: ports it marked, or those named as the
+// ports of a port pool, as added before ports were marked.
+func orphanPorts(device, bridge string, ports []string, devices map[string]bool, marked func(string) bool) []node.Orphan {
+	orphans := make([]node.Orphan, 0)
+	for _, p := range ports {
+		if devices[p] || !(port.IsPoolName(p) || marked(p)) {
+			continue
+		}
+		orphans = append(orphans, node.Orphan{
+			Device: device,
+			Kind:   "port",
+			ID:     bridge + "/" + p,
+			Name:   p,
+			Reason: fmt.Sprintf("port of bridge %s has no network device", bridge),
+		})
+	}
+	return orphans
+}
+
+// markPort sets the external id that marks the bridge port as added by net4me.
+func (m *Manager) markPort(name string) error {
+	_, err := m.vsctl("set", "port", name, fmt.Sprintf("external_ids:%s=true", portLabel))
+	return err
+}
+
+// markedPort returns true if the bridge port is marked as added by net4me.
+func (m *Manager) markedPort(name string) bool {
+	out, err := m.vsctl("--if-exists", "get", "port", name, "external_ids:"+portLabel)
+	return err == nil && strings.Trim(strings.TrimSpace(string(out)), `"`) == "true"
+}
+
+// RemoveOrphan removes a bridge port returned by Orphans.
+func (m *Manager) RemoveOrphan(o node.Orphan) error {
+	bridge, p, ok := strings.Cut(o.ID, "/")
+	if o.Kind != "port" || !ok {
+		return fmt.Errorf("unknown orphan: %s %s", o.Kind, o.ID)
+	}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if err := m.clientOvS.VSwitch.DeletePort(bridge, p); err != nil {
+		return fmt.Errorf("could not remove port %s from bridge %s: %w", p, bridge, err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package ovs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestOrphanPorts(t *testing.T) {
+	devices := map[string]bool{"vpAbCdE": true, "eth1": true}
+	marked := func(name string) bool { return name == "site1-eth0" }
+	tests := []struct {
+		name  string
+		ports []string
+		want  []string
+	}{
+		{name: "pool port with device", ports: []string{"vpAbCdE"}, want: []string{}},
+		{name: "pool port without device", ports: []string{"vpXyZwV"}, want: []string{"br0/vpXyZwV"}},
+		{name: "marked port without device", ports: []string{"site1-eth0"}, want: []string{"br0/site1-eth0"}},
+		{name: "foreign port without device", ports: []string{"eth0", "vpn0", "vpABCDEF"}, want: []string{}},
+		{name: "foreign port with device", ports: []string{"eth1"}, want: []string{}},
+		{
+			name:  "mixed",
+			ports: []string{"eth1", "vpAbCdE", "vpXyZwV", "uplink", "site1-eth0"},
+			want:  []string{"br0/vpXyZwV", "br0/site1-eth0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, o := range orphanPorts("ovs", "br0", tt.ports, devices, marked) {
+				if o.Device != "ovs" || o.Kind != "port" {
+					t.Errorf("unexpected orphan %+v", o)
+				}
+				got = append(got, o.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package ovs
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/neaas/neslink"
+)
+
+// vsctl runs ovs-vsctl with the given arguments, as the ovs client does not
+// expose the external ids of bridges.
+func (m *Manager) vsctl(args ...string) ([]byte, error) {
+	cmd := "ovs-vsctl"
+	if m.sudo {
+		args = append([]string{cmd}, args...)
+		cmd = "sudo"
+	}
+	out, err := exec.Command(cmd, args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+// setLabels stores the labels as external ids of the bridge.
+func (m *Manager) setLabels(bridge string, labels map[string]string) error {
+	return neslink.Do(
+		m.workingNetNs,
+		neslink.LAGeneric("set-ovs-labels", func() error {
+			for k, v := range labels {
+				if _, err := m.vsctl("br-set-external-id", bridge, k, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	)
+}
+
+// Labels returns the labels of the bridge, which are held as its external ids.
+// Bridges that were not created by net4me may have no labels.
+func (n *Node) Labels() (map[string]string, error) {
+	bridgeName, err := n.Name()
+	if err != nil {
+		return nil, fmt.Errorf("could not get bridge name required for labels: %w", err)
+	}
+	labels := make(map[string]string)
+	if err := neslink.Do(
+		n.manager.workingNetNs,
+		neslink.LAGeneric("get-ovs-labels", func() error {
+			out, err := n.manager.vsctl("br-get-external-id", bridgeName)
+			if err != nil {
+				return err
+			}
+			for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+				if k, v, ok := strings.Cut(line, "="); ok {
+					labels[k] = v
+				}
+			}
+			return nil
+		}),
+	); err != nil {
+		return nil, fmt.Errorf("could not get bridge labels: %w", err)
+	}
+	return labels, nil
+}
@@ -16,6 +16,7 @@ import (
 type Manager struct {
 	lock         *sync.RWMutex
 	clientOvS    *ovs.Client
+	sudo         bool
 	workingNetNs neslink.NsProvider
 }
 
@@ -42,6 +43,7 @@ func (m *Manager) Setup(config map[string]interface{}) error {
 	if err := mapstructure.Decode(defaultConfig, &c); err != nil {
 		return fmt.Errorf("failed to decode ovs manager config: %w", err)
 	}
+	m.sudo = c.Sudo
 	if c.Sudo {
 		m.clientOvS = ovs.New(ovs.Sudo())
 	} else {
@@ -81,6 +83,9 @@ func (m *Manager) Nodes(nodeFilters ...node.NodeFilter) ([]node.Node, error) {
 		}
 		nodes = append(nodes, m.newNode(link.Attrs().Index))
 	}
+	for _, filter := range nodeFilters {
+		nodes = filter(nodes)
+	}
 	return nodes, nil
 }
 
@@ -91,10 +96,15 @@ func (m *Manager) Add(name string, labels map[string]string, config map[string]a
 	if err := mapstructure.Decode(config, &c); err != nil {
 		return nil, fmt.Errorf("failed to decode ovs add config: %w", err)
 	}
-	// TODO: add labels somehow? maybe a file of other db solution?
 	if name == "" {
 		return nil, fmt.Errorf("name is required")
 	}
+	nodeLabels := map[string]string{
+		"net4me":             "true",
+		"net4me.device":      "ovs",
+		"net4me.device.name": name,
+	}
+	maps.Copy(nodeLabels, labels)
 	if err := neslink.Do(
 		m.workingNetNs,
 		neslink.LAGeneric("add-ovs-bridge", func() error {
@@ -109,6 +119,9 @@ func (m *Manager) Add(name string, labels map[string]string, config map[string]a
 	); err != nil {
 		return nil, fmt.Errorf("could not set the network bridge down %s: %w", name, err)
 	}
+	if err := m.setLabels(name, nodeLabels); err != nil {
+		return nil, fmt.Errorf("could not label network bridge %s: %w", name, err)
+	}
 	var link netlink.Link
 	if err := neslink.Do(
 		m.workingNetNs,
@@ -108,13 +108,14 @@ func (n *Node) Ports() ([]port.Port, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not list ports: %w", err)
 	}
-	ports := make([]port.Port, 0)
-	for _, portName := range portNames {
-		port, err := port.FromName(n.manager.workingNetNs, portName)
-		if err != nil {
-			return nil, fmt.Errorf("could not get port: %w", err)
-		}
-		ports = append(ports, port)
+	if len(portNames) == 0 {
+		return make([]port.Port, 0), nil
+	}
+	// a single dump of the namespace is filtered, rather than looking up
+	// each port of the bridge in turn
+	ports, err := port.Ports(n.manager.workingNetNs, port.FilterHasNameIn(portNames...))
+	if err != nil {
+		return nil, fmt.Errorf("could not get ports: %w", err)
 	}
 	return ports, nil
 }
@@ -124,7 +125,7 @@ func (n *Node) PortAdd(p port.Port) error {
 	if err != nil {
 		return fmt.Errorf("could not get bridge name required for port add: %w", err)
 	}
-	if err := port.TakePort(n.NetNs(), p); err != nil {
+	if err := port.TopologyPool(node.TopologyOf(n)).Take(n.NetNs(), p); err != nil {
 		return fmt.Errorf("could not take port from pool: %w", err)
 	}
 	if err := neslink.Do(
@@ -135,6 +136,9 @@ func (n *Node) PortAdd(p port.Port) error {
 	); err != nil {
 		return fmt.Errorf("could not add port to bridge: %w", err)
 	}
+	if err := n.manager.markPort(p.Attrs().Name); err != nil {
+		return fmt.Errorf("could not mark port of bridge: %w", err)
+	}
 	return nil
 }
 
@@ -151,7 +155,7 @@ func (n *Node) PortRemove(p port.Port) error {
 	); err != nil {
 		return fmt.Errorf("could not remove port to bridge: %w", err)
 	}
-	if err := port.GivePort(n.NetNs(), p); err != nil {
+	if err := port.TopologyPool(node.TopologyOf(n)).Give(n.NetNs(), p); err != nil {
 		return fmt.Errorf("could not give port back to pool: %w", err)
 	}
 	return nil
@@ -181,3 +185,24 @@ func (n *Node) Stats() (map[string]interface{}, error) {
 		"collisions": link.Attrs().Statistics.Collisions,
 	}, nil
 }
+
+func (n *Node) Config() (map[string]any, error) {
+	bridgeName, err := n.Name()
+	if err != nil {
+		return nil, fmt.Errorf("could not get bridge name required for config: %w", err)
+	}
+	config := make(map[string]any)
+	if err := neslink.Do(
+		n.manager.workingNetNs,
+		neslink.LAGeneric("get-ovs-controller", func() error {
+			controller, err := n.manager.clientOvS.VSwitch.GetController(bridgeName)
+			if err == nil && controller != "" {
+				config["controller_ip"] = controller
+			}
+			return nil
+		}),
+	); err != nil {
+		return nil, fmt.Errorf("could not get bridge controller: %w", err)
+	}
+	return config, nil
+}
@@ -0,0 +1,47 @@
+package port
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/neaas/neslink"
+	"github.com/vishvananda/netlink"
+)
+
+// Emulation describes the netem parameters applied to a port. Latency and
+// jitter are in µs and loss is a percentage.
+type Emulation struct {
+	Latency uint32  `json:"latency"`
+	Jitter  uint32  `json:"jitter"`
+	Loss    float32 `json:"loss"`
+}
+
+// PortEmulation returns the emulation currently applied to the port. If no
+// emulation is applied to the port, nil is returned without an error.
+func PortEmulation(nsp neslink.NsProvider, p Port) (*Emulation, error) {
+	var emulation *Emulation
+	if err := neslink.Do(
+		nsp,
+		neslink.LAGeneric("get-netem", func() error {
+			qdiscs, err := netlink.QdiscList(p)
+			if err != nil {
+				return err
+			}
+			for _, q := range qdiscs {
+				netem, ok := q.(*netlink.Netem)
+				if !ok || netem.Attrs().Parent != netlink.HANDLE_ROOT {
+					continue
+				}
+				emulation = &Emulation{
+					Latency: uint32(float64(netem.Latency) / netlink.TickInUsec()),
+					Jitter:  uint32(float64(netem.Jitter) / netlink.TickInUsec()),
+					Loss:    float32(float64(netem.Loss) / math.MaxUint32 * 100),
+				}
+			}
+			return nil
+		}),
+	); err != nil {
+		return nil, fmt.Errorf("could not get port emulation: %w", err)
+	}
+	return emulation, nil
+}
@@ -0,0 +1,76 @@
+package port
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/neaas/neslink"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// PortAddAddressNoDAD adds an address to the port without performing duplicate
+// address detection. For IPv6 addresses this allows the address to be used
+// immediately rather than waiting for DAD to complete. IPv4 addresses are added
+// as normal.
+func PortAddAddressNoDAD(nsp neslink.NsProvider, port Port, cidr string) error {
+	addr, err := netlink.ParseAddr(cidr)
+	if err != nil {
+		return fmt.Errorf("failed to parse cidr to network address: %w", err)
+	}
+	if addr.IP.To4() == nil {
+		addr.Flags |= unix.IFA_F_NODAD
+	}
+	return neslink.Do(
+		nsp,
+		neslink.LAGeneric("add-address-nodad", func() error {
+			return netlink.AddrAdd(port, addr)
+		}),
+	)
+}
+
+// PortSetDAD enables or disables IPv6 duplicate address detection for any
+// addresses added to the port in the future.
+func PortSetDAD(nsp neslink.NsProvider, port Port, enabled bool) error {
+	value := "0"
+	if enabled {
+		value = "1"
+	}
+	return portSysctl(nsp, port, "accept_dad", value)
+}
+
+// PortSetAcceptRA enables or disables the acceptance of IPv6 router
+// advertisements on the port. When enabled, addresses and default routes may be
+// autoconfigured from advertisements received on the port.
+func PortSetAcceptRA(nsp neslink.NsProvider, port Port, enabled bool) error {
+	value := "0"
+	if enabled {
+		value = "1"
+	}
+	return portSysctl(nsp, port, "accept_ra", value)
+}
+
+// PortSetIPv6 enables or disables IPv6 on the port. This can be used to create
+// IPv4-only ports, as IPv6 is otherwise enabled with a link-local address.
+func PortSetIPv6(nsp neslink.NsProvider, port Port, enabled bool) error {
+	value := "1"
+	if enabled {
+		value = "0"
+	}
+	return portSysctl(nsp, port, "disable_ipv6", value)
+}
+
+// portSysctl sets the IPv6 configuration sysctl for the port. Since the sysctl
+// is keyed by name, the current name of the port is looked up by its index.
+func portSysctl(nsp neslink.NsProvider, port Port, key, value string) error {
+	return neslink.Do(
+		nsp,
+		neslink.LAGeneric("set-port-sysctl", func() error {
+			link, err := netlink.LinkByIndex(port.Attrs().Index)
+			if err != nil {
+				return err
+			}
+			return writeSysctl(path.Join("net/ipv6/conf", link.Attrs().Name, key), value)
+		}),
+	)
+}
@@ -4,32 +4,134 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/neaas/neslink"
 	"github.com/vishvananda/netlink"
 )
 
+// DefaultPool is the name of the network namespace holding the ports of the
+// default topology.
+const DefaultPool = "net4me-ports"
+
+// Pool is a network namespace in which the ports of a topology are held while
+// they are not attached to a node. Each topology has its own pool, so that
+// topologies on the same machine do not see each other's ports.
+type Pool struct {
+	name string
+}
+
+var defaultPool = &Pool{name: DefaultPool}
+
+// TopologyPool returns the port pool of the topology with the given name. The
+// default topology uses the DefaultPool namespace.
+func TopologyPool(topology string) *Pool {
+	if topology == "" || topology == "default" {
+		return defaultPool
+	}
+	return &Pool{name: DefaultPool + "-" + topology}
+}
+
+// Pools returns the pools whose network namespace exists.
+func Pools() ([]*Pool, error) {
+	entries, err := os.ReadDir(os.TempDir())
+	if err != nil {
+		return nil, fmt.Errorf("could not list port mgmt network namespaces: %w", err)
+	}
+	pools := make([]*Pool, 0)
+	for _, entry := range entries {
+		if entry.Name() == DefaultPool || strings.HasPrefix(entry.Name(), DefaultPool+"-") {
+			pools = append(pools, &Pool{name: entry.Name()})
+		}
+	}
+	return pools, nil
+}
+
+// Name returns the name of the network namespace of the pool.
+func (pool *Pool) Name() string {
+	return pool.name
+}
+
+// Topology returns the name of the topology the pool belongs to.
+func (pool *Pool) Topology() string {
+	if topology, ok := strings.CutPrefix(pool.name, DefaultPool+"-"); ok {
+		return topology
+	}
+	return "default"
+}
+
+// Delete removes the network namespace of the pool, destroying any ports in
+// it.
+func (pool *Pool) Delete() error {
+	if err := neslink.Do(
+		neslink.NPProcess(os.Getpid()),
+		neslink.NADeleteNamedAt(os.TempDir(), pool.name),
+	); err != nil {
+		return fmt.Errorf("could not delete port mgmt network namespace: %w", err)
+	}
+	return nil
+}
+
 // NetNs returns the provider to the network namespace used for port management.
 // If the namespace does not exist and/or can not be created, an error will be
 // returned.
 func NetNs() (neslink.NsProvider, error) {
-	netns, _ := neslink.NPNameAt(os.TempDir(), "net4me-ports").Provide()
+	return defaultPool.NetNs()
+}
+
+// PortPool returns the ports of the default pool. See Pool.Ports.
+func PortPool(filters ...PortFilter) ([]Port, error) {
+	return defaultPool.Ports(filters...)
+}
+
+// CreatePortPair creates a new pair of ports in the default pool. See
+// Pool.CreatePortPair.
+func CreatePortPair() (Port, Port, error) {
+	return defaultPool.CreatePortPair()
+}
+
+// DestroyPortPair removes the provided port from the default pool. See
+// Pool.DestroyPortPair.
+func DestroyPortPair(port Port) error {
+	return defaultPool.DestroyPortPair(port)
+}
+
+// GivePort moves a provided port back to the default pool. See Pool.Give.
+func GivePort(nsp neslink.NsProvider, p Port) error {
+	return defaultPool.Give(nsp, p)
+}
+
+// TakePort moves a provided port from the default pool. See Pool.Take.
+func TakePort(nsp neslink.NsProvider, p Port) error {
+	return defaultPool.Take(nsp, p)
+}
+
+// ClearPool removes all ports from the default pool. See Pool.Clear.
+func ClearPool() error {
+	return defaultPool.Clear()
+}
+
+// NetNs returns the provider to the network namespace of the pool. If the
+// namespace does not exist and/or can not be created, an error will be
+// returned.
+func (pool *Pool) NetNs() (neslink.NsProvider, error) {
+	netns, _ := neslink.NPNameAt(os.TempDir(), pool.name).Provide()
 	if _, err := os.Stat(netns.String()); errors.Is(err, os.ErrNotExist) {
 		if err := neslink.Do(
 			neslink.NPProcess(os.Getpid()),
-			neslink.NANewNsAt(os.TempDir(), "net4me-ports"),
+			neslink.NANewNsAt(os.TempDir(), pool.name),
 		); err != nil {
-			return neslink.NPNameAt(os.TempDir(), "net4me-ports"), fmt.Errorf("could not create port mgmt network namespace: %w", err)
+			return neslink.NPNameAt(os.TempDir(), pool.name), fmt.Errorf("could not create port mgmt network namespace: %w", err)
 		}
 	}
-	return neslink.NPNameAt(os.TempDir(), "net4me-ports"), nil
+	return neslink.NPNameAt(os.TempDir(), pool.name), nil
 }
 
-// PortPool returns a list of ports found in the port mgmt network namespace. If
+// Ports returns a list of ports found in the network namespace of the pool. If
 // the ports can not be found, an error will be returned. The list of ports can
 // be filtered by the provided filters.
-func PortPool(filters ...PortFilter) ([]Port, error) {
-	netns, err := NetNs()
+func (pool *Pool) Ports(filters ...PortFilter) ([]Port, error) {
+	netns, err := pool.NetNs()
 	if err != nil {
 		return nil, fmt.Errorf("could not get port mgmt network namespace: %w", err)
 	}
@@ -56,11 +158,11 @@ func PortPool(filters ...PortFilter) ([]Port, error) {
 	return ports, nil
 }
 
-// CreatePortPair creates a new pair of ports in the port mgmt network
-// namespace. Each is given a name in the form vp<random5chars>. If the ports
-// can not be created, an error will be returned.
-func CreatePortPair() (Port, Port, error) {
-	netns, err := NetNs()
+// CreatePortPair creates a new pair of ports in the pool. Each is given a name
+// in the form vp<random5chars>. If the ports can not be created, an error will
+// be returned.
+func (pool *Pool) CreatePortPair() (Port, Port, error) {
+	netns, err := pool.NetNs()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -78,12 +180,11 @@ func CreatePortPair() (Port, Port, error) {
 	return Port(port1), Port(port2), nil
 }
 
-// DestroyPortPair removes the provided port from the port mgmt network
-// namespace. If the port can not be removed, an error will be returned. If the
-// port is removed without issue, any peer ports will also be removed,
-// regardless of their namespace.
-func DestroyPortPair(port Port) error {
-	netns, err := NetNs()
+// DestroyPortPair removes the provided port from the pool. If the port can not
+// be removed, an error will be returned. If the port is removed without issue,
+// any peer ports will also be removed, regardless of their namespace.
+func (pool *Pool) DestroyPortPair(port Port) error {
+	netns, err := pool.NetNs()
 	if err != nil {
 		return err
 	}
@@ -96,11 +197,11 @@ func DestroyPortPair(port Port) error {
 	return nil
 }
 
-// GivePort moves a provided port back to the pool network namespace. If the
-// port can not be moved, an error will be returned. The namespace that the port
-// currently resides in must be provided.
-func GivePort(nsp neslink.NsProvider, p Port) error {
-	netns, err := NetNs()
+// Give moves a provided port back to the pool. If the port can not be moved, an
+// error will be returned. The namespace that the port currently resides in must
+// be provided.
+func (pool *Pool) Give(nsp neslink.NsProvider, p Port) error {
+	netns, err := pool.NetNs()
 	if err != nil {
 		return err
 	}
@@ -113,10 +214,10 @@ func GivePort(nsp neslink.NsProvider, p Port) error {
 	return nil
 }
 
-// TakePort moves a provided port from the pool network namespace to the
-// provided namespace. If the port can not be moved, an error will be returned.
-func TakePort(nsp neslink.NsProvider, p Port) error {
-	netns, err := NetNs()
+// Take moves a provided port from the pool to the provided namespace. If the
+// port can not be moved, an error will be returned.
+func (pool *Pool) Take(nsp neslink.NsProvider, p Port) error {
+	netns, err := pool.NetNs()
 	if err != nil {
 		return err
 	}
@@ -129,19 +230,19 @@ func TakePort(nsp neslink.NsProvider, p Port) error {
 	return nil
 }
 
-// ClearPool removes all ports from the port mgmt network namespace. If the
-// ports can not be removed, an error will be returned. Note, the only ports
-// removed are the ones that are returned by the PortPool function.
-func ClearPool() error {
+// Clear removes all ports from the pool. If the ports can not be removed, an
+// error will be returned. Note, the only ports removed are the ones that are
+// returned by the Ports function.
+func (pool *Pool) Clear() error {
 	for {
-		poolPorts, err := PortPool()
+		poolPorts, err := pool.Ports()
 		if err != nil {
 			return err
 		}
 		if len(poolPorts) == 0 {
 			break
 		}
-		if err := DestroyPortPair(poolPorts[0]); err != nil {
+		if err := pool.DestroyPortPair(poolPorts[0]); err != nil {
 			return err
 		}
 	}
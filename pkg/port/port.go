@@ -142,3 +142,22 @@ func FromName(nsp neslink.NsProvider, name string) (Port, error) {
 	}
 	return link, nil
 }
+
+func FilterHasNameNotIn(name ...string) PortFilter {
+	return func(links []netlink.Link) []netlink.Link {
+		filtered := make([]netlink.Link, 0)
+		for _, link := range links {
+			excluded := false
+			for _, n := range name {
+				if link.Attrs().Name == n {
+					excluded = true
+					break
+				}
+			}
+			if !excluded {
+				filtered = append(filtered, link)
+			}
+		}
+		return filtered
+	}
+}
@@ -0,0 +1,184 @@
+package port
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/neaas/neslink"
+	"github.com/vishvananda/netlink"
+)
+
+// RouteProtocol is the routing protocol identifier given to all routes that
+// are installed by net4me. This allows for net4me routes to be identified and
+// removed without touching routes added by other sources.
+const RouteProtocol netlink.RouteProtocol = 78
+
+// PortAddresses returns the addresses (in cidr notation) assigned to the given
+// port for the given address family (e.g. netlink.FAMILY_V4). If the addresses
+// can not be determined, an error will be returned.
+func PortAddresses(nsp neslink.NsProvider, p Port, family int) ([]string, error) {
+	cidrs := make([]string, 0)
+	if err := neslink.Do(
+		nsp,
+		neslink.LAGeneric("list-addresses", func() error {
+			addrs, err := netlink.AddrList(p, family)
+			if err != nil {
+				return err
+			}
+			for _, addr := range addrs {
+				cidrs = append(cidrs, addr.IPNet.String())
+			}
+			return nil
+		}),
+	); err != nil {
+		return nil, fmt.Errorf("could not list port addresses: %w", err)
+	}
+	return cidrs, nil
+}
+
+// PortAddRoute adds (or replaces) a route to the destination prefix via the
+// given port. If a gateway is provided, the route is via the gateway,
+// otherwise the destination is considered to be on-link. The route is tagged
+// with the net4me routing protocol.
+func PortAddRoute(nsp neslink.NsProvider, p Port, dst, gw string) error {
+	_, dstNet, err := net.ParseCIDR(dst)
+	if err != nil {
+		return fmt.Errorf("invalid route destination %s: %w", dst, err)
+	}
+	route := &netlink.Route{
+		LinkIndex: p.Attrs().Index,
+		Dst:       dstNet,
+		Protocol:  RouteProtocol,
+		Scope:     netlink.SCOPE_LINK,
+	}
+	if gw != "" {
+		route.Gw = net.ParseIP(gw)
+		if route.Gw == nil {
+			return fmt.Errorf("invalid route gateway %s", gw)
+		}
+		route.Scope = netlink.SCOPE_UNIVERSE
+	}
+	return neslink.Do(
+		nsp,
+		neslink.LAGeneric("add-route", func() error {
+			return netlink.RouteReplace(route)
+		}),
+	)
+}
+
+// Routes returns the routes in the namespace that were installed by net4me,
+// for the given address family.
+func Routes(nsp neslink.NsProvider, family int) ([]netlink.Route, error) {
+	var routes []netlink.Route
+	if err := neslink.Do(
+		nsp,
+		neslink.NAGeneric("list-routes", func() error {
+			var err error
+			routes, err = netlink.RouteListFiltered(family, &netlink.Route{
+				Protocol: RouteProtocol,
+			}, netlink.RT_FILTER_PROTOCOL)
+			return err
+		}),
+	); err != nil {
+		return nil, fmt.Errorf("could not list routes: %w", err)
+	}
+	return routes, nil
+}
+
+// ClearRoutes removes all routes from the namespace that were installed by
+// net4me, other than default routes. Default routes are only added explicitly,
+// e.g. for sites and gateways, so are not replaced by computed routes. Routes
+// added by any other source are left untouched.
+func ClearRoutes(nsp neslink.NsProvider, family int) error {
+	return neslink.Do(
+		nsp,
+		neslink.NAGeneric("clear-routes", func() error {
+			routes, err := netlink.RouteListFiltered(family, &netlink.Route{
+				Protocol: RouteProtocol,
+			}, netlink.RT_FILTER_PROTOCOL)
+			if err != nil {
+				return err
+			}
+			for _, r := range routes {
+				if isDefaultRoute(r) {
+					continue
+				}
+				if err := netlink.RouteDel(&r); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	)
+}
+
+func isDefaultRoute(r netlink.Route) bool {
+	if r.Dst == nil {
+		return true
+	}
+	ones, _ := r.Dst.Mask.Size()
+	return ones == 0
+}
+
+// PortAddDefaultRoute adds (or replaces) the default route for the address
+// family of the given gateway, via the given port. Both IPv4 and IPv6
+// gateways are supported. The route is tagged with the net4me routing
+// protocol.
+func PortAddDefaultRoute(nsp neslink.NsProvider, p Port, gw string) error {
+	gateway := net.ParseIP(gw)
+	if gateway == nil {
+		return fmt.Errorf("invalid default route gateway %s", gw)
+	}
+	route := &netlink.Route{
+		LinkIndex: p.Attrs().Index,
+		Gw:        gateway,
+		Protocol:  RouteProtocol,
+	}
+	return neslink.Do(
+		nsp,
+		neslink.LAGeneric("add-default-route", func() error {
+			return netlink.RouteReplace(route)
+		}),
+	)
+}
+
+// SetForwarding enables or disables packet forwarding for the given address
+// family (netlink.FAMILY_V4 or netlink.FAMILY_V6) in the namespace. This is
+// required for nodes that must route traffic on behalf of others.
+func SetForwarding(nsp neslink.NsProvider, family int, enabled bool) error {
+	value := "0"
+	if enabled {
+		value = "1"
+	}
+	key := "net/ipv4/ip_forward"
+	if family == netlink.FAMILY_V6 {
+		key = "net/ipv6/conf/all/forwarding"
+	}
+	return neslink.Do(
+		nsp,
+		neslink.NAGeneric("set-forwarding", func() error {
+			return writeSysctl(key, value)
+		}),
+	)
+}
+
+// Forwarding returns true if packet forwarding is enabled in the namespace for
+// the given address family (netlink.FAMILY_V4 or netlink.FAMILY_V6).
+func Forwarding(nsp neslink.NsProvider, family int) (bool, error) {
+	key := "net/ipv4/ip_forward"
+	if family == netlink.FAMILY_V6 {
+		key = "net/ipv6/conf/all/forwarding"
+	}
+	var value string
+	if err := neslink.Do(
+		nsp,
+		neslink.NAGeneric("get-forwarding", func() error {
+			var err error
+			value, err = readSysctl(key)
+			return err
+		}),
+	); err != nil {
+		return false, fmt.Errorf("could not get forwarding: %w", err)
+	}
+	return value == "1", nil
+}
@@ -0,0 +1,25 @@
+package port
+
+import (
+	"os"
+	"path"
+	"strings"
+)
+
+// writeSysctl writes the value to the given sysctl key (e.g.
+// net/ipv4/ip_forward). The network related sysctls are scoped to the network
+// namespace of the calling thread, so this should be called within a
+// neslink.Do call.
+func writeSysctl(key, value string) error {
+	return os.WriteFile(path.Join("/proc/sys", key), []byte(value), 0644)
+}
+
+// readSysctl reads the value of the given sysctl key. As with writeSysctl,
+// this should be called within a neslink.Do call.
+func readSysctl(key string) (string, error) {
+	b, err := os.ReadFile(path.Join("/proc/sys", key))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
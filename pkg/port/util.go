@@ -1,6 +1,9 @@
 package port
 
-import "math/rand"
+import (
+	"math/rand"
+	"regexp"
+)
 
 var letters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 
@@ -12,3 +15,11 @@ func portNameGenerate() string {
 	b = append([]rune{'v', 'p'}, b...)
 	return string(b)
 }
+
+var poolNamePattern = regexp.MustCompile(`^vp[a-zA-Z]{5}$`)
+
+// IsPoolName returns true if the name is of the form given to the ports that
+// are created in a port pool.
+func IsPoolName(name string) bool {
+	return poolNamePattern.MatchString(name)
+}
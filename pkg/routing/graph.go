@@ -0,0 +1,193 @@
+package routing
+
+import (
+	"net"
+	"sort"
+
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/vishvananda/netlink"
+)
+
+// Weight returns the cost of traversing the given link. Costs must be
+// positive, with lower costs being preferred when computing paths.
+type Weight func(*topology.Link) float64
+
+// WeightHops gives every link the same cost, so the computed paths are those
+// with the lowest hop count.
+func WeightHops(l *topology.Link) float64 {
+	return 1
+}
+
+// WeightLatency gives each link a cost equal to the emulated latency (in µs)
+// configured on both of its ports. A base cost of 1 is added so that links
+// without emulation still count towards the path length.
+func WeightLatency(l *topology.Link) float64 {
+	cost := float64(1)
+	if em, err := port.PortEmulation(l.SelfHost().NetworkNamespace(), l.SelfPort()); err == nil && em != nil {
+		cost += float64(em.Latency)
+	}
+	if em, err := port.PortEmulation(l.PeerHost().NetworkNamespace(), l.PeerPort()); err == nil && em != nil {
+		cost += float64(em.Latency)
+	}
+	return cost
+}
+
+// edge is a single direction of a link between two hosts in the graph. Hosts
+// are identified by their IDs, with the hosts themselves kept for the caller.
+type edge struct {
+	fromID   string
+	from     *topology.Host
+	fromPort port.Port
+	toID     string
+	to       *topology.Host
+	toPort   port.Port
+	cost     float64
+}
+
+// graph is a weighted, directed view of the hosts and links of the topology.
+// Only links where both ports are up are included.
+type graph struct {
+	hosts map[string]*topology.Host
+	edges map[string][]*edge
+}
+
+// newGraph builds the graph of the hosts and links. Each link must only be
+// given once, as returned by topology.Links.
+func newGraph(hosts []*topology.Host, links []*topology.Link, weight Weight) *graph {
+	g := &graph{
+		hosts: make(map[string]*topology.Host),
+		edges: make(map[string][]*edge),
+	}
+	for _, h := range hosts {
+		g.hosts[h.ID()] = h
+	}
+	for _, l := range links {
+		if !portUp(l.SelfPort()) || !portUp(l.PeerPort()) {
+			continue
+		}
+		g.addLink(&edge{
+			fromID: l.SelfHost().ID(), from: l.SelfHost(), fromPort: l.SelfPort(),
+			toID: l.PeerHost().ID(), to: l.PeerHost(), toPort: l.PeerPort(),
+			cost: weight(l),
+		})
+	}
+	g.sortEdges()
+	return g
+}
+
+// addLink adds the edge and its reverse to the graph.
+func (g *graph) addLink(e *edge) {
+	g.edges[e.fromID] = append(g.edges[e.fromID], e)
+	g.edges[e.toID] = append(g.edges[e.toID], &edge{
+		fromID: e.toID, from: e.to, fromPort: e.toPort,
+		toID: e.fromID, to: e.from, toPort: e.fromPort,
+		cost: e.cost,
+	})
+}
+
+// sortEdges orders the edges of each host by the host they lead to, then by
+// port, so that paths of equal cost are always chosen the same way.
+func (g *graph) sortEdges() {
+	for _, edges := range g.edges {
+		sort.SliceStable(edges, func(i, j int) bool {
+			if edges[i].toID != edges[j].toID {
+				return edges[i].toID < edges[j].toID
+			}
+			return portIndex(edges[i].fromPort) < portIndex(edges[j].fromPort)
+		})
+	}
+}
+
+func portIndex(p port.Port) int {
+	if p == nil {
+		return 0
+	}
+	return p.Attrs().Index
+}
+
+// shortestPaths computes the lowest cost path from the source host to every
+// reachable host using Dijkstra's algorithm. The result maps each reachable
+// host ID to the ordered list of edges taken from the source. Ties between
+// paths of equal cost are broken by host ID, so the result is deterministic.
+func (g *graph) shortestPaths(source string) map[string][]*edge {
+	dist := map[string]float64{source: 0}
+	prev := make(map[string]*edge)
+	done := make(map[string]bool)
+	for {
+		current := ""
+		for id, d := range dist {
+			if done[id] {
+				continue
+			}
+			if current == "" || d < dist[current] || (d == dist[current] && id < current) {
+				current = id
+			}
+		}
+		if current == "" {
+			break
+		}
+		done[current] = true
+		for _, e := range g.edges[current] {
+			if d, ok := dist[e.toID]; !ok || dist[current]+e.cost < d {
+				dist[e.toID] = dist[current] + e.cost
+				prev[e.toID] = e
+			}
+		}
+	}
+	paths := make(map[string][]*edge)
+	for id := range dist {
+		if id == source {
+			continue
+		}
+		path := make([]*edge, 0)
+		for at := id; at != source; at = prev[at].fromID {
+			path = append([]*edge{prev[at]}, path...)
+		}
+		paths[id] = path
+	}
+	return paths
+}
+
+// portUp returns true if the port is administratively up and its operational
+// state does not report it to be down.
+func portUp(p port.Port) bool {
+	if p.Attrs().Flags&net.FlagUp == 0 {
+		return false
+	}
+	switch p.Attrs().OperState {
+	case netlink.OperDown, netlink.OperLowerLayerDown, netlink.OperNotPresent:
+		return false
+	}
+	return true
+}
+
+// Hop is a single traversal of a link along a path, leaving one host via a
+// port and arriving at the next host via the peer port.
+type Hop struct {
+	From     *topology.Host
+	FromPort port.Port
+	To       *topology.Host
+	ToPort   port.Port
+}
+
+// Paths returns the lowest cost path (using the given weight) from the source
+// host to every host it can reach in the topology, keyed by the ID of the
+// reached host. Only links that are up are considered.
+func Paths(source *topology.Host, weight Weight) map[string][]Hop {
+	g := newGraph(topology.Hosts(), topology.Links(), weight)
+	paths := make(map[string][]Hop)
+	for id, edges := range g.shortestPaths(source.ID()) {
+		hops := make([]Hop, 0, len(edges))
+		for _, e := range edges {
+			hops = append(hops, Hop{
+				From:     e.from,
+				FromPort: e.fromPort,
+				To:       e.to,
+				ToPort:   e.toPort,
+			})
+		}
+		paths[id] = hops
+	}
+	return paths
+}
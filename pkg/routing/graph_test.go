@@ -0,0 +1,97 @@
+package routing
+
+import (
+	"strings"
+	"testing"
+)
+
+// testGraph builds a graph from links given as "a-b:cost", without hosts or
+// ports, which shortestPaths does not need.
+func testGraph(links ...string) *graph {
+	g := &graph{edges: make(map[string][]*edge)}
+	for _, l := range links {
+		ends, cost := l, 1.0
+		if i := strings.Index(l, ":"); i >= 0 {
+			ends = l[:i]
+			cost = float64(l[i+1] - '0')
+		}
+		ids := strings.Split(ends, "-")
+		g.addLink(&edge{fromID: ids[0], toID: ids[1], cost: cost})
+	}
+	g.sortEdges()
+	return g
+}
+
+// route returns the hosts visited by the path, e.g. "a-b-c".
+func route(source string, path []*edge) string {
+	ids := []string{source}
+	for _, e := range path {
+		ids = append(ids, e.toID)
+	}
+	return strings.Join(ids, "-")
+}
+
+func TestShortestPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		links  []string
+		source string
+		want   map[string]string
+	}{
+		{
+			name:   "line",
+			links:  []string{"a-b", "b-c"},
+			source: "a",
+			want:   map[string]string{"b": "a-b", "c": "a-b-c"},
+		},
+		{
+			name:   "cheaper longer path",
+			links:  []string{"a-c:5", "a-b:1", "b-c:1"},
+			source: "a",
+			want:   map[string]string{"b": "a-b", "c": "a-b-c"},
+		},
+		{
+			name:   "tie broken by host id",
+			links:  []string{"a-c", "c-d", "a-b", "b-d"},
+			source: "a",
+			want:   map[string]string{"b": "a-b", "c": "a-c", "d": "a-b-d"},
+		},
+		{
+			name:   "unreachable host",
+			links:  []string{"a-b", "c-d"},
+			source: "a",
+			want:   map[string]string{"b": "a-b"},
+		},
+		{
+			name:   "from the middle",
+			links:  []string{"a-b", "b-c", "c-d"},
+			source: "c",
+			want:   map[string]string{"a": "c-b-a", "b": "c-b", "d": "c-d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// map iteration order varies, so the result must be the same
+			// every time
+			for i := 0; i < 20; i++ {
+				paths := testGraph(tt.links...).shortestPaths(tt.source)
+				if len(paths) != len(tt.want) {
+					t.Fatalf("got %d paths, want %d", len(paths), len(tt.want))
+				}
+				for target, want := range tt.want {
+					if got := route(tt.source, paths[target]); got != want {
+						t.Fatalf("path to %s: got %s, want %s", target, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestShortestPathsParallelLinks(t *testing.T) {
+	g := testGraph("a-b:3", "a-b:2")
+	path := g.shortestPaths("a")["b"]
+	if len(path) != 1 || path[0].cost != 2 {
+		t.Fatalf("expected the cheaper of the parallel links, got %+v", path)
+	}
+}
@@ -0,0 +1,281 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+// Route is a static route to be installed in the network namespace of a host.
+// If the gateway is empty, the destination is reachable on-link via the port
+// (e.g. across one or more L2 switches).
+type Route struct {
+	Host        *topology.Host
+	Port        port.Port
+	Destination string
+	Gateway     string
+	Cost        float64
+}
+
+// addressBook holds the IPv4 and global IPv6 addresses assigned to the linked
+// ports of each host, keyed by host ID and port index.
+type addressBook map[string]map[int][]*net.IPNet
+
+func newAddressBook(g *graph) addressBook {
+	book := make(addressBook)
+	for id, edges := range g.edges {
+		book[id] = make(map[int][]*net.IPNet)
+		for _, e := range edges {
+			cidrs, err := port.PortAddresses(e.from.NetworkNamespace(), e.fromPort, netlink.FAMILY_ALL)
+			if err != nil {
+				logrus.WithError(err).WithField("host", e.from.Name()).Warnln("could not get port addresses for routing")
+				continue
+			}
+			for _, cidr := range cidrs {
+				ip, ipNet, err := net.ParseCIDR(cidr)
+				if err != nil || ip.IsLinkLocalUnicast() {
+					continue
+				}
+				ipNet.IP = ip
+				book[id][e.fromPort.Attrs().Index] = append(book[id][e.fromPort.Attrs().Index], ipNet)
+			}
+		}
+	}
+	return book
+}
+
+// routed returns true if the host has any address on any linked port. Hosts
+// without addresses (e.g. switches) are treated as L2 devices.
+func (b addressBook) routed(hostID string) bool {
+	for _, addrs := range b[hostID] {
+		if len(addrs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// gateway returns the address of the host port that is of the same address
+// family as the given network. If there is no such address, nil is returned.
+func (b addressBook) gateway(hostID string, portIndex int, network *net.IPNet) net.IP {
+	for _, addr := range b[hostID][portIndex] {
+		if (addr.IP.To4() == nil) == (network.IP.To4() == nil) {
+			return addr.IP
+		}
+	}
+	return nil
+}
+
+// connected returns true if the host has an address within the given network.
+func (b addressBook) connected(hostID string, network *net.IPNet) bool {
+	for _, addrs := range b[hostID] {
+		for _, addr := range addrs {
+			if network.Contains(addr.IP) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Compute calculates the static routes required for every routed host in the
+// topology to reach the networks of every other routed host. Paths are chosen
+// using the given link weight. Hosts that do not have addresses are considered
+// to be L2 devices that are transparent to routing, so a path that only
+// crosses L2 devices results in an on-link route. Otherwise, the first routed
+// host along the path is used as the gateway.
+func Compute(weight Weight) ([]*Route, error) {
+	g := newGraph(topology.Hosts(), topology.Links(), weight)
+	book := newAddressBook(g)
+	routes := make([]*Route, 0)
+	for sourceID, source := range g.hosts {
+		if !book.routed(sourceID) {
+			continue
+		}
+		best := make(map[string]*Route)
+		paths := g.shortestPaths(sourceID)
+		// targets are visited in order, so that of networks reachable at the
+		// same cost via different hosts, the same one is always chosen
+		targets := make([]string, 0, len(paths))
+		for id := range paths {
+			targets = append(targets, id)
+		}
+		sort.Strings(targets)
+		for _, targetID := range targets {
+			path := paths[targetID]
+			if !book.routed(targetID) {
+				continue
+			}
+			cost := float64(0)
+			for _, e := range path {
+				cost += e.cost
+			}
+			var via *edge
+			for _, e := range path {
+				if book.routed(e.toID) {
+					if e.toID != targetID {
+						via = e
+					}
+					break
+				}
+			}
+			for _, addrs := range book[targetID] {
+				for _, addr := range addrs {
+					network := &net.IPNet{IP: addr.IP.Mask(addr.Mask), Mask: addr.Mask}
+					if book.connected(sourceID, network) {
+						continue
+					}
+					if existing, ok := best[network.String()]; ok && existing.Cost <= cost {
+						continue
+					}
+					route := &Route{
+						Host:        source,
+						Port:        path[0].fromPort,
+						Destination: network.String(),
+						Cost:        cost,
+					}
+					if via != nil {
+						gw := book.gateway(via.toID, via.toPort.Attrs().Index, network)
+						if gw == nil {
+							continue
+						}
+						route.Gateway = gw.String()
+					}
+					best[network.String()] = route
+				}
+			}
+		}
+		for _, r := range best {
+			routes = append(routes, r)
+		}
+	}
+	return routes, nil
+}
+
+// Install replaces the net4me routes of every host in the topology with the
+// given routes, keeping any default routes. Hosts that are used as a gateway
+// have forwarding enabled.
+func Install(routes []*Route) error {
+	byHost := make(map[string][]*Route)
+	gateways := make(map[string]bool)
+	for _, r := range routes {
+		byHost[r.Host.ID()] = append(byHost[r.Host.ID()], r)
+		if r.Gateway != "" {
+			gateways[net.ParseIP(r.Gateway).String()] = true
+		}
+	}
+	for _, h := range topology.Hosts() {
+		if err := port.ClearRoutes(h.NetworkNamespace(), netlink.FAMILY_ALL); err != nil {
+			if _, ok := byHost[h.ID()]; ok {
+				return fmt.Errorf("failed to clear routes on host %s: %w", h.Name(), err)
+			}
+			logrus.WithError(err).WithField("host", h.Name()).Warnln("could not clear routes from host")
+		}
+	}
+	for _, hostRoutes := range byHost {
+		h := hostRoutes[0].Host
+		for _, r := range hostRoutes {
+			if err := port.PortAddRoute(h.NetworkNamespace(), r.Port, r.Destination, r.Gateway); err != nil {
+				return fmt.Errorf("failed to add route to %s on host %s: %w", r.Destination, h.Name(), err)
+			}
+		}
+	}
+	for _, h := range topology.Hosts() {
+		ports, err := h.Ports()
+		if err != nil {
+			continue
+		}
+		for _, p := range ports {
+			cidrs, err := port.PortAddresses(h.NetworkNamespace(), p, netlink.FAMILY_ALL)
+			if err != nil {
+				continue
+			}
+			for _, cidr := range cidrs {
+				ip, _, err := net.ParseCIDR(cidr)
+				if err != nil || !gateways[ip.String()] {
+					continue
+				}
+				family := netlink.FAMILY_V4
+				if ip.To4() == nil {
+					family = netlink.FAMILY_V6
+				}
+				if err := port.SetForwarding(h.NetworkNamespace(), family, true); err != nil {
+					return fmt.Errorf("failed to enable forwarding on host %s: %w", h.Name(), err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Apply computes and installs the routes for the current topology.
+func Apply(weight Weight) ([]*Route, error) {
+	routes, err := Compute(weight)
+	if err != nil {
+		return nil, err
+	}
+	return routes, Install(routes)
+}
+
+// linkSignature returns a string that identifies the set of links that are
+// currently up in the topology. A change in signature indicates that routes
+// should be recomputed.
+func linkSignature() string {
+	up := make([]string, 0)
+	for _, l := range topology.Links() {
+		if portUp(l.SelfPort()) && portUp(l.PeerPort()) {
+			up = append(up, fmt.Sprintf("%s/%d-%s/%d", l.SelfHost().ID(), l.SelfPort().Attrs().Index, l.PeerHost().ID(), l.PeerPort().Attrs().Index))
+		}
+	}
+	sort.Strings(up)
+	return strings.Join(up, ",")
+}
+
+// RunRecompute applies routes for the current topology and then checks the
+// state of the links every interval (seconds). Whenever a link fails or
+// recovers, the routes are recomputed and reinstalled. The recompute loop stops
+// when the context is done, or after an error is sent on the returned channel,
+// and the channel is then closed.
+func RunRecompute(ctx context.Context, weight Weight, interval int) (<-chan error, error) {
+	errCh := make(chan error, 1)
+	signature := linkSignature()
+	if _, err := Apply(weight); err != nil {
+		close(errCh)
+		return errCh, fmt.Errorf("failed to apply initial routes: %w", err)
+	}
+	go func() {
+		defer close(errCh)
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current := linkSignature()
+			if current == signature {
+				continue
+			}
+			routes, err := Apply(weight)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			signature = current
+			logrus.
+				WithField("timestamp", time.Now()).
+				WithField("route_count", len(routes)).
+				Debugln("routing: recomputed routes after link change")
+		}
+	}()
+	return errCh, nil
+}
@@ -0,0 +1,24 @@
+package routing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunRecomputeStops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh, err := RunRecompute(ctx, WeightHops, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	select {
+	case err, ok := <-errCh:
+		if ok {
+			t.Fatalf("got error %v, want the channel closed", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("recompute loop did not stop when the context was canceled")
+	}
+}
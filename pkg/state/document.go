@@ -0,0 +1,124 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/port"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the version of the document format written by Save. Documents of
+// any other version can not be restored.
+const Version = 1
+
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// Document is a portable description of a topology, holding everything needed
+// to recreate it on the same or another machine.
+type Document struct {
+	Version  int       `json:"version" yaml:"version"`
+	Topology string    `json:"topology" yaml:"topology"`
+	Saved    time.Time `json:"saved" yaml:"saved"`
+	Hosts    []Host    `json:"hosts" yaml:"hosts"`
+	Links    []Link    `json:"links" yaml:"links"`
+	Routes   []Route   `json:"routes,omitempty" yaml:"routes,omitempty"`
+}
+
+// Host is a host of the topology. Config is the add config of the host's
+// device, and labels exclude those added by net4me itself.
+type Host struct {
+	Name       string            `json:"name" yaml:"name"`
+	Device     string            `json:"device" yaml:"device"`
+	Labels     map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Config     map[string]any    `json:"config,omitempty" yaml:"config,omitempty"`
+	Running    bool              `json:"running" yaml:"running"`
+	Forwarding Forwarding        `json:"forwarding" yaml:"forwarding"`
+}
+
+// Forwarding is the packet forwarding state of a host per address family.
+type Forwarding struct {
+	IPv4 bool `json:"ipv4" yaml:"ipv4"`
+	IPv6 bool `json:"ipv6" yaml:"ipv6"`
+}
+
+// Endpoint is one end of a link. The port name only identifies the port within
+// the document (e.g. for routes), as ports are given new names on restore.
+type Endpoint struct {
+	Host      string          `json:"host" yaml:"host"`
+	Port      string          `json:"port" yaml:"port"`
+	Addresses []string        `json:"addresses,omitempty" yaml:"addresses,omitempty"`
+	Up        bool            `json:"up" yaml:"up"`
+	Emulation *port.Emulation `json:"emulation,omitempty" yaml:"emulation,omitempty"`
+}
+
+// Link is a link between two hosts.
+type Link struct {
+	A Endpoint `json:"a" yaml:"a"`
+	B Endpoint `json:"b" yaml:"b"`
+}
+
+// Route is a route installed by net4me on a host, via one of its link ports.
+// An empty destination is the default route.
+type Route struct {
+	Host        string `json:"host" yaml:"host"`
+	Port        string `json:"port" yaml:"port"`
+	Destination string `json:"destination,omitempty" yaml:"destination,omitempty"`
+	Gateway     string `json:"gateway,omitempty" yaml:"gateway,omitempty"`
+}
+
+// FormatFromPath returns the document format implied by the extension of the
+// path, defaulting to yaml.
+func FormatFromPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// Encode writes the document to w in the given format.
+func Encode(w io.Writer, doc *Document, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("unknown document format: %s", format)
+	}
+}
+
+// Decode reads a document in the given format from r.
+func Decode(r io.Reader, format string) (*Document, error) {
+	var doc Document
+	switch format {
+	case FormatJSON:
+		if err := json.NewDecoder(r).Decode(&doc); err != nil {
+			return nil, fmt.Errorf("could not decode json document: %w", err)
+		}
+	case FormatYAML:
+		if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+			return nil, fmt.Errorf("could not decode yaml document: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown document format: %s", format)
+	}
+	if doc.Version != Version {
+		return nil, fmt.Errorf("unsupported document version %d (expected %d)", doc.Version, Version)
+	}
+	return &doc, nil
+}
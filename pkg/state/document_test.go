@@ -0,0 +1,133 @@
+package state
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/port"
+)
+
+func testDocument() *Document {
+	return &Document{
+		Version:  Version,
+		Topology: "default",
+		Saved:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Hosts: []Host{
+			{
+				Name:       "r1",
+				Device:     "dind",
+				Labels:     map[string]string{"role": "router"},
+				Config:     map[string]any{"persistent": true},
+				Running:    true,
+				Forwarding: Forwarding{IPv4: true},
+			},
+			{Name: "h1", Device: "netns"},
+		},
+		Links: []Link{
+			{
+				A: Endpoint{Host: "r1", Port: "eth0", Addresses: []string{"10.0.0.1/30"}, Up: true, Emulation: &port.Emulation{Latency: 10, Jitter: 2, Loss: 0.5}},
+				B: Endpoint{Host: "h1", Port: "eth0", Addresses: []string{"10.0.0.2/30"}, Up: true},
+			},
+		},
+		Routes: []Route{
+			{Host: "h1", Port: "eth0", Gateway: "10.0.0.1"},
+			{Host: "r1", Port: "eth0", Destination: "192.168.0.0/24", Gateway: "10.0.0.2"},
+		},
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, format := range []string{FormatYAML, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := Encode(&b, testDocument(), format); err != nil {
+				t.Fatal(err)
+			}
+			doc, err := Decode(&b, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(doc, testDocument()) {
+				t.Fatalf("got %+v, want %+v", doc, testDocument())
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		format   string
+	}{
+		{name: "unknown format", document: "version: 1", format: "toml"},
+		{name: "wrong version", document: "version: 2", format: FormatYAML},
+		{name: "missing version", document: `{"hosts": []}`, format: FormatJSON},
+		{name: "invalid json", document: "{", format: FormatJSON},
+		{name: "invalid yaml", document: "hosts: [", format: FormatYAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(strings.NewReader(tt.document), tt.format); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := map[string]string{
+		"topology.json": FormatJSON,
+		"topology.JSON": FormatJSON,
+		"topology.yaml": FormatYAML,
+		"topology.yml":  FormatYAML,
+		"topology":      FormatYAML,
+	}
+	for path, want := range tests {
+		if got := FormatFromPath(path); got != want {
+			t.Errorf("%s: got %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(doc *Document)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(doc *Document) {}},
+		{name: "host without device", modify: func(doc *Document) { doc.Hosts[1].Device = "" }, wantErr: true},
+		{name: "duplicate host", modify: func(doc *Document) { doc.Hosts[1].Name = "r1" }, wantErr: true},
+		{name: "link to unknown host", modify: func(doc *Document) { doc.Links[0].B.Host = "h2" }, wantErr: true},
+		{
+			name: "port used twice",
+			modify: func(doc *Document) {
+				doc.Links = append(doc.Links, Link{A: Endpoint{Host: "r1", Port: "eth0"}, B: Endpoint{Host: "h1", Port: "eth1"}})
+			},
+			wantErr: true,
+		},
+		{name: "route via unknown port", modify: func(doc *Document) { doc.Routes[0].Port = "eth1" }, wantErr: true},
+		{name: "default route without gateway", modify: func(doc *Document) { doc.Routes[0].Gateway = "" }, wantErr: true},
+		{
+			name:   "route without gateway",
+			modify: func(doc *Document) { doc.Routes[1].Gateway = "" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := testDocument()
+			tt.modify(doc)
+			err := Validate(doc)
+			if tt.wantErr && err == nil {
+				t.Fatal("expected an error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package state
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+// Validate checks that the document is consistent and can be restored into
+// the current topology, i.e. that none of its hosts already exist.
+func Validate(doc *Document) error {
+	hosts := make(map[string]bool)
+	for _, h := range doc.Hosts {
+		if h.Name == "" || h.Device == "" {
+			return fmt.Errorf("hosts require a name and device")
+		}
+		if hosts[h.Name] {
+			return fmt.Errorf("duplicate host %s", h.Name)
+		}
+		hosts[h.Name] = true
+		if len(topology.Hosts(topology.FilterByName(h.Name))) > 0 {
+			return fmt.Errorf("host %s already exists in the topology", h.Name)
+		}
+	}
+	ports := make(map[string]bool)
+	for _, l := range doc.Links {
+		for _, e := range []Endpoint{l.A, l.B} {
+			if !hosts[e.Host] {
+				return fmt.Errorf("link endpoint references unknown host %s", e.Host)
+			}
+			if e.Port != "" {
+				if ports[e.Host+"/"+e.Port] {
+					return fmt.Errorf("port %s of host %s is used by more than one link", e.Port, e.Host)
+				}
+				ports[e.Host+"/"+e.Port] = true
+			}
+		}
+	}
+	for _, r := range doc.Routes {
+		if !ports[r.Host+"/"+r.Port] {
+			return fmt.Errorf("route on host %s references unknown port %s", r.Host, r.Port)
+		}
+		if r.Destination == "" && r.Gateway == "" {
+			return fmt.Errorf("default route on host %s requires a gateway", r.Host)
+		}
+	}
+	return nil
+}
+
+// Restore recreates the hosts, links, addresses, emulation and routes of the
+// document, then starts the hosts that were running when it was saved. If
+// anything fails, the hosts created so far are removed again.
+func Restore(doc *Document) (err error) {
+	if err := Validate(doc); err != nil {
+		return err
+	}
+	hosts := make(map[string]*topology.Host)
+	defer func() {
+		if err == nil {
+			return
+		}
+		for name, h := range hosts {
+			if removeErr := h.Remove(); removeErr != nil {
+				logrus.WithError(removeErr).WithField("host", name).Warnln("could not remove host after failed restore")
+			}
+		}
+	}()
+
+	specs := make([]topology.HostSpec, len(doc.Hosts))
+	for i, h := range doc.Hosts {
+		specs[i] = topology.HostSpec{
+			Device: h.Device,
+			Name:   h.Name,
+			Labels: maps.Clone(h.Labels),
+			Config: maps.Clone(h.Config),
+		}
+		if specs[i].Labels == nil {
+			specs[i].Labels = make(map[string]string)
+		}
+		if specs[i].Config == nil {
+			specs[i].Config = make(map[string]any)
+		}
+	}
+	results := topology.NewHosts(specs, topology.BulkOptions{})
+	for _, r := range results {
+		if r.Host != nil {
+			hosts[r.Name] = r.Host
+		}
+	}
+	if err := topology.BulkError(results); err != nil {
+		return fmt.Errorf("failed to create hosts: %w", err)
+	}
+
+	// ports maps the port names of the document to the ports created for them
+	ports := make(map[string]port.Port)
+	for _, l := range doc.Links {
+		link, err := hosts[l.A.Host].LinkWith(hosts[l.B.Host], topology.LinkOptions{
+			Self: linkEndpoint(l.A),
+			Peer: linkEndpoint(l.B),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to link %s to %s: %w", l.A.Host, l.B.Host, err)
+		}
+		ports[l.A.Host+"/"+l.A.Port] = link.SelfPort()
+		ports[l.B.Host+"/"+l.B.Port] = link.PeerPort()
+	}
+
+	for _, h := range doc.Hosts {
+		host := hosts[h.Name]
+		for family, enabled := range map[int]bool{netlink.FAMILY_V4: h.Forwarding.IPv4, netlink.FAMILY_V6: h.Forwarding.IPv6} {
+			if !enabled {
+				continue
+			}
+			if err := port.SetForwarding(host.NetworkNamespace(), family, true); err != nil {
+				return fmt.Errorf("failed to enable forwarding on host %s: %w", h.Name, err)
+			}
+		}
+	}
+
+	for _, r := range doc.Routes {
+		host, p := hosts[r.Host], ports[r.Host+"/"+r.Port]
+		if r.Destination == "" {
+			err = port.PortAddDefaultRoute(host.NetworkNamespace(), p, r.Gateway)
+		} else {
+			err = port.PortAddRoute(host.NetworkNamespace(), p, r.Destination, r.Gateway)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add route to %s on host %s: %w", r.Destination, r.Host, err)
+		}
+	}
+
+	running := make([]*topology.Host, 0)
+	for _, h := range doc.Hosts {
+		if h.Running {
+			running = append(running, hosts[h.Name])
+		}
+	}
+	if err := topology.BulkError(topology.StartHosts(running, topology.BulkOptions{})); err != nil {
+		return fmt.Errorf("failed to start hosts: %w", err)
+	}
+	return nil
+}
+
+func linkEndpoint(e Endpoint) topology.LinkEndpoint {
+	return topology.LinkEndpoint{
+		Addresses: e.Addresses,
+		Emulation: e.Emulation,
+		Up:        e.Up,
+	}
+}
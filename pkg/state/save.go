@@ -0,0 +1,134 @@
+package state
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/vishvananda/netlink"
+)
+
+// Save captures the live state of the topology as a document.
+func Save() (*Document, error) {
+	doc := &Document{
+		Version:  Version,
+		Topology: topology.Name(),
+		Saved:    time.Now(),
+		Hosts:    make([]Host, 0),
+		Links:    make([]Link, 0),
+		Routes:   make([]Route, 0),
+	}
+	for _, h := range topology.Hosts() {
+		host, err := saveHost(h)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save host %s: %w", h.Name(), err)
+		}
+		doc.Hosts = append(doc.Hosts, *host)
+		routes, err := saveRoutes(h)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save routes of host %s: %w", h.Name(), err)
+		}
+		doc.Routes = append(doc.Routes, routes...)
+	}
+	for _, l := range topology.Links() {
+		a, err := saveEndpoint(l.SelfHost(), l.SelfPort())
+		if err != nil {
+			return nil, err
+		}
+		b, err := saveEndpoint(l.PeerHost(), l.PeerPort())
+		if err != nil {
+			return nil, err
+		}
+		doc.Links = append(doc.Links, Link{A: *a, B: *b})
+	}
+	return doc, nil
+}
+
+func saveHost(h *topology.Host) (*Host, error) {
+	host := &Host{
+		Name:    h.Name(),
+		Device:  h.Device(),
+		Labels:  make(map[string]string),
+		Running: h.Node().Running(),
+	}
+	for k, v := range h.Labels() {
+		if k != "net4me" && !strings.HasPrefix(k, "net4me.") {
+			host.Labels[k] = v
+		}
+	}
+	if configured, ok := h.Node().(node.Configured); ok {
+		config, err := configured.Config()
+		if err != nil {
+			return nil, err
+		}
+		host.Config = config
+	}
+	var err error
+	if host.Forwarding.IPv4, err = port.Forwarding(h.NetworkNamespace(), netlink.FAMILY_V4); err != nil {
+		return nil, err
+	}
+	if host.Forwarding.IPv6, err = port.Forwarding(h.NetworkNamespace(), netlink.FAMILY_V6); err != nil {
+		return nil, err
+	}
+	return host, nil
+}
+
+func saveEndpoint(h *topology.Host, p port.Port) (*Endpoint, error) {
+	endpoint := &Endpoint{
+		Host:      h.Name(),
+		Port:      p.Attrs().Name,
+		Addresses: make([]string, 0),
+		Up:        p.Attrs().Flags&net.FlagUp != 0,
+	}
+	cidrs, err := port.PortAddresses(h.NetworkNamespace(), p, netlink.FAMILY_ALL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses of port %s on host %s: %w", p.Attrs().Name, h.Name(), err)
+	}
+	for _, cidr := range cidrs {
+		if ip, _, err := net.ParseCIDR(cidr); err == nil && !ip.IsLinkLocalUnicast() {
+			endpoint.Addresses = append(endpoint.Addresses, cidr)
+		}
+	}
+	if endpoint.Emulation, err = port.PortEmulation(h.NetworkNamespace(), p); err != nil {
+		return nil, fmt.Errorf("failed to get emulation of port %s on host %s: %w", p.Attrs().Name, h.Name(), err)
+	}
+	return endpoint, nil
+}
+
+// saveRoutes returns the net4me routes of the host that are via its link
+// ports. Routes via other ports (e.g. a NAT uplink) are recreated by the
+// device itself.
+func saveRoutes(h *topology.Host) ([]Route, error) {
+	ports, err := h.Ports()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string)
+	for _, p := range ports {
+		names[p.Attrs().Index] = p.Attrs().Name
+	}
+	routes, err := port.Routes(h.NetworkNamespace(), netlink.FAMILY_ALL)
+	if err != nil {
+		return nil, err
+	}
+	saved := make([]Route, 0)
+	for _, r := range routes {
+		name, ok := names[r.LinkIndex]
+		if !ok {
+			continue
+		}
+		route := Route{Host: h.Name(), Port: name}
+		if r.Dst != nil {
+			route.Destination = r.Dst.String()
+		}
+		if r.Gw != nil {
+			route.Gateway = r.Gw.String()
+		}
+		saved = append(saved, route)
+	}
+	return saved, nil
+}
@@ -0,0 +1,165 @@
+package topology
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultBulkWorkers is the number of hosts operated on concurrently by bulk
+// operations, unless set in the options.
+const DefaultBulkWorkers = 8
+
+// HostSpec describes a host to be created by NewHosts.
+type HostSpec struct {
+	Device string
+	Name   string
+	Labels map[string]string
+	Config map[string]any
+}
+
+// BulkOptions configure bulk host operations.
+type BulkOptions struct {
+	// Workers is the number of hosts operated on concurrently. If not set,
+	// DefaultBulkWorkers is used.
+	Workers int
+	// Timeout is how long the operation on a single host may take. Once it
+	// has passed, the remaining steps of the operation (e.g. starting a
+	// created host) are skipped and its result is reported as timed out. A
+	// step that is already running is still waited for, so that its outcome
+	// (e.g. the created host) is recorded. If not set, there is no timeout.
+	Timeout time.Duration
+	// Start starts the hosts once created. This only applies to NewHosts.
+	Start bool
+}
+
+// BulkResult is the outcome of a bulk operation for a single host.
+type BulkResult struct {
+	Name     string
+	Host     *Host
+	Err      error
+	TimedOut bool
+	Duration time.Duration
+}
+
+// NewHosts creates hosts in the default topology. See Topology.NewHosts.
+func NewHosts(specs []HostSpec, options BulkOptions) []BulkResult {
+	return GetTopology().NewHosts(specs, options)
+}
+
+// NewHosts creates the hosts concurrently, optionally starting each once it is
+// created. A result is returned for every spec, in the same order. The hosts
+// that were created are part of the topology, even if they could not be
+// started, so it is up to the caller to remove them if needed.
+func (t *Topology) NewHosts(specs []HostSpec, options BulkOptions) []BulkResult {
+	results := make([]BulkResult, len(specs))
+	for i, spec := range specs {
+		results[i].Name = spec.Name
+	}
+	bulk(results, options, func(ctx context.Context, i int, r *BulkResult) error {
+		host, err := t.NewHost(specs[i].Device, specs[i].Name, specs[i].Labels, specs[i].Config)
+		if err != nil {
+			return err
+		}
+		r.Host = host
+		if options.Start {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := host.Start(); err != nil {
+				return fmt.Errorf("failed to start host: %w", err)
+			}
+		}
+		return nil
+	})
+	return results
+}
+
+// StartHosts starts the hosts concurrently. A result is returned for every
+// host, in the same order.
+func StartHosts(hosts []*Host, options BulkOptions) []BulkResult {
+	results := hostResults(hosts)
+	bulk(results, options, func(ctx context.Context, i int, r *BulkResult) error {
+		return hosts[i].Start()
+	})
+	return results
+}
+
+// StopHosts stops the hosts concurrently. A result is returned for every host,
+// in the same order.
+func StopHosts(hosts []*Host, options BulkOptions) []BulkResult {
+	results := hostResults(hosts)
+	bulk(results, options, func(ctx context.Context, i int, r *BulkResult) error {
+		return hosts[i].Stop()
+	})
+	return results
+}
+
+// BulkError returns an error summarising the failed results, or nil if all
+// succeeded.
+func BulkError(results []BulkResult) error {
+	failed := 0
+	var first *BulkResult
+	for i := range results {
+		if results[i].Err != nil {
+			if first == nil {
+				first = &results[i]
+			}
+			failed++
+		}
+	}
+	if first == nil {
+		return nil
+	}
+	return fmt.Errorf("%d of %d hosts failed, first %s: %w", failed, len(results), first.Name, first.Err)
+}
+
+func hostResults(hosts []*Host) []BulkResult {
+	results := make([]BulkResult, len(hosts))
+	for i, h := range hosts {
+		results[i].Name, results[i].Host = h.Name(), h
+	}
+	return results
+}
+
+// bulk runs op for each of the results with a bounded number of workers. The op
+// may fill in its result, which is completed with the error and duration of the
+// op. The op is given a context that is done once the timeout has passed, and
+// holds its worker until it returns, even if it has timed out, so that no more
+// than the given number of ops run at once.
+func bulk(results []BulkResult, options BulkOptions, op func(ctx context.Context, i int, r *BulkResult) error) {
+	workers := options.Workers
+	if workers <= 0 {
+		workers = DefaultBulkWorkers
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			ctx, cancel := context.Background(), context.CancelFunc(func() {})
+			if options.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+			}
+			defer cancel()
+			started := time.Now()
+			r := &results[i]
+			r.Err = op(ctx, i, r)
+			r.Duration = time.Since(started)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				r.TimedOut = true
+				if r.Err == nil || errors.Is(r.Err, context.DeadlineExceeded) {
+					r.Err = fmt.Errorf("timed out after %s", options.Timeout)
+				} else {
+					r.Err = fmt.Errorf("timed out after %s: %w", options.Timeout, r.Err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,96 @@
+package topology
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBulk(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      BulkOptions
+		delay        time.Duration
+		err          error
+		wantTimedOut bool
+		wantErr      bool
+	}{
+		{name: "ok", options: BulkOptions{Workers: 2}},
+		{name: "error", options: BulkOptions{Workers: 2}, err: errors.New("failed"), wantErr: true},
+		{name: "within timeout", options: BulkOptions{Workers: 3, Timeout: time.Second}, delay: time.Millisecond},
+		{name: "timed out", options: BulkOptions{Workers: 3, Timeout: 5 * time.Millisecond}, delay: 20 * time.Millisecond, wantTimedOut: true, wantErr: true},
+		{name: "default workers", options: BulkOptions{}, delay: time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workers := tt.options.Workers
+			if workers == 0 {
+				workers = DefaultBulkWorkers
+			}
+			var running, maxRunning atomic.Int32
+			results := make([]BulkResult, 10)
+			bulk(results, tt.options, func(ctx context.Context, i int, r *BulkResult) error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					current := maxRunning.Load()
+					if n <= current || maxRunning.CompareAndSwap(current, n) {
+						break
+					}
+				}
+				// the op ignores its context, as a step that can not be
+				// cancelled would
+				time.Sleep(tt.delay)
+				r.Host = &Host{name: fmt.Sprint(i)}
+				return tt.err
+			})
+			if n := maxRunning.Load(); n > int32(workers) {
+				t.Errorf("%d ops ran at once with %d workers", n, workers)
+			}
+			for i, r := range results {
+				if r.TimedOut != tt.wantTimedOut || (r.Err != nil) != tt.wantErr {
+					t.Errorf("result %d: timed out %v, error %v", i, r.TimedOut, r.Err)
+				}
+				if r.Host == nil || r.Host.name != fmt.Sprint(i) {
+					t.Errorf("result %d: host not recorded", i)
+				}
+				if r.Duration < tt.delay {
+					t.Errorf("result %d: duration %s less than op", i, r.Duration)
+				}
+			}
+		})
+	}
+}
+
+func TestNewHostsTimeout(t *testing.T) {
+	m := registerFakeManager("fake-slow")
+	m.delay = 20 * time.Millisecond
+	topo := New("bulk")
+	specs := []HostSpec{{Device: "fake-slow", Name: "bulk-1"}, {Device: "fake-slow", Name: "bulk-2"}}
+	results := topo.NewHosts(specs, BulkOptions{Timeout: 5 * time.Millisecond, Start: true})
+	if BulkError(results) == nil {
+		t.Fatal("expected an error")
+	}
+	for _, r := range results {
+		if !r.TimedOut {
+			t.Errorf("%s: not timed out", r.Name)
+		}
+		// the host created after the timeout is recorded, so that it can be
+		// removed, but is not started
+		if r.Host == nil {
+			t.Fatalf("%s: host not recorded", r.Name)
+		}
+		if r.Host.State() != HostStateReady {
+			t.Errorf("%s: host is %s", r.Name, r.Host.State())
+		}
+		if err := r.Host.Remove(); err != nil {
+			t.Error(err)
+		}
+	}
+	if names := m.names(); len(names) != 0 {
+		t.Fatalf("nodes left after removal: %v", names)
+	}
+}
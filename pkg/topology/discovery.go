@@ -0,0 +1,285 @@
+package topology
+
+import (
+	"os"
+	"sync"
+
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/neaas/neslink"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+// discoveryWorkers is the number of hosts whose ports are listed concurrently
+// during link discovery.
+const discoveryWorkers = 8
+
+// portKey identifies a port by its host and index, as port indexes are only
+// unique within a network namespace.
+type portKey struct {
+	host  string
+	index int
+}
+
+type hostPort struct {
+	host *Host
+	port port.Port
+}
+
+// discoverLinks finds the links between the given hosts. The ports of each host
+// are listed once, and the veth ports are then paired using an index of their
+// peer indexes (IFLA_LINK). As indexes are per namespace, a pair is only
+// formed when both ports reference each other and the peer is in the
+// namespace referenced by the port (IFLA_LINK_NETNSID).
+func discoverLinks(hosts []*Host) []*Link {
+	hostPorts := make([][]hostPort, len(hosts))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, discoveryWorkers)
+	for i, h := range hosts {
+		wg.Add(1)
+		go func(i int, h *Host) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			ports, err := h.node.Ports()
+			if err != nil {
+				logrus.WithError(err).WithField("host", h.name).Debugln("could not list host ports for link discovery")
+				return
+			}
+			for _, p := range ports {
+				if _, ok := p.(*netlink.Veth); ok && p.Attrs().ParentIndex != 0 {
+					hostPorts[i] = append(hostPorts[i], hostPort{host: h, port: p})
+				}
+			}
+		}(i, h)
+	}
+	wg.Wait()
+	return pairLinks(hostPorts, newNsIDResolver().resolve)
+}
+
+// nsIDUnknown is returned by a namespace id resolver if the id can not be
+// determined, in which case ports are paired by their indexes alone.
+const nsIDUnknown = -2
+
+// pairLinks pairs the veth ports of the hosts into links. peerNsID returns the
+// id of the namespace of the peer host in that of the host, or -1 if both are
+// in the same namespace.
+func pairLinks(hostPorts [][]hostPort, peerNsID func(self, peer *Host) int) []*Link {
+	byIndex := make(map[int][]hostPort)
+	for _, ports := range hostPorts {
+		for _, hp := range ports {
+			byIndex[hp.port.Attrs().Index] = append(byIndex[hp.port.Attrs().Index], hp)
+		}
+	}
+	paired := make(map[portKey]bool)
+	links := make([]*Link, 0)
+	for _, ports := range hostPorts {
+		for _, self := range ports {
+			selfKey := portKey{self.host.id, self.port.Attrs().Index}
+			if paired[selfKey] {
+				continue
+			}
+			for _, peer := range byIndex[self.port.Attrs().ParentIndex] {
+				peerKey := portKey{peer.host.id, peer.port.Attrs().Index}
+				if peerKey == selfKey || paired[peerKey] || peer.port.Attrs().ParentIndex != self.port.Attrs().Index {
+					continue
+				}
+				if id := peerNsID(self.host, peer.host); id != nsIDUnknown && id != self.port.Attrs().NetNsID {
+					continue
+				}
+				paired[selfKey], paired[peerKey] = true, true
+				links = append(links, newLink(self.host, self.port, peer.host, peer.port))
+				break
+			}
+		}
+	}
+	return links
+}
+
+// nsIDResolver looks up the ids that the namespaces of hosts have in each
+// other's namespaces, caching them for a single discovery.
+type nsIDResolver struct {
+	ids map[[2]string]int
+}
+
+func newNsIDResolver() *nsIDResolver {
+	return &nsIDResolver{ids: make(map[[2]string]int)}
+}
+
+func (r *nsIDResolver) resolve(self, peer *Host) int {
+	key := [2]string{self.id, peer.id}
+	if id, ok := r.ids[key]; ok {
+		return id
+	}
+	id, err := netNsID(self.NetworkNamespace(), peer.NetworkNamespace())
+	if err != nil {
+		logrus.WithError(err).WithField("host", self.name).WithField("peer", peer.name).Debugln("could not get namespace id of peer for link discovery")
+		id = nsIDUnknown
+	}
+	r.ids[key] = id
+	return id
+}
+
+// netNsID returns the id of the peer namespace in the namespace, or -1 if they
+// are the same namespace.
+func netNsID(nsp, peer neslink.NsProvider) (int, error) {
+	ns, err := nsp.Provide()
+	if err != nil {
+		return 0, err
+	}
+	peerNs, err := peer.Provide()
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.Open(peerNs.String())
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	nsInfo, err := os.Stat(ns.String())
+	if err != nil {
+		return 0, err
+	}
+	peerInfo, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if os.SameFile(nsInfo, peerInfo) {
+		return -1, nil
+	}
+	id := -1
+	if err := neslink.Do(nsp, neslink.NAGeneric("get-netns-id", func() error {
+		id, err = netlink.GetNetNsIdByFd(int(f.Fd()))
+		return err
+	})); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// linkCache holds the links found by discovery until a link changes in the
+// namespace of any host, as reported by netlink events. The attributes of the
+// cached ports (e.g. statistics) are those at the time of discovery, see
+// Topology.DiscoverLinks.
+type linkCache struct {
+	lock     sync.Mutex
+	enabled  bool
+	valid    bool
+	links    []*Link
+	watchers map[string]chan struct{}
+	// generation is incremented on every invalidation, so that links found
+	// by a discovery that raced with an event are not cached
+	generation uint64
+}
+
+func newLinkCache() *linkCache {
+	return &linkCache{
+		watchers: make(map[string]chan struct{}),
+	}
+}
+
+// get returns the cached links, and whether they are valid, along with the
+// current generation.
+func (c *linkCache) get() ([]*Link, bool, uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.enabled || !c.valid {
+		return nil, false, c.generation
+	}
+	return append([]*Link(nil), c.links...), true, c.generation
+}
+
+// put caches the links found by a discovery that started at the given
+// generation, watching the namespaces of the hosts for changes.
+func (c *linkCache) put(hosts []*Host, links []*Link, generation uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.enabled {
+		return
+	}
+	for _, h := range hosts {
+		if err := c.watch(h); err != nil {
+			logrus.WithError(err).WithField("host", h.name).Warnln("could not watch host links, so links are not cached")
+			return
+		}
+	}
+	if generation != c.generation {
+		return
+	}
+	c.links = links
+	c.valid = true
+}
+
+func (c *linkCache) invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.valid = false
+	c.generation++
+}
+
+// watch subscribes to link updates in the namespace of the host. The lock
+// must be held.
+func (c *linkCache) watch(h *Host) error {
+	if _, ok := c.watchers[h.id]; ok {
+		return nil
+	}
+	updates := make(chan netlink.LinkUpdate)
+	done := make(chan struct{})
+	// the subscription socket stays in the namespace it is opened in
+	if err := neslink.Do(
+		h.NetworkNamespace(),
+		neslink.NAGeneric("subscribe-links", func() error {
+			return netlink.LinkSubscribe(updates, done)
+		}),
+	); err != nil {
+		return err
+	}
+	c.watchers[h.id] = done
+	go func() {
+		for range updates {
+			c.invalidate()
+		}
+		// the subscription ends when the namespace is gone or unwatched
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		if c.watchers[h.id] == done {
+			delete(c.watchers, h.id)
+		}
+		c.valid = false
+		c.generation++
+	}()
+	return nil
+}
+
+// unwatch stops watching the namespace of the host.
+func (c *linkCache) unwatch(h *Host) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if done, ok := c.watchers[h.id]; ok {
+		close(done)
+		delete(c.watchers, h.id)
+	}
+	c.valid = false
+	c.generation++
+}
+
+// setEnabled enables or disables caching.
+func (c *linkCache) setEnabled(enabled bool) {
+	c.lock.Lock()
+	c.enabled = enabled
+	c.lock.Unlock()
+	c.reset()
+}
+
+// reset drops the cached links and stops all watchers, e.g. when the hosts of
+// the topology are reloaded.
+func (c *linkCache) reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for id, done := range c.watchers {
+		close(done)
+		delete(c.watchers, id)
+	}
+	c.valid = false
+	c.generation++
+}
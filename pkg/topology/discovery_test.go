@@ -0,0 +1,102 @@
+package topology
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+)
+
+// testVeth returns a host port of a veth with the given index, peer index and
+// peer namespace id.
+func testVeth(h *Host, index, parentIndex, nsID int) hostPort {
+	veth := &netlink.Veth{}
+	veth.Index, veth.ParentIndex, veth.NetNsID = index, parentIndex, nsID
+	return hostPort{host: h, port: veth}
+}
+
+func TestPairLinks(t *testing.T) {
+	a, b, c := &Host{id: "a", name: "a"}, &Host{id: "b", name: "b"}, &Host{id: "c", name: "c"}
+	// nsIDs holds the ids of the namespaces of hosts in each host's namespace
+	nsIDs := map[string]map[string]int{
+		"a": {"a": -1, "b": 1, "c": 2},
+		"b": {"a": 3, "b": -1, "c": 4},
+		"c": {"a": 5, "b": 6, "c": -1},
+	}
+	resolve := func(self, peer *Host) int {
+		return nsIDs[self.id][peer.id]
+	}
+	unknown := func(self, peer *Host) int {
+		return nsIDUnknown
+	}
+	tests := []struct {
+		name    string
+		ports   [][]hostPort
+		resolve func(self, peer *Host) int
+		want    []string
+	}{
+		{
+			name:    "single link",
+			ports:   [][]hostPort{{testVeth(a, 5, 7, 1)}, {testVeth(b, 7, 5, 3)}},
+			resolve: resolve,
+			want:    []string{"a/5-b/7"},
+		},
+		{
+			// a/5 and b/5 both reference index 7, but only a is the peer of c/7
+			name:    "colliding indexes",
+			ports:   [][]hostPort{{testVeth(b, 5, 7, 0)}, {testVeth(a, 5, 7, 2)}, {testVeth(c, 7, 5, 5)}},
+			resolve: resolve,
+			want:    []string{"a/5-c/7"},
+		},
+		{
+			name: "colliding indexes of two links",
+			ports: [][]hostPort{
+				{testVeth(c, 7, 5, 6), testVeth(c, 8, 5, 5)},
+				{testVeth(a, 5, 8, 2)},
+				{testVeth(b, 5, 7, 4)},
+			},
+			resolve: resolve,
+			want:    []string{"c/7-b/5", "c/8-a/5"},
+		},
+		{
+			name:    "same namespace",
+			ports:   [][]hostPort{{testVeth(a, 5, 6, -1), testVeth(a, 6, 5, -1)}},
+			resolve: resolve,
+			want:    []string{"a/5-a/6"},
+		},
+		{
+			name:    "peer outside of the hosts",
+			ports:   [][]hostPort{{testVeth(a, 5, 7, 9)}, {testVeth(b, 7, 5, 9)}},
+			resolve: resolve,
+			want:    []string{},
+		},
+		{
+			name:    "one sided reference",
+			ports:   [][]hostPort{{testVeth(a, 5, 7, 1)}, {testVeth(b, 7, 6, 3)}},
+			resolve: resolve,
+			want:    []string{},
+		},
+		{
+			name:    "unknown namespace ids",
+			ports:   [][]hostPort{{testVeth(a, 5, 7, 1)}, {testVeth(b, 7, 5, 3)}},
+			resolve: unknown,
+			want:    []string{"a/5-b/7"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, l := range pairLinks(tt.ports, tt.resolve) {
+				got = append(got, linkString(l))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func linkString(l *Link) string {
+	return fmt.Sprintf("%s/%d-%s/%d", l.SelfHost().id, l.SelfPort().Attrs().Index, l.PeerHost().id, l.PeerPort().Attrs().Index)
+}
@@ -0,0 +1,148 @@
+package topology
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/neaas/neslink"
+)
+
+// fakeManager is an in-memory node manager for tests. Its nodes have no ports
+// and only run while started.
+type fakeManager struct {
+	device string
+	lock   sync.Mutex
+	nodes  []*fakeNode
+	ids    atomic.Int64
+	// delay is how long adding a node takes
+	delay time.Duration
+	// listDelay is how long listing the nodes takes, after they are listed
+	listDelay time.Duration
+}
+
+var (
+	fakeManagers     = make(map[string]*fakeManager)
+	fakeManagersLock sync.Mutex
+)
+
+// registerFakeManager registers a fake manager for the device once, returning
+// the registered manager on later calls.
+func registerFakeManager(device string) *fakeManager {
+	fakeManagersLock.Lock()
+	defer fakeManagersLock.Unlock()
+	if m, ok := fakeManagers[device]; ok {
+		return m
+	}
+	m := &fakeManager{device: device}
+	fakeManagers[device] = m
+	node.RegisterManager(m)
+	return m
+}
+
+func (m *fakeManager) Device() string                { return m.device }
+func (m *fakeManager) Setup(map[string]any) error    { return nil }
+func (m *fakeManager) Info() (map[string]any, error) { return map[string]any{}, nil }
+func (m *fakeManager) Icon() string                  { return "cube" }
+func (m *fakeManager) Color() string                 { return "grey" }
+
+func (m *fakeManager) Nodes(filters ...node.NodeFilter) ([]node.Node, error) {
+	m.lock.Lock()
+	nodes := make([]node.Node, 0, len(m.nodes))
+	for _, n := range m.nodes {
+		nodes = append(nodes, n)
+	}
+	m.lock.Unlock()
+	time.Sleep(m.listDelay)
+	for _, filter := range filters {
+		nodes = filter(nodes)
+	}
+	return nodes, nil
+}
+
+func (m *fakeManager) Add(name string, labels map[string]string, config map[string]any) (node.Node, error) {
+	time.Sleep(m.delay)
+	n := &fakeNode{
+		id:      fmt.Sprintf("%s-%d", m.device, m.ids.Add(1)),
+		name:    name,
+		labels:  maps.Clone(labels),
+		manager: m,
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, existing := range m.nodes {
+		if existing.name == name {
+			return nil, fmt.Errorf("node %s already exists", name)
+		}
+	}
+	m.nodes = append(m.nodes, n)
+	return n, nil
+}
+
+func (m *fakeManager) Remove(n node.Node) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i, existing := range m.nodes {
+		if existing.id == n.ID() {
+			m.nodes = append(m.nodes[:i], m.nodes[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("node %s not found", n.ID())
+}
+
+// names returns the names of the nodes of the manager.
+func (m *fakeManager) names() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	names := make([]string, 0, len(m.nodes))
+	for _, n := range m.nodes {
+		names = append(names, n.name)
+	}
+	return names
+}
+
+type fakeNode struct {
+	id      string
+	name    string
+	labels  map[string]string
+	running atomic.Bool
+	manager *fakeManager
+}
+
+func (n *fakeNode) ID() string                         { return n.id }
+func (n *fakeNode) Manager() node.Manager              { return n.manager }
+func (n *fakeNode) Name() (string, error)              { return n.name, nil }
+func (n *fakeNode) Device() string                     { return n.manager.device }
+func (n *fakeNode) Running() bool                      { return n.running.Load() }
+func (n *fakeNode) NetNs() neslink.NsProvider          { return neslink.NPNow() }
+func (n *fakeNode) Ports() ([]port.Port, error)        { return []port.Port{}, nil }
+func (n *fakeNode) PortAdd(port.Port) error            { return nil }
+func (n *fakeNode) PortRemove(port.Port) error         { return nil }
+func (n *fakeNode) Stats() (map[string]any, error)     { return map[string]any{}, nil }
+func (n *fakeNode) Labels() (map[string]string, error) { return maps.Clone(n.labels), nil }
+
+func (n *fakeNode) Start() error {
+	n.running.Store(true)
+	return nil
+}
+
+func (n *fakeNode) Stop() error {
+	n.running.Store(false)
+	return nil
+}
+
+func (n *fakeNode) Info() (map[string]any, error) {
+	n.manager.lock.Lock()
+	defer n.manager.lock.Unlock()
+	for _, existing := range n.manager.nodes {
+		if existing == n {
+			return map[string]any{"name": n.name}, nil
+		}
+	}
+	return nil, fmt.Errorf("node %s not found", n.id)
+}
@@ -1,18 +1,25 @@
 package topology
 
 import (
+	"fmt"
+	"path"
+	"strings"
+
 	"github.com/ai4networks/net4me/pkg/node"
 )
 
-func hosts() ([]*Host, error) {
+// hostsFromManagers creates a host of the topology for every node of the
+// registered managers that belongs to the topology, without adding them to the
+// topology.
+func (t *Topology) hostsFromManagers() ([]*Host, error) {
 	var hosts []*Host
 	for _, manager := range node.Managers() {
-		nodes, err := manager.Nodes()
+		nodes, err := manager.Nodes(node.FilterByTopology(t.name))
 		if err != nil {
 			return nil, err
 		}
 		for _, n := range nodes {
-			host, err := NewHostFromNode(n)
+			host, err := t.newHost(n)
 			if err != nil {
 				return nil, err
 			}
@@ -65,3 +72,63 @@ func FilterByID(ids ...string) HostFilter {
 		return filtered
 	}
 }
+
+// FilterByNameMatch keeps the hosts with a name matching any of the given
+// glob patterns (see path.Match).
+func FilterByNameMatch(patterns ...string) HostFilter {
+	return func(hosts []*Host) []*Host {
+		filtered := make([]*Host, 0)
+		for _, n := range hosts {
+			for _, pattern := range patterns {
+				if ok, _ := path.Match(pattern, n.Name()); ok {
+					filtered = append(filtered, n)
+					break
+				}
+			}
+		}
+		return filtered
+	}
+}
+
+// FilterByLabel keeps the hosts that have the label with the given value.
+func FilterByLabel(key, value string) HostFilter {
+	return func(hosts []*Host) []*Host {
+		filtered := make([]*Host, 0)
+		for _, n := range hosts {
+			if v, ok := n.Labels()[key]; ok && v == value {
+				filtered = append(filtered, n)
+			}
+		}
+		return filtered
+	}
+}
+
+// ParseSelector parses a comma separated list of `key=value` terms into host
+// filters, all of which must match. The `name` key matches host names using
+// glob patterns, the `device` key matches the device type, and any other key
+// matches a host label. For example: `device=dind,name=site-*,region=eu`.
+func ParseSelector(selector string) ([]HostFilter, error) {
+	filters := make([]HostFilter, 0)
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(term, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid selector term: %s", term)
+		}
+		switch key {
+		case "name":
+			if _, err := path.Match(value, ""); err != nil {
+				return nil, fmt.Errorf("invalid name pattern %s: %w", value, err)
+			}
+			filters = append(filters, FilterByNameMatch(value))
+		case "device":
+			filters = append(filters, FilterByDevice(value))
+		default:
+			filters = append(filters, FilterByLabel(key, value))
+		}
+	}
+	return filters, nil
+}
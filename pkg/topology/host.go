@@ -2,39 +2,93 @@ package topology
 
 import (
 	"fmt"
+	"maps"
+	"net"
 	"time"
 
 	"github.com/ai4networks/net4me/pkg/node"
 	"github.com/ai4networks/net4me/pkg/port"
 	"github.com/neaas/neslink"
 	"github.com/segmentio/ksuid"
+	"github.com/vishvananda/netlink"
 )
 
-// NewHost creates a new topology host and its underlying node. For this, the
-// manager for the provided device is used to create the node. If the manager
-// does not exist an error is returned. The host is given an auto generated
-// unique ID to couple with the node info. If the node can not be added (e.g.
-// name can not be determined), an error will be returned.
+// NewHost creates a new host in the default topology. See Topology.NewHost.
 func NewHost(device, name string, labels map[string]string, config map[string]any) (*Host, error) {
+	return GetTopology().NewHost(device, name, labels, config)
+}
+
+// NewHostFromNode creates a new host in the default topology from a given
+// node. See Topology.NewHostFromNode.
+func NewHostFromNode(n node.Node) (*Host, error) {
+	return GetTopology().NewHostFromNode(n)
+}
+
+// NewHost creates a new topology host and its underlying node. For this, the
+// manager for the provided device is used to create the node, labelled with the
+// name of the topology. If the manager does not exist an error is returned. As
+// node names are used for system resources (e.g. containers and namespaces),
+// they are shared by all topologies, and a name already used by a node of the
+// device is rejected. The host is given an auto generated unique ID to couple
+// with the node info. If the node can not be added (e.g. name can not be
+// determined), an error will be returned.
+func (t *Topology) NewHost(device, name string, labels map[string]string, config map[string]any) (*Host, error) {
 	addedAt := time.Now()
 	manager := node.Device(device)
 	if manager == nil {
 		return nil, fmt.Errorf("device manager not found: %s", device)
 	}
-	n, err := manager.Add(name, labels, config)
+	if err := t.checkName(manager, name); err != nil {
+		return nil, err
+	}
+	nodeLabels := make(map[string]string)
+	maps.Copy(nodeLabels, labels)
+	nodeLabels[node.TopologyLabel] = t.name
+	n, err := manager.Add(name, nodeLabels, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create node for host: %w", err)
 	}
-	host, err := NewHostFromNode(n)
+	host, err := t.newHost(n)
+	if err != nil {
+		return nil, err
+	}
 	host.addedAt = addedAt
-	return host, err
+	t.add(host)
+	return host, nil
+}
+
+// checkName returns an error if a node of the manager already has the name,
+// naming the topology of the node.
+func (t *Topology) checkName(manager node.Manager, name string) error {
+	nodes, err := manager.Nodes(node.FilterByName(name))
+	if err != nil {
+		return fmt.Errorf("failed to check for existing nodes named %s: %w", name, err)
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	if owner := node.TopologyOf(nodes[0]); owner != t.name {
+		return fmt.Errorf("name %s is already used by a %s node of topology %s, and node names are shared by all topologies", name, manager.Device(), owner)
+	}
+	return fmt.Errorf("host %s already exists in the topology", name)
 }
 
 // NewHostFromNode creates a new topology host from a given node. The node can
 // be of any device type. The host is given an auto generated unique ID to
 // couple with the node info. If the node can not be added (e.g. name can not be
 // determined), an error will be returned.
-func NewHostFromNode(n node.Node) (*Host, error) {
+func (t *Topology) NewHostFromNode(n node.Node) (*Host, error) {
+	host, err := t.newHost(n)
+	if err != nil {
+		return nil, err
+	}
+	t.add(host)
+	return host, nil
+}
+
+// newHost creates a host of the topology for the node, without adding it to
+// the topology.
+func (t *Topology) newHost(n node.Node) (*Host, error) {
 	id, err := ksuid.NewRandom()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate unique host id: %w", err)
@@ -43,21 +97,27 @@ func NewHostFromNode(n node.Node) (*Host, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get host name from node: %w", err)
 	}
-	h := &Host{
+	labels := make(map[string]string)
+	if l, ok := n.(node.Labelled); ok {
+		nodeLabels, err := l.Labels()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get host labels from node: %w", err)
+		}
+		maps.Copy(labels, nodeLabels)
+	}
+	return &Host{
 		id:        id.String(),
 		name:      name,
 		addedAt:   time.Now(),
 		updatedAt: time.Now(),
-		labels:    make(map[string]string), //TODO: add labels to node
-		topology:  topology,
+		labels:    labels,
+		topology:  t,
 		node:      n,
-	}
-	topology.hosts = append(topology.hosts, h)
-	return h, nil
+	}, nil
 }
 
-// Remove removes the underlying node from the host. If the node can not be
-// removed, an error will be returned.
+// Remove removes the underlying node from the host and the host from its
+// topology. If the node can not be removed, an error will be returned.
 func (h *Host) Remove() error {
 	manager := node.Device(h.node.Device())
 	if manager == nil {
@@ -66,12 +126,7 @@ func (h *Host) Remove() error {
 	if err := manager.Remove(h.node); err != nil {
 		return fmt.Errorf("failed to remove node: %w", err)
 	}
-	for i, host := range topology.hosts {
-		if host.id == h.id {
-			topology.hosts = append(topology.hosts[:i], topology.hosts[i+1:]...)
-			break
-		}
-	}
+	h.topology.remove(h)
 	return nil
 }
 
@@ -117,7 +172,7 @@ func (h *Host) Start() error {
 // Stop stops the underlying node. If the node can not be stopped, an error will
 // be returned.
 func (h *Host) Stop() error {
-	if h.State() != HostStateRunning {
+	if !h.State().Started() {
 		return fmt.Errorf("host is not in running state")
 	}
 	return h.node.Stop()
@@ -126,14 +181,63 @@ func (h *Host) Stop() error {
 // Ports returns the ports of the underlying node. If the node is not in a valid
 // state for ports, an error will be returned.
 func (h *Host) Ports() ([]port.Port, error) {
-	if h.State() != HostStateReady && h.State() != HostStateRunning {
+	if !h.State().Exists() {
 		return nil, fmt.Errorf("host is not in valid state for ports")
 	}
 	return h.node.Ports()
 }
 
+// Addresses returns the addresses (in cidr notation) of all ports of the host,
+// excluding link-local addresses. If the ports can not be determined, an error
+// will be returned.
+func (h *Host) Addresses() ([]string, error) {
+	ports, err := h.Ports()
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, 0)
+	for _, p := range ports {
+		cidrs, err := port.PortAddresses(h.NetworkNamespace(), p, netlink.FAMILY_ALL)
+		if err != nil {
+			return nil, err
+		}
+		for _, cidr := range cidrs {
+			if ip, _, err := net.ParseCIDR(cidr); err == nil && !ip.IsLinkLocalUnicast() {
+				addresses = append(addresses, cidr)
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// FindPort returns the port of the host identified by the given reference. The
+// reference can either be the name of a port on the host, or the name of a
+// peer host, in which case the port of the link to that peer is returned. If
+// no port matches, an error will be returned.
+func (h *Host) FindPort(ref string) (port.Port, error) {
+	ports, err := h.Ports()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range ports {
+		if p.Attrs().Name == ref {
+			return p, nil
+		}
+	}
+	links, err := h.Links()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range links {
+		if l.PeerHost().Name() == ref {
+			return l.SelfPort(), nil
+		}
+	}
+	return nil, fmt.Errorf("no port or link found on host %s for %s", h.Name(), ref)
+}
+
 func (h *Host) Stats() (map[string]any, error) {
-	if h.State() != HostStateRunning {
+	if !h.State().Started() {
 		return nil, fmt.Errorf("host is not in running state")
 	}
 	return h.node.Stats()
@@ -1,34 +1,176 @@
 package topology
 
-import "github.com/ai4networks/net4me/pkg/port"
+import (
+	"errors"
+	"fmt"
 
+	"github.com/ai4networks/net4me/pkg/port"
+)
+
+func newLink(selfHost *Host, selfPort port.Port, peerHost *Host, peerPort port.Port) *Link {
+	l := &Link{}
+	l.self.host, l.self.port = selfHost, selfPort
+	l.peer.host, l.peer.port = peerHost, peerPort
+	return l
+}
+
+// reversed returns the link from the perspective of its peer host.
+func (l *Link) reversed() *Link {
+	return newLink(l.peer.host, l.peer.port, l.self.host, l.self.port)
+}
+
+// LinkEndpoint describes how one end of a link is configured once attached to
+// its host. Addresses are cidr prefixes, added without duplicate address
+// detection. Gateways are added as default routes through the port once it is
+// configured, so they must be reachable from its addresses. If IPv6 is nil,
+// the kernel defaults for IPv6 are kept.
+type LinkEndpoint struct {
+	Addresses []string
+	Gateways  []string
+	Emulation *port.Emulation
+	IPv6      *IPv6Options
+	Up        bool
+}
+
+// IPv6Options configure IPv6 on a port. Unless disabled, router advertisements
+// received on the port are only used if AcceptRA is set, and duplicate address
+// detection is only performed for autoconfigured addresses if DAD is set.
+type IPv6Options struct {
+	Disabled bool
+	AcceptRA bool
+	DAD      bool
+}
+
+// LinkOptions configure the ends of a link created by LinkWith.
+type LinkOptions struct {
+	Self LinkEndpoint
+	Peer LinkEndpoint
+}
+
+// Link links the host to the peer, leaving both ports down and unconfigured.
+// See LinkWith.
 func (h *Host) Link(peer *Host) (*Link, error) {
-	sp, pp, err := port.CreatePortPair()
+	return h.LinkWith(peer, LinkOptions{})
+}
+
+// LinkWith links the host to the peer and configures each end of the link as
+// described by the options. This is done as a single operation, so if any step
+// fails, the ports are detached from the hosts and destroyed before the error
+// is returned, leaving the topology as it was.
+func (h *Host) LinkWith(peer *Host, options LinkOptions) (*Link, error) {
+	if peer.topology != h.topology {
+		return nil, fmt.Errorf("hosts %s and %s are not in the same topology", h.name, peer.name)
+	}
+	sp, pp, err := h.topology.Pool().CreatePortPair()
 	if err != nil {
 		return nil, err
 	}
-	if err := h.node.PortAdd(sp); err != nil {
-		return nil, err
+	defer h.topology.links.invalidate()
+	l := newLink(h, sp, peer, pp)
+	tx := linkTx{link: l}
+	if err := tx.attach(); err != nil {
+		return nil, tx.rollback(err)
 	}
-	if err := peer.node.PortAdd(pp); err != nil {
-		return nil, err
+	if err := configureEndpoint(h, sp, options.Self); err != nil {
+		return nil, tx.rollback(err)
+	}
+	if err := configureEndpoint(peer, pp, options.Peer); err != nil {
+		return nil, tx.rollback(err)
+	}
+	return l, nil
+}
+
+// linkTx tracks which ends of a link being created are attached to their
+// hosts, so that it can be undone.
+type linkTx struct {
+	link         *Link
+	selfAttached bool
+	peerAttached bool
+}
+
+func (tx *linkTx) attach() error {
+	l := tx.link
+	if err := l.self.host.node.PortAdd(l.self.port); err != nil {
+		return fmt.Errorf("failed to attach port to host %s: %w", l.self.host.name, err)
+	}
+	tx.selfAttached = true
+	if err := l.peer.host.node.PortAdd(l.peer.port); err != nil {
+		return fmt.Errorf("failed to attach port to host %s: %w", l.peer.host.name, err)
+	}
+	tx.peerAttached = true
+	return nil
+}
+
+// rollback detaches the attached ends of the link and destroys the port pair,
+// which also removes any addresses and emulation configured on it. The error
+// that caused the rollback is returned, joined with any error of the rollback.
+func (tx *linkTx) rollback(cause error) error {
+	l := tx.link
+	errs := make([]error, 0)
+	// destroying either end destroys the pair, so each way of destroying it is
+	// tried in turn, preferring the ends in the pool to those left in a host
+	pool := l.self.host.topology.Pool()
+	destroy := make([]func() error, 0, 2)
+	fallback := make([]func() error, 0, 2)
+	for _, e := range []struct {
+		host     *Host
+		port     port.Port
+		attached bool
+	}{
+		{l.self.host, l.self.port, tx.selfAttached},
+		{l.peer.host, l.peer.port, tx.peerAttached},
+	} {
+		if e.attached {
+			if err := e.host.node.PortRemove(e.port); err != nil {
+				errs = append(errs, fmt.Errorf("failed to detach port from host %s: %w", e.host.name, err))
+				fallback = append(fallback, func() error { return port.PortPairRemove(e.host.NetworkNamespace(), e.port) })
+				continue
+			}
+		}
+		destroy = append(destroy, func() error { return pool.DestroyPortPair(e.port) })
 	}
-	return &Link{
-		self: struct {
-			host *Host
-			port port.Port
-		}{
-			host: h,
-			port: sp,
-		},
-		peer: struct {
-			host *Host
-			port port.Port
-		}{
-			host: peer,
-			port: pp,
-		},
-	}, nil
+	var destroyErr error
+	for _, d := range append(destroy, fallback...) {
+		if destroyErr = d(); destroyErr == nil {
+			break
+		}
+	}
+	if destroyErr != nil {
+		errs = append(errs, fmt.Errorf("failed to destroy port pair: %w", destroyErr))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w (rollback failed: %w)", cause, errors.Join(errs...))
+	}
+	return cause
+}
+
+func configureEndpoint(h *Host, p port.Port, e LinkEndpoint) error {
+	if e.IPv6 != nil {
+		if err := configureIPv6(h, p, *e.IPv6); err != nil {
+			return err
+		}
+	}
+	for _, address := range e.Addresses {
+		if err := port.PortAddAddressNoDAD(h.NetworkNamespace(), p, address); err != nil {
+			return fmt.Errorf("failed to add address %s to host %s: %w", address, h.name, err)
+		}
+	}
+	if e.Emulation != nil {
+		if err := port.PortSetEmulation(h.NetworkNamespace(), p, e.Emulation.Latency, e.Emulation.Jitter, e.Emulation.Loss); err != nil {
+			return fmt.Errorf("failed to set emulation on host %s: %w", h.name, err)
+		}
+	}
+	if e.Up {
+		if err := port.PortSetUp(h.NetworkNamespace(), p); err != nil {
+			return fmt.Errorf("failed to set port up on host %s: %w", h.name, err)
+		}
+	}
+	for _, gateway := range e.Gateways {
+		if err := port.PortAddDefaultRoute(h.NetworkNamespace(), p, gateway); err != nil {
+			return fmt.Errorf("failed to add default route via %s to host %s: %w", gateway, h.name, err)
+		}
+	}
+	return nil
 }
 
 func (h *Host) Unlink(peer *Host) error {
@@ -36,6 +178,7 @@ func (h *Host) Unlink(peer *Host) error {
 	if err != nil {
 		return err
 	}
+	defer h.topology.links.invalidate()
 	for _, l := range links {
 		if l.peer.host == peer {
 			if err := h.node.PortRemove(l.self.port); err != nil {
@@ -44,7 +187,7 @@ func (h *Host) Unlink(peer *Host) error {
 			if err := peer.node.PortRemove(l.peer.port); err != nil {
 				return err
 			}
-			if err := port.DestroyPortPair(l.self.port); err != nil {
+			if err := h.topology.Pool().DestroyPortPair(l.self.port); err != nil {
 				return err
 			}
 		}
@@ -52,43 +195,35 @@ func (h *Host) Unlink(peer *Host) error {
 	return nil
 }
 
+// Links returns the links of the host, each from the perspective of the host.
 func (h *Host) Links() ([]*Link, error) {
-	selfPorts, err := h.Ports()
-	if err != nil {
-		return nil, err
+	if !h.State().Exists() {
+		return nil, fmt.Errorf("host is not in valid state for links")
 	}
 	links := make([]*Link, 0)
-	for _, p := range selfPorts {
-		peerIdx, err := port.PortPeerIndex(h.NetworkNamespace(), p)
-		if err != nil {
-			continue
-		}
-		for _, ph := range topology.hosts {
-			peerPorts, err := ph.Ports()
-			if err != nil {
-				continue
-			}
-			for _, pp := range peerPorts {
-				if pp.Attrs().Index == peerIdx {
-					links = append(links, &Link{
-						self: struct {
-							host *Host
-							port port.Port
-						}{
-							host: h,
-							port: p,
-						},
-						peer: struct {
-							host *Host
-							port port.Port
-						}{
-							host: ph,
-							port: pp,
-						},
-					})
-				}
-			}
+	for _, l := range h.topology.Links() {
+		switch h {
+		case l.self.host:
+			links = append(links, l)
+		case l.peer.host:
+			links = append(links, l.reversed())
 		}
 	}
 	return links, nil
 }
+
+func configureIPv6(h *Host, p port.Port, options IPv6Options) error {
+	if options.Disabled {
+		if err := port.PortSetIPv6(h.NetworkNamespace(), p, false); err != nil {
+			return fmt.Errorf("failed to disable ipv6 on host %s: %w", h.name, err)
+		}
+		return nil
+	}
+	if err := port.PortSetAcceptRA(h.NetworkNamespace(), p, options.AcceptRA); err != nil {
+		return fmt.Errorf("failed to set router advertisements on host %s: %w", h.name, err)
+	}
+	if err := port.PortSetDAD(h.NetworkNamespace(), p, options.DAD); err != nil {
+		return fmt.Errorf("failed to set duplicate address detection on host %s: %w", h.name, err)
+	}
+	return nil
+}
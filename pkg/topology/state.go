@@ -1,12 +1,18 @@
 package topology
 
+import "github.com/ai4networks/net4me/pkg/node"
+
 const (
 	HostStateUnknown HostState = "unknown"
 	HostStateReady   HostState = "ready"
 	HostStateRunning HostState = "running"
-	HostStateError   HostState = "error"
-	HostStateStopped HostState = "stopped"
-	HostStateRemoved HostState = "removed"
+	// HostStateStarting and HostStateUnhealthy are the states of a running
+	// host whose node is not (yet) healthy, as returned by Host.Health.
+	HostStateStarting  HostState = "starting"
+	HostStateUnhealthy HostState = "unhealthy"
+	HostStateError     HostState = "error"
+	HostStateStopped   HostState = "stopped"
+	HostStateRemoved   HostState = "removed"
 )
 
 // State returns a HostState representing the status of the underlying node. To
@@ -14,7 +20,8 @@ const (
 // be collected, it is assumed that the node is not created or has been removed.
 // However, if this is not the case, the state will be considered Ready.
 // Furthermore, if Info determines the node to be ready and Running returns
-// true, the node is considered to be running.
+// true, the node is considered to be running. The health of the node is not
+// checked, see Health.
 func (h *Host) State() HostState {
 	state := HostStateUnknown
 	if _, err := h.node.Info(); err == nil {
@@ -27,3 +34,36 @@ func (h *Host) State() HostState {
 	}
 	return state
 }
+
+// Health returns the state of the host as State, except that a running host
+// whose node reports its health is only considered to be running once
+// healthy, and is otherwise starting or unhealthy. As checking the health of
+// a node can take a while (e.g. pinging the inner engine of a site), it is
+// only done here rather than by State.
+func (h *Host) Health() HostState {
+	state := h.State()
+	if state != HostStateRunning {
+		return state
+	}
+	if hc, ok := h.node.(node.HealthChecker); ok {
+		switch hc.Health() {
+		case node.NodeHealthStarting:
+			return HostStateStarting
+		case node.NodeHealthUnhealthy:
+			return HostStateUnhealthy
+		}
+	}
+	return state
+}
+
+// Started returns true if the state is that of a host whose node has been
+// started, regardless of its health.
+func (s HostState) Started() bool {
+	return s == HostStateRunning || s == HostStateStarting || s == HostStateUnhealthy
+}
+
+// Exists returns true if the state is that of a host whose node exists, so
+// that its ports can be used.
+func (s HostState) Exists() bool {
+	return s == HostStateReady || s.Started()
+}
@@ -0,0 +1,68 @@
+package topology
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/ai4networks/net4me/pkg/node"
+)
+
+// healthNode is a fake node that reports its health.
+type healthNode struct {
+	*fakeNode
+	health node.NodeHealth
+	checks atomic.Int32
+}
+
+func (n *healthNode) Health() node.NodeHealth {
+	n.checks.Add(1)
+	return n.health
+}
+
+func TestHostHealth(t *testing.T) {
+	m := registerFakeManager("fake-health")
+	tests := []struct {
+		name       string
+		added      bool
+		running    bool
+		health     node.NodeHealth
+		wantState  HostState
+		wantHealth HostState
+		wantChecks int32
+	}{
+		{name: "removed", health: node.NodeHealthHealthy, wantState: HostStateRemoved, wantHealth: HostStateRemoved},
+		{name: "ready", added: true, health: node.NodeHealthUnhealthy, wantState: HostStateReady, wantHealth: HostStateReady},
+		{name: "healthy", added: true, running: true, health: node.NodeHealthHealthy, wantState: HostStateRunning, wantHealth: HostStateRunning, wantChecks: 1},
+		{name: "starting", added: true, running: true, health: node.NodeHealthStarting, wantState: HostStateRunning, wantHealth: HostStateStarting, wantChecks: 1},
+		{name: "unhealthy", added: true, running: true, health: node.NodeHealthUnhealthy, wantState: HostStateRunning, wantHealth: HostStateUnhealthy, wantChecks: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &healthNode{fakeNode: &fakeNode{id: tt.name, name: tt.name, manager: m}, health: tt.health}
+			n.running.Store(tt.running)
+			if tt.added {
+				m.lock.Lock()
+				m.nodes = append(m.nodes, n.fakeNode)
+				m.lock.Unlock()
+				defer m.Remove(n)
+			}
+			h := &Host{id: tt.name, name: tt.name, node: n}
+			if state := h.State(); state != tt.wantState {
+				t.Errorf("got state %s, want %s", state, tt.wantState)
+			}
+			if n.checks.Load() != 0 {
+				t.Error("state checked the health of the node")
+			}
+			health := h.Health()
+			if health != tt.wantHealth {
+				t.Errorf("got health %s, want %s", health, tt.wantHealth)
+			}
+			if checks := n.checks.Load(); checks != tt.wantChecks {
+				t.Errorf("health was checked %d times, want %d", checks, tt.wantChecks)
+			}
+			if !health.Exists() && tt.added {
+				t.Errorf("health %s of an added node does not exist", health)
+			}
+		})
+	}
+}
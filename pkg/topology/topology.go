@@ -1,67 +1,297 @@
 package topology
 
-import "github.com/segmentio/ksuid"
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
 
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/segmentio/ksuid"
+)
+
+// Topology is a set of hosts and the links between them. A topology is safe for
+// concurrent use. The package level functions operate on the default topology,
+// but further independent topologies can be created with New.
+//
+// Topologies are isolated from each other by name: the nodes of a topology are
+// labelled with its name (see node.TopologyLabel), only those nodes are loaded
+// into it, and its links are created in its own port pool.
 type Topology struct {
-	id    string
-	hosts []*Host
+	id   string
+	name string
+	// changes serialises Load with adding and removing hosts, so that hosts
+	// loaded from the managers do not overwrite concurrent changes.
+	changes sync.Mutex
+	lock    sync.RWMutex
+	hosts   []*Host
+	links   *linkCache
 }
 
 var (
-	topology *Topology = new()
+	topology *Topology = New(node.DefaultTopology)
+
+	registryLock sync.Mutex
+	registry     = map[string]*Topology{node.DefaultTopology: topology}
+
+	namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
 )
 
-func new() *Topology {
+// ValidateName checks that the name can be used for a topology. As the name is
+// used in labels, metric tags and the name of the port pool namespace, it must
+// be at most 32 lowercase alphanumeric characters, dashes or underscores.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid topology name %q: must match %s", name, namePattern.String())
+	}
+	return nil
+}
+
+// New creates an empty topology with the given name and a new ID. The topology
+// is not loaded, see Load.
+func New(name string) *Topology {
 	return &Topology{
 		id:    ksuid.New().String(),
+		name:  name,
 		hosts: make([]*Host, 0),
+		links: newLinkCache(),
 	}
 }
 
 func ID() string {
-	return topology.id
+	return GetTopology().ID()
 }
 
+// Name returns the name of the default topology. See Topology.Name.
+func Name() string {
+	return GetTopology().Name()
+}
+
+// GetTopology returns the default topology.
 func GetTopology() *Topology {
+	registryLock.Lock()
+	defer registryLock.Unlock()
 	return topology
 }
 
-// LoadTopology loads the topology (hosts and links) by querying the node
-// managers that have been registered and setup. This will generate new IDs for
-// all elements of the topology.
+// SetDefault makes the topology the default, which the package level
+// functions operate on, and registers it so that it can be opened by name.
+func SetDefault(t *Topology) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	topology = t
+	registry[t.name] = t
+}
+
+// Open returns the topology with the given name. A topology that has not been
+// opened before is created and loaded from the system.
+func Open(name string) (*Topology, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	if t := registered(name); t != nil {
+		return t, nil
+	}
+	t, err := load(name)
+	if err != nil {
+		return nil, err
+	}
+	return register(t), nil
+}
+
+// Lookup returns the topology with the given name, if it has been opened or
+// has hosts on the system, in which case it is opened. Unlike Open, a topology
+// that does not exist is not created.
+func Lookup(name string) (*Topology, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	if t := registered(name); t != nil {
+		return t, nil
+	}
+	t, err := load(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Hosts()) == 0 {
+		return nil, fmt.Errorf("topology not found: %s", name)
+	}
+	return register(t), nil
+}
+
+// registered returns the opened topology with the given name, or nil.
+func registered(name string) *Topology {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	return registry[name]
+}
+
+// load creates the topology with the given name and loads it from the system.
+// Loading queries the node managers, so is done without holding the registry
+// lock.
+func load(name string) (*Topology, error) {
+	t := New(name)
+	if err := t.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load topology %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// register adds the loaded topology to the registry, unless a concurrent Open
+// registered one first, in which case that topology is returned instead.
+func register(t *Topology) *Topology {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if existing, ok := registry[t.name]; ok {
+		return existing
+	}
+	registry[t.name] = t
+	return t
+}
+
+// Topologies returns the names of the topologies that have been opened.
+func Topologies() []string {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	return names
+}
+
+// LoadTopology loads the default topology from the system. See Topology.Load.
 func LoadTopology() error {
-	hosts, err := hosts()
+	return GetTopology().Load()
+}
+
+// Hosts returns the hosts of the default topology. The hosts can be filtered
+// by the provided filters.
+func Hosts(filters ...HostFilter) []*Host {
+	return GetTopology().Hosts(filters...)
+}
+
+// Links returns the links between the hosts of the default topology. Each link
+// is returned once, from the perspective of either of its hosts.
+func Links() []*Link {
+	return GetTopology().Links()
+}
+
+// DiscoverLinks returns the links between the hosts of the default topology,
+// without using cached links. See Topology.DiscoverLinks.
+func DiscoverLinks() []*Link {
+	return GetTopology().DiscoverLinks()
+}
+
+// EnableLinkCache enables or disables caching of discovered links in the
+// default topology. See Topology.EnableLinkCache.
+func EnableLinkCache(enabled bool) {
+	GetTopology().EnableLinkCache(enabled)
+}
+
+func (t *Topology) ID() string {
+	return t.id
+}
+
+// Name returns the name of the topology. Unlike the ID, the name is stable
+// across runs of net4me and so can be used to address the topology (e.g. in
+// DNS records and metrics).
+func (t *Topology) Name() string {
+	return t.name
+}
+
+// Load loads the topology (hosts and links) by querying the node managers that
+// have been registered and setup, replacing any hosts already in the topology.
+// This will generate new IDs for all elements of the topology. Hosts are not
+// added or removed while the managers are queried.
+func (t *Topology) Load() error {
+	t.changes.Lock()
+	defer t.changes.Unlock()
+	hosts, err := t.hostsFromManagers()
 	if err != nil {
 		return err
 	}
-	topology.hosts = hosts
+	t.lock.Lock()
+	t.hosts = hosts
+	t.lock.Unlock()
+	t.links.reset()
 	return nil
 }
 
-func Hosts() []*Host {
-	return topology.hosts
+// Hosts returns the hosts of the topology. The hosts can be filtered by the
+// provided filters. The returned slice is a copy, so the topology can be
+// changed while it is in use.
+func (t *Topology) Hosts(filters ...HostFilter) []*Host {
+	t.lock.RLock()
+	hosts := append([]*Host(nil), t.hosts...)
+	t.lock.RUnlock()
+	for _, filter := range filters {
+		hosts = filter(hosts)
+	}
+	return hosts
 }
 
-func Links() []*Link {
-	links := make([]*Link, 0)
-	for _, h := range topology.hosts {
-		l, err := h.Links()
-		if err != nil {
-			continue
-		}
-		for _, hostlink := range l {
-			duplicate := false
-			for _, link := range links {
-				if hostlink.SelfPort().Attrs().Index == link.PeerPort().Attrs().Index &&
-					hostlink.PeerPort().Attrs().Index == link.SelfPort().Attrs().Index {
-					duplicate = true
-				}
-			}
-			if !duplicate {
-				links = append(links, l...)
-			}
-		}
-
+// Links returns the links between the hosts of the topology, discovering them
+// unless they are cached. Each link is returned once, from the perspective of
+// either of its hosts.
+func (t *Topology) Links() []*Link {
+	links, ok, generation := t.links.get()
+	if ok {
+		return links
 	}
+	hosts := t.Hosts()
+	links = discoverLinks(hosts)
+	t.links.put(hosts, links, generation)
+	return links
+}
+
+// DiscoverLinks returns the links between the hosts of the topology as
+// Links, but always discovers them rather than using cached links, so that the
+// attributes of their ports (e.g. statistics) are current.
+func (t *Topology) DiscoverLinks() []*Link {
+	_, _, generation := t.links.get()
+	hosts := t.Hosts()
+	links := discoverLinks(hosts)
+	t.links.put(hosts, links, generation)
 	return links
 }
+
+// Pool returns the pool of the topology, in which the ports of its links are
+// created.
+func (t *Topology) Pool() *port.Pool {
+	return port.TopologyPool(t.name)
+}
+
+// EnableLinkCache enables or disables caching of discovered links. Cached
+// links are dropped whenever a link changes in the namespace of any host, but
+// the attributes of their ports (e.g. statistics and state) are those of when
+// the links were discovered. Use DiscoverLinks where they must be current.
+func (t *Topology) EnableLinkCache(enabled bool) {
+	t.links.setEnabled(enabled)
+}
+
+// add adds the host to the topology. A host of the same node, e.g. loaded by a
+// concurrent Load, is replaced.
+func (t *Topology) add(h *Host) {
+	t.changes.Lock()
+	defer t.changes.Unlock()
+	t.lock.Lock()
+	t.hosts = slices.DeleteFunc(t.hosts, h.sameNode)
+	t.hosts = append(t.hosts, h)
+	t.lock.Unlock()
+	t.links.invalidate()
+}
+
+// remove removes the host from the topology, if it is part of it, along with
+// any other host of the same node.
+func (t *Topology) remove(h *Host) {
+	t.changes.Lock()
+	defer t.changes.Unlock()
+	t.links.unwatch(h)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.hosts = slices.DeleteFunc(t.hosts, func(host *Host) bool {
+		return host.id == h.id || h.sameNode(host)
+	})
+}
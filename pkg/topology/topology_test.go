@@ -0,0 +1,267 @@
+package topology
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/node"
+)
+
+func hostNames(hosts []*Host) []string {
+	names := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		names = append(names, h.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TestConcurrentUse changes a topology from many goroutines while it is read
+// and reloaded, and is intended to be run with -race.
+func TestConcurrentUse(t *testing.T) {
+	// a slow listing of the nodes widens the window in which a load races
+	// with hosts being added and removed
+	registerFakeManager("fake-slow").listDelay = time.Millisecond
+	topo := New("concurrent")
+	const hosts = 16
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < hosts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h, err := topo.NewHost("fake-slow", fmt.Sprintf("concurrent-%d", i), map[string]string{"index": fmt.Sprint(i)}, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			// hosts loaded concurrently must not undo the change
+			if len(topo.Hosts(FilterByName(h.Name()))) != 1 {
+				t.Errorf("host %s is not in the topology once added", h.Name())
+			}
+			if err := h.Start(); err != nil {
+				t.Error(err)
+			}
+			if i%2 == 0 {
+				if err := h.Remove(); err != nil {
+					t.Error(err)
+				}
+				if len(topo.Hosts(FilterByName(h.Name()))) != 0 {
+					t.Errorf("host %s is in the topology once removed", h.Name())
+				}
+			}
+		}(i)
+	}
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func(i int) {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for _, h := range topo.Hosts(FilterByDevice("fake-slow")) {
+					h.State()
+					h.Labels()
+				}
+				topo.Links()
+				if i == 0 {
+					if err := topo.Load(); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	want := make([]string, 0)
+	for i := 1; i < hosts; i += 2 {
+		want = append(want, fmt.Sprintf("concurrent-%d", i))
+	}
+	sort.Strings(want)
+	if got := hostNames(topo.Hosts()); !slices.Equal(got, want) {
+		t.Fatalf("got hosts %v, want %v", got, want)
+	}
+	if err := topo.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := hostNames(topo.Hosts()); !slices.Equal(got, want) {
+		t.Fatalf("got hosts %v after load, want %v", got, want)
+	}
+	for _, h := range topo.Hosts() {
+		if h.State() != HostStateRunning {
+			t.Errorf("host %s is %s", h.Name(), h.State())
+		}
+		if err := h.Remove(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	registerFakeManager("fake")
+	registerFakeManager("fake-switch")
+	topo := New("selector")
+	for _, spec := range []HostSpec{
+		{Device: "fake", Name: "selector-site-1", Labels: map[string]string{"region": "eu"}},
+		{Device: "fake", Name: "selector-site-2", Labels: map[string]string{"region": "us"}},
+		{Device: "fake-switch", Name: "selector-switch", Labels: map[string]string{"region": "eu"}},
+	} {
+		h, err := topo.NewHost(spec.Device, spec.Name, spec.Labels, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer h.Remove()
+	}
+	tests := []struct {
+		selector string
+		want     []string
+		wantErr  bool
+	}{
+		{selector: "", want: []string{"selector-site-1", "selector-site-2", "selector-switch"}},
+		{selector: "device=fake", want: []string{"selector-site-1", "selector-site-2"}},
+		{selector: "name=selector-site-*", want: []string{"selector-site-1", "selector-site-2"}},
+		{selector: "region=eu", want: []string{"selector-site-1", "selector-switch"}},
+		{selector: " device=fake , region=eu ", want: []string{"selector-site-1"}},
+		{selector: "name=*-2,region=eu", want: []string{}},
+		{selector: "region=", want: []string{}},
+		{selector: "net4me.topology=selector,device=fake-switch", want: []string{"selector-switch"}},
+		{selector: "region", wantErr: true},
+		{selector: "=eu", wantErr: true},
+		{selector: "name=[", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			filters, err := ParseSelector(tt.selector)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hostNames(topo.Hosts(filters...)); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameCollision(t *testing.T) {
+	registerFakeManager("fake")
+	registerFakeManager("fake-switch")
+	a, b := New("collision-a"), New("collision-b")
+	h, err := a.NewHost("fake", "collision", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Remove()
+	tests := []struct {
+		name     string
+		topology *Topology
+		device   string
+		wantErr  string
+	}{
+		{name: "other topology", topology: b, device: "fake", wantErr: "already used by a fake node of topology collision-a"},
+		{name: "same topology", topology: a, device: "fake", wantErr: "already exists in the topology"},
+		{name: "other device", topology: b, device: "fake-switch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := tt.topology.NewHost(tt.device, "collision", nil, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				h.Remove()
+				return
+			}
+			if err == nil {
+				h.Remove()
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %q, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	registerFakeManager("fake")
+	if _, err := Open("Invalid Name"); err == nil {
+		t.Fatal("expected an error for an invalid name")
+	}
+	opened := make([]*Topology, 8)
+	var wg sync.WaitGroup
+	for i := range opened {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			topo, err := Open("opened")
+			if err != nil {
+				t.Error(err)
+			}
+			opened[i] = topo
+		}(i)
+	}
+	wg.Wait()
+	for _, topo := range opened {
+		if topo != opened[0] {
+			t.Fatal("concurrent opens returned different topologies")
+		}
+	}
+	if !slices.Contains(Topologies(), "opened") {
+		t.Fatalf("opened topology is not registered: %v", Topologies())
+	}
+}
+
+func TestLookup(t *testing.T) {
+	m := registerFakeManager("fake")
+	n, err := m.Add("lookup-node", map[string]string{node.TopologyLabel: "lookup-existing"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Remove(n)
+	if _, err := Open("lookup-opened"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		topology   string
+		wantErr    bool
+		registered bool
+	}{
+		{name: "opened", topology: "lookup-opened", registered: true},
+		{name: "has nodes", topology: "lookup-existing", registered: true},
+		{name: "unknown", topology: "lookup-unknown", wantErr: true},
+		{name: "invalid", topology: "Lookup Unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topo, err := Lookup(tt.topology)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && topo.Name() != tt.topology {
+				t.Errorf("got topology %s, want %s", topo.Name(), tt.topology)
+			}
+			if got := slices.Contains(Topologies(), tt.topology); got != tt.registered {
+				t.Errorf("registered = %v, want %v", got, tt.registered)
+			}
+		})
+	}
+}
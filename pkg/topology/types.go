@@ -1,6 +1,7 @@
 package topology
 
 import (
+	"maps"
 	"time"
 
 	"github.com/ai4networks/net4me/pkg/node"
@@ -47,14 +48,21 @@ func (h *Host) UpdatedAt() time.Time {
 	return h.updatedAt
 }
 
+// Labels returns a copy of the labels of the host.
 func (h *Host) Labels() map[string]string {
-	return h.labels
+	return maps.Clone(h.labels)
 }
 
 func (h *Host) Node() node.Node {
 	return h.node
 }
 
+// sameNode returns true if the hosts are of the same node, as hosts are
+// recreated with new IDs when a topology is loaded.
+func (h *Host) sameNode(other *Host) bool {
+	return h.node.Device() == other.node.Device() && h.node.ID() == other.node.ID()
+}
+
 func (l *Link) SelfHost() *Host {
 	return l.self.host
 }
@@ -0,0 +1,281 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/neaas/neslink"
+)
+
+const (
+	ProtocolTCP = "tcp"
+	ProtocolUDP = "udp"
+)
+
+const (
+	// DefaultPort is the port the receiver listens on when none is given.
+	DefaultPort = 5201
+	// DefaultUDPBitrate is the bitrate (bits/s) of UDP flows when none is
+	// given, as UDP flows are not congestion controlled.
+	DefaultUDPBitrate = 1000000
+	// DefaultPacketSize is the payload size of UDP datagrams when none is
+	// given.
+	DefaultPacketSize = 1400
+	// tcpBufferSize is the size of each write made by TCP senders.
+	tcpBufferSize = 128 * 1024
+)
+
+// Options configures a traffic flow between two hosts.
+type Options struct {
+	// Protocol is the transport protocol of the flow, either tcp or udp.
+	Protocol string
+	// Address is the address of the target host that traffic is sent to. The
+	// first address of the target is used if empty.
+	Address string
+	// Port is the port the receiver listens on.
+	Port int
+	// Bitrate is the target bitrate (bits/s) of the flow, shared evenly across
+	// the parallel streams. Zero means unlimited for TCP flows.
+	Bitrate uint64
+	// Duration is how long the flow runs for. Zero runs the flow until the
+	// context given to Run is cancelled, for use as background load.
+	Duration time.Duration
+	// Parallel is the number of concurrent streams in the flow.
+	Parallel int
+	// DSCP is the differentiated services code point marked on sent packets.
+	DSCP int
+	// PacketSize is the payload size of UDP datagrams.
+	PacketSize int
+}
+
+// Result holds the measurements of a completed flow. Throughput is measured at
+// the receiver, with jitter and loss only being measured for UDP flows.
+type Result struct {
+	Source   string        `json:"source"`
+	Target   string        `json:"target"`
+	Address  string        `json:"address"`
+	Protocol string        `json:"protocol"`
+	Streams  int           `json:"streams"`
+	DSCP     int           `json:"dscp"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+
+	BytesSent     uint64  `json:"bytes_sent"`
+	BytesReceived uint64  `json:"bytes_received"`
+	Throughput    float64 `json:"throughput"`
+
+	PacketsSent     uint64        `json:"packets_sent,omitempty"`
+	PacketsReceived uint64        `json:"packets_received,omitempty"`
+	Loss            float64       `json:"loss,omitempty"`
+	Jitter          time.Duration `json:"jitter,omitempty"`
+}
+
+// receiver is the server end of a flow, running in the target namespace.
+type receiver interface {
+	close() error
+	// stats returns the bytes and packets received, along with the jitter
+	// (UDP only).
+	stats() (uint64, uint64, time.Duration)
+}
+
+// sender is the client end of a single stream, running in the source
+// namespace.
+type sender interface {
+	close() error
+	// send transmits until the context is done, pacing to the given bitrate,
+	// returning the bytes and packets sent.
+	send(ctx context.Context, bitrate uint64) (uint64, uint64)
+}
+
+// applyDefaults fills unset options and checks the remaining options are
+// valid.
+func (o *Options) applyDefaults() error {
+	switch o.Protocol {
+	case "":
+		o.Protocol = ProtocolTCP
+	case ProtocolTCP, ProtocolUDP:
+	default:
+		return fmt.Errorf("unknown protocol %s", o.Protocol)
+	}
+	if o.Port == 0 {
+		o.Port = DefaultPort
+	}
+	if o.Parallel <= 0 {
+		o.Parallel = 1
+	}
+	if o.Protocol == ProtocolUDP && o.Bitrate == 0 {
+		o.Bitrate = DefaultUDPBitrate
+	}
+	if o.PacketSize <= 0 {
+		o.PacketSize = DefaultPacketSize
+	}
+	if o.PacketSize < udpHeaderSize {
+		return fmt.Errorf("packet size must be at least %d bytes", udpHeaderSize)
+	}
+	if o.DSCP < 0 || o.DSCP > 63 {
+		return fmt.Errorf("dscp must be between 0 and 63")
+	}
+	return nil
+}
+
+// Run sends traffic from the source host to the target host, returning the
+// measured result once the flow's duration has elapsed or the context is
+// cancelled. The receiver and senders are run by this process, with their
+// sockets being opened within the network namespaces of the hosts.
+func Run(ctx context.Context, source, target *topology.Host, options Options) (*Result, error) {
+	if err := options.applyDefaults(); err != nil {
+		return nil, err
+	}
+	if options.Address == "" {
+		addresses, err := target.Addresses()
+		if err != nil {
+			return nil, fmt.Errorf("could not get addresses of host %s: %w", target.Name(), err)
+		}
+		if len(addresses) == 0 {
+			return nil, fmt.Errorf("host %s has no addresses", target.Name())
+		}
+		ip, _, _ := net.ParseCIDR(addresses[0])
+		options.Address = ip.String()
+	}
+	address := net.JoinHostPort(options.Address, strconv.Itoa(options.Port))
+
+	var recv receiver
+	if err := neslink.Do(
+		target.NetworkNamespace(),
+		neslink.NAGeneric("traffic-listen", func() error {
+			var err error
+			if options.Protocol == ProtocolUDP {
+				recv, err = listenUDP(options.Port, options.PacketSize)
+			} else {
+				recv, err = listenTCP(options.Port)
+			}
+			return err
+		}),
+	); err != nil {
+		return nil, fmt.Errorf("could not start receiver on host %s: %w", target.Name(), err)
+	}
+	defer recv.close()
+
+	senders := make([]sender, 0, options.Parallel)
+	defer func() {
+		for _, s := range senders {
+			s.close()
+		}
+	}()
+	if err := neslink.Do(
+		source.NetworkNamespace(),
+		neslink.NAGeneric("traffic-dial", func() error {
+			for i := 0; i < options.Parallel; i++ {
+				var s sender
+				var err error
+				if options.Protocol == ProtocolUDP {
+					s, err = dialUDP(address, options.DSCP, uint32(i), options.PacketSize)
+				} else {
+					s, err = dialTCP(address, options.DSCP)
+				}
+				if err != nil {
+					return err
+				}
+				senders = append(senders, s)
+			}
+			return nil
+		}),
+	); err != nil {
+		return nil, fmt.Errorf("could not start sender on host %s: %w", source.Name(), err)
+	}
+
+	if options.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Duration)
+		defer cancel()
+	}
+	result := &Result{
+		Source:   source.Name(),
+		Target:   target.Name(),
+		Address:  address,
+		Protocol: options.Protocol,
+		Streams:  options.Parallel,
+		DSCP:     options.DSCP,
+		Start:    time.Now(),
+	}
+	lock := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for _, s := range senders {
+		wg.Add(1)
+		go func(s sender) {
+			defer wg.Done()
+			bytes, packets := s.send(ctx, options.Bitrate/uint64(options.Parallel))
+			lock.Lock()
+			defer lock.Unlock()
+			result.BytesSent += bytes
+			result.PacketsSent += packets
+		}(s)
+	}
+	wg.Wait()
+	result.Duration = time.Since(result.Start)
+
+	// allow in-flight data to arrive before collecting the receiver stats
+	for _, s := range senders {
+		s.close()
+	}
+	time.Sleep(drainPeriod)
+	result.BytesReceived, result.PacketsReceived, result.Jitter = recv.stats()
+	result.Throughput = float64(result.BytesReceived*8) / result.Duration.Seconds()
+	if options.Protocol == ProtocolUDP && result.PacketsSent > 0 {
+		received := min(result.PacketsReceived, result.PacketsSent)
+		result.Loss = float64(result.PacketsSent-received) / float64(result.PacketsSent) * 100
+	}
+	return result, nil
+}
+
+// drainPeriod is how long to wait after the senders stop for packets still in
+// flight (e.g. delayed by emulated latency) to reach the receiver.
+const drainPeriod = 500 * time.Millisecond
+
+// pace blocks until the given number of bytes is due to have been sent at the
+// given bitrate since start. It returns false if the context is done first.
+func pace(ctx context.Context, start time.Time, sent, bitrate uint64) bool {
+	if bitrate == 0 {
+		return ctx.Err() == nil
+	}
+	due := start.Add(time.Duration(float64(sent*8) / float64(bitrate) * float64(time.Second)))
+	wait := time.Until(due)
+	if wait <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(wait):
+		return true
+	}
+}
+
+// ParseBitrate parses a bitrate in bits/s with an optional K, M or G suffix
+// (powers of 1000), e.g. `10M`.
+func ParseBitrate(s string) (uint64, error) {
+	multiplier := uint64(1)
+	if len(s) > 0 {
+		switch s[len(s)-1] {
+		case 'k', 'K':
+			multiplier = 1000
+		case 'm', 'M':
+			multiplier = 1000000
+		case 'g', 'G':
+			multiplier = 1000000000
+		}
+		if multiplier > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid bitrate %q", s)
+	}
+	return uint64(value * float64(multiplier)), nil
+}
@@ -0,0 +1,134 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseBitrate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint64
+		wantErr bool
+	}{
+		{in: "0", want: 0},
+		{in: "1500", want: 1500},
+		{in: "10k", want: 10000},
+		{in: "10K", want: 10000},
+		{in: "1.5M", want: 1500000},
+		{in: "100m", want: 100000000},
+		{in: "2G", want: 2000000000},
+		{in: "", wantErr: true},
+		{in: "M", wantErr: true},
+		{in: "-1M", wantErr: true},
+		{in: "10Mb", wantErr: true},
+		{in: "fast", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseBitrate(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		check   func(Options) error
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			check: func(o Options) error {
+				if o.Protocol != ProtocolTCP || o.Port != DefaultPort || o.Parallel != 1 || o.PacketSize != DefaultPacketSize || o.Bitrate != 0 {
+					return fmt.Errorf("unexpected defaults %+v", o)
+				}
+				return nil
+			},
+		},
+		{
+			name:    "udp is paced by default",
+			options: Options{Protocol: ProtocolUDP},
+			check: func(o Options) error {
+				if o.Bitrate != DefaultUDPBitrate {
+					return fmt.Errorf("got bitrate %d, want %d", o.Bitrate, DefaultUDPBitrate)
+				}
+				return nil
+			},
+		},
+		{name: "unknown protocol", options: Options{Protocol: "sctp"}, wantErr: true},
+		{name: "packet too small", options: Options{PacketSize: udpHeaderSize - 1}, wantErr: true},
+		{name: "dscp out of range", options: Options{DSCP: 64}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := tt.options
+			err := o.applyDefaults()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.check(o); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestUDPLoopback(t *testing.T) {
+	r, err := listenUDP(0, DefaultPacketSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.close()
+	s, err := dialUDP(r.conn.LocalAddr().(*net.UDPAddr).AddrPort().String(), 0, 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.close()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	sent, packets := s.send(ctx, 400000)
+	if sent != packets*100 {
+		t.Fatalf("sent %d bytes in %d packets of 100 bytes", sent, packets)
+	}
+	// 400kbit/s for 200ms is 10kB, and pacing must not send much more
+	if sent == 0 || sent > 12000 {
+		t.Fatalf("sent %d bytes, want about 10000", sent)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		received, receivedPackets, _ := r.stats()
+		if receivedPackets == packets {
+			if received != sent {
+				t.Fatalf("received %d bytes, sent %d", received, sent)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("received %d of %d packets", receivedPackets, packets)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,31 @@
+package traffic
+
+import (
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// markControl returns a socket control function that sets the DSCP of all
+// packets sent from the socket. The DSCP occupies the upper 6 bits of the IPv4
+// TOS field or the IPv6 traffic class.
+func markControl(dscp int) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		if dscp == 0 {
+			return nil
+		}
+		var serr error
+		if err := c.Control(func(fd uintptr) {
+			ip, _, _ := net.SplitHostPort(address)
+			if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+				serr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_TCLASS, dscp<<2)
+			} else {
+				serr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_TOS, dscp<<2)
+			}
+		}); err != nil {
+			return err
+		}
+		return serr
+	}
+}
@@ -0,0 +1,98 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type tcpReceiver struct {
+	listener net.Listener
+	received atomic.Uint64
+	lock     *sync.Mutex
+	conns    []net.Conn
+}
+
+func listenTCP(port int) (*tcpReceiver, error) {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, err
+	}
+	r := &tcpReceiver{listener: l, lock: &sync.Mutex{}}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r.lock.Lock()
+			r.conns = append(r.conns, conn)
+			r.lock.Unlock()
+			go func() {
+				buf := make([]byte, tcpBufferSize)
+				for {
+					n, err := conn.Read(buf)
+					r.received.Add(uint64(n))
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return r, nil
+}
+
+func (r *tcpReceiver) close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, conn := range r.conns {
+		conn.Close()
+	}
+	return r.listener.Close()
+}
+
+func (r *tcpReceiver) stats() (uint64, uint64, time.Duration) {
+	return r.received.Load(), 0, 0
+}
+
+type tcpSender struct {
+	conn net.Conn
+}
+
+func dialTCP(address string, dscp int) (*tcpSender, error) {
+	dialer := net.Dialer{Control: markControl(dscp), Timeout: 5 * time.Second}
+	conn, err := dialer.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpSender{conn: conn}, nil
+}
+
+func (s *tcpSender) close() error {
+	return s.conn.Close()
+}
+
+func (s *tcpSender) send(ctx context.Context, bitrate uint64) (uint64, uint64) {
+	// unblock any pending write once the flow is over
+	stop := context.AfterFunc(ctx, func() { s.conn.SetWriteDeadline(time.Now()) })
+	defer stop()
+	buf := make([]byte, tcpBufferSize)
+	chunk := len(buf)
+	if bitrate > 0 {
+		// keep writes small enough that pacing stays smooth at low bitrates
+		chunk = int(min(uint64(len(buf)), max(bitrate/8/100, 1)))
+	}
+	start, sent := time.Now(), uint64(0)
+	for pace(ctx, start, sent, bitrate) {
+		n, err := s.conn.Write(buf[:chunk])
+		sent += uint64(n)
+		if err != nil {
+			break
+		}
+	}
+	return sent, 0
+}
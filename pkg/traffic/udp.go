@@ -0,0 +1,130 @@
+package traffic
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// udpHeaderSize is the size of the header at the start of each datagram,
+// holding the stream ID (4 bytes), sequence number (8 bytes) and the send
+// time in unix nanoseconds (8 bytes).
+const udpHeaderSize = 20
+
+// udpStream is the receiver state of a single stream, used to compute jitter
+// as described in RFC 3550.
+type udpStream struct {
+	transit time.Duration
+	jitter  float64
+}
+
+type udpReceiver struct {
+	conn net.PacketConn
+
+	lock    *sync.Mutex
+	bytes   uint64
+	packets uint64
+	streams map[uint32]*udpStream
+}
+
+func listenUDP(port, packetSize int) (*udpReceiver, error) {
+	conn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, err
+	}
+	r := &udpReceiver{
+		conn:    conn,
+		lock:    &sync.Mutex{},
+		streams: make(map[uint32]*udpStream),
+	}
+	go func() {
+		buf := make([]byte, packetSize)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < udpHeaderSize {
+				continue
+			}
+			r.record(n, binary.BigEndian.Uint32(buf[0:]), time.Unix(0, int64(binary.BigEndian.Uint64(buf[12:]))))
+		}
+	}()
+	return r, nil
+}
+
+// record accounts for a received datagram. Senders and receiver share the
+// same clock, so the transit time is the one-way delay of the datagram.
+func (r *udpReceiver) record(size int, id uint32, sentAt time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.bytes += uint64(size)
+	r.packets++
+	transit := time.Since(sentAt)
+	stream, ok := r.streams[id]
+	if !ok {
+		r.streams[id] = &udpStream{transit: transit}
+		return
+	}
+	d := transit - stream.transit
+	if d < 0 {
+		d = -d
+	}
+	stream.jitter += (float64(d) - stream.jitter) / 16
+	stream.transit = transit
+}
+
+func (r *udpReceiver) close() error {
+	return r.conn.Close()
+}
+
+func (r *udpReceiver) stats() (uint64, uint64, time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	jitter := float64(0)
+	for _, s := range r.streams {
+		jitter += s.jitter
+	}
+	if len(r.streams) > 0 {
+		jitter /= float64(len(r.streams))
+	}
+	return r.bytes, r.packets, time.Duration(jitter)
+}
+
+type udpSender struct {
+	conn       net.Conn
+	id         uint32
+	packetSize int
+}
+
+func dialUDP(address string, dscp int, id uint32, packetSize int) (*udpSender, error) {
+	dialer := net.Dialer{Control: markControl(dscp)}
+	conn, err := dialer.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &udpSender{conn: conn, id: id, packetSize: packetSize}, nil
+}
+
+func (s *udpSender) close() error {
+	return s.conn.Close()
+}
+
+func (s *udpSender) send(ctx context.Context, bitrate uint64) (uint64, uint64) {
+	buf := make([]byte, s.packetSize)
+	binary.BigEndian.PutUint32(buf[0:], s.id)
+	start, sent, seq := time.Now(), uint64(0), uint64(0)
+	for pace(ctx, start, sent, bitrate) {
+		binary.BigEndian.PutUint64(buf[4:], seq)
+		binary.BigEndian.PutUint64(buf[12:], uint64(time.Now().UnixNano()))
+		// datagrams refused by the receiver (e.g. icmp unreachable) are
+		// counted as sent and so contribute to the measured loss
+		s.conn.Write(buf)
+		sent += uint64(len(buf))
+		seq++
+	}
+	return sent, seq
+}
@@ -0,0 +1,167 @@
+package transfer
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Tar streams a tar archive of the file or directory at src. Within the
+// archive, src is given the path name, and everything beneath it is stored
+// relative to that. If name is empty, src itself is omitted and its contents
+// are stored at the root of the archive. Symlinks are stored as links rather
+// than followed.
+func Tar(src, name string) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(writer)
+		err := filepath.Walk(src, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(src, file)
+			if err != nil {
+				return err
+			}
+			entry := path.Join(name, filepath.ToSlash(rel))
+			if entry == "." || entry == "" {
+				return nil
+			}
+			link := ""
+			if info.Mode()&os.ModeSymlink != 0 {
+				if link, err = os.Readlink(file); err != nil {
+					return err
+				}
+			}
+			header, err := tar.FileInfoHeader(info, link)
+			if err != nil {
+				return err
+			}
+			header.Name = entry
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err == nil {
+			err = tw.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+	return reader
+}
+
+// Untar extracts a tar archive into the dst directory, which is created if
+// needed. Entries that would be extracted outside of dst are rejected, either
+// by their path or by a symlink on the way to them, so symlinks are never
+// followed during extraction. Relative symlinks must point within dst, while
+// absolute symlinks (e.g. to /etc) are kept as they are, as they refer to the
+// system the files are used on. Directories, regular files and symlinks are
+// extracted, with all other entry types being skipped.
+func Untar(r io.Reader, dst string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, filepath.FromSlash(header.Name))
+		rel, err := filepath.Rel(dst, target)
+		if err != nil || !within(rel) {
+			return fmt.Errorf("archive entry %s is outside of the destination", header.Name)
+		}
+		if rel == "." {
+			continue
+		}
+		if err := checkParents(dst, rel); err != nil {
+			return fmt.Errorf("archive entry %s: %w", header.Name, err)
+		}
+		mode := os.FileMode(header.Mode).Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			// an existing symlink is replaced rather than written through
+			if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				if err := os.Remove(target); err != nil {
+					return err
+				}
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|syscall.O_NOFOLLOW, mode)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			link := filepath.FromSlash(header.Linkname)
+			if !filepath.IsAbs(link) && !within(filepath.Join(filepath.Dir(rel), link)) {
+				return fmt.Errorf("archive entry %s links outside of the destination", header.Name)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// within returns true if the cleaned relative path does not leave its base.
+func within(rel string) bool {
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// checkParents returns an error if any parent directory of the relative path
+// within dst is a symlink, as extracting through it could write outside dst.
+func checkParents(dst, rel string) error {
+	parent := dst
+	parts := strings.Split(filepath.Dir(rel), string(filepath.Separator))
+	for _, part := range parts {
+		if part == "." {
+			continue
+		}
+		parent = filepath.Join(parent, part)
+		info, err := os.Lstat(parent)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("path goes through symlink %s", parent)
+		}
+	}
+	return nil
+}
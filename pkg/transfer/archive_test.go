@@ -0,0 +1,192 @@
+package transfer
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type entry struct {
+	name     string
+	typeflag byte
+	body     string
+	linkname string
+}
+
+func archive(t *testing.T, entries ...entry) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		header := &tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Mode:     0644,
+			Size:     int64(len(e.body)),
+			Linkname: e.linkname,
+		}
+		if e.typeflag == tar.TypeDir {
+			header.Mode = 0755
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestUntar(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+		wantErr bool
+		// files that must exist with the given content after extraction
+		files map[string]string
+		// symlinks that must exist with the given target after extraction
+		links map[string]string
+	}{
+		{
+			name: "files and directories",
+			entries: []entry{
+				{name: "dir/", typeflag: tar.TypeDir},
+				{name: "dir/a.txt", typeflag: tar.TypeReg, body: "a"},
+				{name: "b/c.txt", typeflag: tar.TypeReg, body: "c"},
+				{name: "dir/link", typeflag: tar.TypeSymlink, linkname: "a.txt"},
+			},
+			files: map[string]string{"dir/a.txt": "a", "b/c.txt": "c", "dir/link": "a"},
+		},
+		{
+			name:    "parent path",
+			entries: []entry{{name: "../escape", typeflag: tar.TypeReg, body: "x"}},
+			wantErr: true,
+		},
+		{
+			name:    "nested parent path",
+			entries: []entry{{name: "dir/../../escape", typeflag: tar.TypeReg, body: "x"}},
+			wantErr: true,
+		},
+		{
+			name:    "absolute symlink",
+			entries: []entry{{name: "link", typeflag: tar.TypeSymlink, linkname: "/etc"}},
+			links:   map[string]string{"link": "/etc"},
+		},
+		{
+			name: "write through absolute symlink",
+			entries: []entry{
+				{name: "link", typeflag: tar.TypeSymlink, linkname: "/etc"},
+				{name: "link/cron.d/x", typeflag: tar.TypeReg, body: "x"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "relative symlink outside",
+			entries: []entry{{name: "dir/link", typeflag: tar.TypeSymlink, linkname: "../../etc"}},
+			wantErr: true,
+		},
+		{
+			name: "write through symlink",
+			entries: []entry{
+				{name: "sub/", typeflag: tar.TypeDir},
+				{name: "link", typeflag: tar.TypeSymlink, linkname: "sub"},
+				{name: "link/cron.d/x", typeflag: tar.TypeReg, body: "x"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "dst")
+			err := Untar(archive(t, tt.entries...), dst)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tt.files {
+				got, err := os.ReadFile(filepath.Join(dst, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != want {
+					t.Fatalf("%s: got %q, want %q", name, got, want)
+				}
+			}
+			for name, want := range tt.links {
+				got, err := os.Readlink(filepath.Join(dst, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Fatalf("%s: got link to %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUntarReplacesExistingSymlink(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(dir, "outside")
+	if err := os.WriteFile(outside, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "dst")
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dst, "file")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Untar(archive(t, entry{name: "file", typeflag: tar.TypeReg, body: "new"}), dst); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(outside); string(got) != "original" {
+		t.Fatalf("file outside of the destination was written: %q", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dst, "file")); string(got) != "new" {
+		t.Fatalf("got %q, want %q", got, "new")
+	}
+}
+
+func TestTarRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "a", "b"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a", "b", "file"), []byte("content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("b/file", filepath.Join(src, "a", "link")); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"", "project"} {
+		dst := t.TempDir()
+		r := Tar(src, name)
+		err := Untar(r, dst)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		base := filepath.Join(dst, name)
+		for _, file := range []string{"a/b/file", "a/link"} {
+			if got, err := os.ReadFile(filepath.Join(base, file)); err != nil || string(got) != "content" {
+				t.Fatalf("name %q, %s: got %q (%v)", name, file, got, err)
+			}
+		}
+		if info, err := os.Stat(filepath.Join(base, "a/b/file")); err != nil || info.Mode().Perm() != 0600 {
+			t.Fatalf("name %q: mode not preserved (%v)", name, err)
+		}
+	}
+}
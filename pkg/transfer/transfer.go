@@ -0,0 +1,46 @@
+package transfer
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ai4networks/net4me/pkg/node"
+)
+
+// CopyTo copies the file or directory at the local src path into the dst
+// directory on the node (or a container within it). The dst directory, and
+// any missing parents, are created.
+func CopyTo(fc node.FileCopier, container, src, dst string) error {
+	if !path.IsAbs(dst) {
+		return fmt.Errorf("destination must be an absolute path: %s", dst)
+	}
+	src, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	// entries are named by their full destination path and extracted at the
+	// root, so the destination directory is created as part of extraction
+	archive := Tar(src, path.Join(strings.TrimPrefix(path.Clean(dst), "/"), filepath.Base(src)))
+	defer archive.Close()
+	if err := fc.ArchiveTo(container, "/", archive); err != nil {
+		return fmt.Errorf("could not copy %s to %s: %w", src, dst, err)
+	}
+	return nil
+}
+
+// CopyFrom copies the file or directory at the src path on the node (or a
+// container within it) into the local dst directory, which is created if
+// needed.
+func CopyFrom(fc node.FileCopier, container, src, dst string) error {
+	archive, err := fc.ArchiveFrom(container, src)
+	if err != nil {
+		return fmt.Errorf("could not copy %s: %w", src, err)
+	}
+	defer archive.Close()
+	if err := Untar(archive, dst); err != nil {
+		return fmt.Errorf("could not extract %s to %s: %w", src, dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package verify
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/neaas/neslink"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// ProbeResult holds the measurements from probing a single address.
+type ProbeResult struct {
+	Sent     int             `json:"sent"`
+	Received int             `json:"received"`
+	RTTs     []time.Duration `json:"-"`
+}
+
+// Loss returns the percentage of probes that did not receive a reply.
+func (r ProbeResult) Loss() float64 {
+	if r.Sent == 0 {
+		return 0
+	}
+	return float64(r.Sent-r.Received) / float64(r.Sent) * 100
+}
+
+// RTT returns the minimum, average and maximum round trip times of the
+// probes that received a reply.
+func (r ProbeResult) RTT() (time.Duration, time.Duration, time.Duration) {
+	if len(r.RTTs) == 0 {
+		return 0, 0, 0
+	}
+	minRTT, maxRTT, total := r.RTTs[0], r.RTTs[0], time.Duration(0)
+	for _, rtt := range r.RTTs {
+		minRTT = min(minRTT, rtt)
+		maxRTT = max(maxRTT, rtt)
+		total += rtt
+	}
+	return minRTT, total / time.Duration(len(r.RTTs)), maxRTT
+}
+
+// ProbeOptions configures how many probes are sent and how long to wait for
+// each reply.
+type ProbeOptions struct {
+	Count    int
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// ProbeICMP sends ICMP echo requests to the target address from within the
+// given namespace, returning the replies received. The raw socket is opened
+// within the namespace, so requires the same privileges as net4me itself.
+func ProbeICMP(nsp neslink.NsProvider, target net.IP, options ProbeOptions) (ProbeResult, error) {
+	network, address, proto := "ip4:icmp", "0.0.0.0", 1
+	var requestType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	if target.To4() == nil {
+		network, address, proto = "ip6:ipv6-icmp", "::", 58
+		requestType, replyType = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	}
+	var conn *icmp.PacketConn
+	if err := neslink.Do(
+		nsp,
+		neslink.NAGeneric("open-icmp-socket", func() error {
+			c, err := icmp.ListenPacket(network, address)
+			if err != nil {
+				return err
+			}
+			conn = c
+			return nil
+		}),
+	); err != nil {
+		return ProbeResult{}, fmt.Errorf("could not open icmp socket: %w", err)
+	}
+	defer conn.Close()
+
+	id := rand.Intn(0xffff)
+	result := ProbeResult{}
+	buf := make([]byte, 1500)
+	for seq := 0; seq < options.Count; seq++ {
+		request := icmp.Message{
+			Type: requestType,
+			Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("net4me-verify")},
+		}
+		payload, err := request.Marshal(nil)
+		if err != nil {
+			return result, err
+		}
+		sentAt := time.Now()
+		if _, err := conn.WriteTo(payload, &net.IPAddr{IP: target}); err != nil {
+			result.Sent++
+			time.Sleep(options.Interval)
+			continue
+		}
+		result.Sent++
+		conn.SetReadDeadline(sentAt.Add(options.Timeout))
+		for {
+			n, peer, err := conn.ReadFrom(buf)
+			if err != nil {
+				break
+			}
+			reply, err := icmp.ParseMessage(proto, buf[:n])
+			if err != nil || reply.Type != replyType {
+				continue
+			}
+			echo, ok := reply.Body.(*icmp.Echo)
+			if !ok || echo.ID != id || echo.Seq != seq || !peer.(*net.IPAddr).IP.Equal(target) {
+				continue
+			}
+			result.Received++
+			result.RTTs = append(result.RTTs, time.Since(sentAt))
+			break
+		}
+		if wait := options.Interval - time.Since(sentAt); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	return result, nil
+}
+
+// ProbeUDP sends UDP probes to the target address and port from within the
+// given namespace, returning the echoed replies received. The target must be
+// running a UDP echo responder (see Responder).
+func ProbeUDP(nsp neslink.NsProvider, target net.IP, udpPort int, options ProbeOptions) (ProbeResult, error) {
+	var conn *net.UDPConn
+	if err := neslink.Do(
+		nsp,
+		neslink.NAGeneric("open-udp-socket", func() error {
+			c, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: target, Port: udpPort})
+			if err != nil {
+				return err
+			}
+			conn = c
+			return nil
+		}),
+	); err != nil {
+		return ProbeResult{}, fmt.Errorf("could not open udp socket: %w", err)
+	}
+	defer conn.Close()
+
+	id := rand.Uint32()
+	result := ProbeResult{}
+	buf := make([]byte, 64)
+	for seq := uint32(0); seq < uint32(options.Count); seq++ {
+		payload := binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, id), seq)
+		sentAt := time.Now()
+		result.Sent++
+		if _, err := conn.Write(payload); err == nil {
+			conn.SetReadDeadline(sentAt.Add(options.Timeout))
+			for {
+				n, err := conn.Read(buf)
+				if err != nil {
+					break
+				}
+				if n == 8 && binary.BigEndian.Uint32(buf[0:]) == id && binary.BigEndian.Uint32(buf[4:]) == seq {
+					result.Received++
+					result.RTTs = append(result.RTTs, time.Since(sentAt))
+					break
+				}
+			}
+		}
+		if wait := options.Interval - time.Since(sentAt); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	return result, nil
+}
+
+// Responder is a UDP echo server running within the namespace of a host. It is
+// used as the target of UDP probes.
+type Responder struct {
+	conn net.PacketConn
+}
+
+// NewResponder starts a UDP echo server on the given port within the
+// namespace. The server runs until closed.
+func NewResponder(nsp neslink.NsProvider, udpPort int) (*Responder, error) {
+	r := &Responder{}
+	if err := neslink.Do(
+		nsp,
+		neslink.NAGeneric("open-udp-responder", func() error {
+			c, err := net.ListenPacket("udp", fmt.Sprintf(":%d", udpPort))
+			if err != nil {
+				return err
+			}
+			r.conn = c
+			return nil
+		}),
+	); err != nil {
+		return nil, fmt.Errorf("could not open udp responder: %w", err)
+	}
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, addr, err := r.conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			r.conn.WriteTo(buf[:n], addr)
+		}
+	}()
+	return r, nil
+}
+
+// Close stops the responder.
+func (r *Responder) Close() error {
+	return r.conn.Close()
+}
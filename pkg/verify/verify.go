@@ -0,0 +1,187 @@
+package verify
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ai4networks/net4me/pkg/port"
+	"github.com/ai4networks/net4me/pkg/routing"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ProtocolICMP = "icmp"
+	ProtocolUDP  = "udp"
+)
+
+// Options configures a verification run.
+type Options struct {
+	ProbeOptions
+	// Protocol is the probe protocol, either icmp or udp.
+	Protocol string
+	// UDPPort is the port used by the UDP responders (udp protocol only).
+	UDPPort int
+	// Workers is the number of pairs probed concurrently.
+	Workers int
+	// Weight is used to determine the expected path between hosts.
+	Weight routing.Weight
+	// RTTTolerance is the fraction (e.g. 0.2 for 20%) by which the measured
+	// average RTT may differ from the expected RTT before being flagged.
+	RTTTolerance float64
+	// RTTSlack is the absolute difference allowed between the measured and the
+	// expected RTT, accounting for processing delays on un-emulated paths.
+	RTTSlack time.Duration
+	// LossTolerance is the number of percentage points by which the measured
+	// loss may exceed the expected loss before being flagged.
+	LossTolerance float64
+}
+
+// PairResult is the verification result of probing a single address of the
+// target host from the source host.
+type PairResult struct {
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Address string `json:"address"`
+
+	Reachable bool          `json:"reachable"`
+	Loss      float64       `json:"loss"`
+	RTTMin    time.Duration `json:"rtt_min"`
+	RTTAvg    time.Duration `json:"rtt_avg"`
+	RTTMax    time.Duration `json:"rtt_max"`
+
+	ExpectedReachable bool          `json:"expected_reachable"`
+	ExpectedRTT       time.Duration `json:"expected_rtt"`
+	ExpectedLoss      float64       `json:"expected_loss"`
+
+	Mismatches []string `json:"mismatches,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// expectation derives the expected round trip time and loss along the path.
+// Emulation is applied on egress of each port, so traffic in each direction
+// of a hop is subject to the emulation of the port it leaves from.
+func expectation(path []routing.Hop) (time.Duration, float64) {
+	latency := uint32(0)
+	delivery := float64(1)
+	for _, hop := range path {
+		for _, end := range []struct {
+			host *topology.Host
+			port port.Port
+		}{{hop.From, hop.FromPort}, {hop.To, hop.ToPort}} {
+			em, err := port.PortEmulation(end.host.NetworkNamespace(), end.port)
+			if err != nil || em == nil {
+				continue
+			}
+			latency += em.Latency
+			delivery *= 1 - float64(em.Loss)/100
+		}
+	}
+	return time.Duration(latency) * time.Microsecond, (1 - delivery) * 100
+}
+
+// compare flags any differences between the measured and expected results.
+func (r *PairResult) compare(options Options) {
+	if r.Reachable != r.ExpectedReachable {
+		r.Mismatches = append(r.Mismatches, fmt.Sprintf("reachable=%t, expected %t", r.Reachable, r.ExpectedReachable))
+	}
+	if !r.Reachable || !r.ExpectedReachable {
+		return
+	}
+	allowed := time.Duration(float64(r.ExpectedRTT)*options.RTTTolerance) + options.RTTSlack
+	if diff := r.RTTAvg - r.ExpectedRTT; time.Duration(math.Abs(float64(diff))) > allowed {
+		r.Mismatches = append(r.Mismatches, fmt.Sprintf("rtt=%s, expected %s", r.RTTAvg, r.ExpectedRTT))
+	}
+	if r.Loss > r.ExpectedLoss+options.LossTolerance {
+		r.Mismatches = append(r.Mismatches, fmt.Sprintf("loss=%.1f%%, expected %.1f%%", r.Loss, r.ExpectedLoss))
+	}
+}
+
+// Run probes every address of every host from the network namespace of every
+// other host in the topology. Hosts without addresses (e.g. switches) are
+// neither probed nor used as a source. The measurements are compared against
+// the reachability and emulation expected from the path between the hosts.
+func Run(options Options) ([]*PairResult, error) {
+	hosts := make([]*topology.Host, 0)
+	addresses := make(map[string][]net.IP)
+	for _, h := range topology.Hosts() {
+		cidrs, err := h.Addresses()
+		if err != nil || len(cidrs) == 0 {
+			continue
+		}
+		for _, cidr := range cidrs {
+			ip, _, _ := net.ParseCIDR(cidr)
+			addresses[h.ID()] = append(addresses[h.ID()], ip)
+		}
+		hosts = append(hosts, h)
+	}
+
+	if options.Protocol == ProtocolUDP {
+		for _, h := range hosts {
+			responder, err := NewResponder(h.NetworkNamespace(), options.UDPPort)
+			if err != nil {
+				return nil, fmt.Errorf("could not start responder on host %s: %w", h.Name(), err)
+			}
+			defer responder.Close()
+		}
+	}
+
+	results := make([]*PairResult, 0)
+	for _, source := range hosts {
+		paths := routing.Paths(source, options.Weight)
+		for _, target := range hosts {
+			if source.ID() == target.ID() {
+				continue
+			}
+			path, reachable := paths[target.ID()]
+			rtt, loss := expectation(path)
+			for _, ip := range addresses[target.ID()] {
+				results = append(results, &PairResult{
+					Source:            source.Name(),
+					Target:            target.Name(),
+					Address:           ip.String(),
+					ExpectedReachable: reachable,
+					ExpectedRTT:       rtt,
+					ExpectedLoss:      loss,
+				})
+			}
+		}
+	}
+
+	byName := make(map[string]*topology.Host)
+	for _, h := range hosts {
+		byName[h.Name()] = h
+	}
+	workers := make(chan struct{}, max(options.Workers, 1))
+	wg := &sync.WaitGroup{}
+	for _, r := range results {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(r *PairResult) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			nsp := byName[r.Source].NetworkNamespace()
+			var probe ProbeResult
+			var err error
+			if options.Protocol == ProtocolUDP {
+				probe, err = ProbeUDP(nsp, net.ParseIP(r.Address), options.UDPPort, options.ProbeOptions)
+			} else {
+				probe, err = ProbeICMP(nsp, net.ParseIP(r.Address), options.ProbeOptions)
+			}
+			if err != nil {
+				logrus.WithError(err).WithField("source", r.Source).WithField("address", r.Address).Warnln("verify: probe failed")
+				r.Error = err.Error()
+				return
+			}
+			r.Reachable = probe.Received > 0
+			r.Loss = probe.Loss()
+			r.RTTMin, r.RTTAvg, r.RTTMax = probe.RTT()
+			r.compare(options)
+		}(r)
+	}
+	wg.Wait()
+	return results, nil
+}
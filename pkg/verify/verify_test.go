@@ -0,0 +1,98 @@
+package verify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProbeResult(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name                   string
+		result                 ProbeResult
+		loss                   float64
+		minRTT, avgRTT, maxRTT time.Duration
+	}{
+		{name: "nothing sent", result: ProbeResult{}},
+		{name: "all lost", result: ProbeResult{Sent: 4}, loss: 100},
+		{
+			name:   "some lost",
+			result: ProbeResult{Sent: 4, Received: 3, RTTs: []time.Duration{2 * ms, 1 * ms, 6 * ms}},
+			loss:   25,
+			minRTT: 1 * ms, avgRTT: 3 * ms, maxRTT: 6 * ms,
+		},
+		{
+			name:   "all received",
+			result: ProbeResult{Sent: 2, Received: 2, RTTs: []time.Duration{5 * ms, 5 * ms}},
+			minRTT: 5 * ms, avgRTT: 5 * ms, maxRTT: 5 * ms,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if loss := tt.result.Loss(); loss != tt.loss {
+				t.Fatalf("got loss %.1f, want %.1f", loss, tt.loss)
+			}
+			minRTT, avgRTT, maxRTT := tt.result.RTT()
+			if minRTT != tt.minRTT || avgRTT != tt.avgRTT || maxRTT != tt.maxRTT {
+				t.Fatalf("got rtt %s/%s/%s, want %s/%s/%s", minRTT, avgRTT, maxRTT, tt.minRTT, tt.avgRTT, tt.maxRTT)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	ms := time.Millisecond
+	options := Options{RTTTolerance: 0.2, RTTSlack: 1 * ms, LossTolerance: 5}
+	tests := []struct {
+		name       string
+		result     PairResult
+		mismatches int
+	}{
+		{
+			name:   "as expected",
+			result: PairResult{Reachable: true, ExpectedReachable: true, RTTAvg: 10 * ms, ExpectedRTT: 10 * ms},
+		},
+		{
+			name:       "unexpectedly unreachable",
+			result:     PairResult{ExpectedReachable: true},
+			mismatches: 1,
+		},
+		{
+			name:       "unexpectedly reachable",
+			result:     PairResult{Reachable: true, RTTAvg: 50 * ms},
+			mismatches: 1,
+		},
+		{
+			name:   "rtt within tolerance and slack",
+			result: PairResult{Reachable: true, ExpectedReachable: true, RTTAvg: 12900 * time.Microsecond, ExpectedRTT: 10 * ms},
+		},
+		{
+			name:       "rtt too high",
+			result:     PairResult{Reachable: true, ExpectedReachable: true, RTTAvg: 14 * ms, ExpectedRTT: 10 * ms},
+			mismatches: 1,
+		},
+		{
+			name:       "rtt too low",
+			result:     PairResult{Reachable: true, ExpectedReachable: true, RTTAvg: 5 * ms, ExpectedRTT: 10 * ms},
+			mismatches: 1,
+		},
+		{
+			name:   "loss within tolerance",
+			result: PairResult{Reachable: true, ExpectedReachable: true, Loss: 14, ExpectedLoss: 10},
+		},
+		{
+			name:       "rtt and loss too high",
+			result:     PairResult{Reachable: true, ExpectedReachable: true, RTTAvg: 20 * ms, ExpectedRTT: 10 * ms, Loss: 50, ExpectedLoss: 10},
+			mismatches: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.result
+			r.compare(options)
+			if len(r.Mismatches) != tt.mismatches {
+				t.Fatalf("got mismatches %v, want %d", r.Mismatches, tt.mismatches)
+			}
+		})
+	}
+}
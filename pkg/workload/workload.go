@@ -0,0 +1,158 @@
+package workload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ai4networks/net4me/pkg/node"
+	"github.com/ai4networks/net4me/pkg/topology"
+	"github.com/spf13/viper"
+)
+
+// Workload maps a Docker Compose project onto one or more sites. The compose
+// file path is relative to the manifest the workload is loaded from. The
+// project directory copied to each site defaults to the directory holding the
+// compose file, so relative build contexts, env files and bind mounts within
+// it are preserved.
+type Workload struct {
+	Project   string            `mapstructure:"project" json:"project"`
+	Compose   string            `mapstructure:"compose" json:"compose"`
+	Directory string            `mapstructure:"directory" json:"directory,omitempty"`
+	Site      string            `mapstructure:"site" json:"site,omitempty"`
+	Sites     []string          `mapstructure:"sites" json:"sites,omitempty"`
+	Env       map[string]string `mapstructure:"env" json:"env,omitempty"`
+}
+
+// LoadManifest reads the workloads from a manifest file, in any format
+// supported for the net4me configuration (e.g. toml or yaml). Workloads are
+// listed under the `workload` key.
+func LoadManifest(path string) ([]Workload, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("could not read workload manifest: %w", err)
+	}
+	workloads := make([]Workload, 0)
+	if err := v.UnmarshalKey("workload", &workloads); err != nil {
+		return nil, fmt.Errorf("could not decode workload manifest: %w", err)
+	}
+	base := filepath.Dir(path)
+	for i := range workloads {
+		if !filepath.IsAbs(workloads[i].Compose) {
+			workloads[i].Compose = filepath.Join(base, workloads[i].Compose)
+		}
+		if workloads[i].Directory != "" && !filepath.IsAbs(workloads[i].Directory) {
+			workloads[i].Directory = filepath.Join(base, workloads[i].Directory)
+		}
+	}
+	return workloads, nil
+}
+
+// Targets returns the names of all sites the workload is deployed to.
+func (w Workload) Targets() []string {
+	targets := make([]string, 0, len(w.Sites)+1)
+	if w.Site != "" {
+		targets = append(targets, w.Site)
+	}
+	return append(targets, w.Sites...)
+}
+
+// ProjectName returns the compose project name, defaulting to the name of the
+// project directory normalised as compose does, i.e. lowercased and with any
+// characters other than letters, digits, dashes and underscores removed.
+func (w Workload) ProjectName() string {
+	if w.Project != "" {
+		return w.Project
+	}
+	return normalizeProjectName(filepath.Base(w.directory()))
+}
+
+var invalidProjectChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+func normalizeProjectName(name string) string {
+	name = invalidProjectChars.ReplaceAllString(strings.ToLower(name), "")
+	return strings.TrimLeft(name, "_-")
+}
+
+func (w Workload) directory() string {
+	if w.Directory != "" {
+		return w.Directory
+	}
+	return filepath.Dir(w.Compose)
+}
+
+// Runner returns the workload runner of the named site. An error is returned
+// if the site does not exist or its device does not support workloads.
+func Runner(site string) (node.WorkloadRunner, error) {
+	hosts := topology.Hosts(topology.FilterByName(site))
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("site not found: %s", site)
+	}
+	runner, ok := hosts[0].Node().(node.WorkloadRunner)
+	if !ok {
+		return nil, fmt.Errorf("site %s (%s) does not support workloads", site, hosts[0].Device())
+	}
+	return runner, nil
+}
+
+// Deploy brings the workload up on every one of its target sites, stopping at
+// the first site that fails.
+func Deploy(w Workload) error {
+	dir, err := filepath.Abs(w.directory())
+	if err != nil {
+		return err
+	}
+	compose, err := filepath.Abs(w.Compose)
+	if err != nil {
+		return err
+	}
+	file, err := filepath.Rel(dir, compose)
+	if err != nil || strings.HasPrefix(file, "..") {
+		return fmt.Errorf("compose file %s is not within project directory %s", w.Compose, dir)
+	}
+	targets := w.Targets()
+	if len(targets) == 0 {
+		return fmt.Errorf("workload %s has no target sites", w.ProjectName())
+	}
+	for _, site := range targets {
+		runner, err := Runner(site)
+		if err != nil {
+			return err
+		}
+		if err := runner.DeployWorkload(w.ProjectName(), dir, filepath.ToSlash(file), w.Env); err != nil {
+			return fmt.Errorf("could not deploy %s to site %s: %w", w.ProjectName(), site, err)
+		}
+	}
+	return nil
+}
+
+// Status returns the state of the containers of a project on a site.
+func Status(site, project string) ([]node.WorkloadService, error) {
+	runner, err := Runner(site)
+	if err != nil {
+		return nil, err
+	}
+	return runner.WorkloadStatus(project)
+}
+
+// Logs writes the logs of a project on a site to the writer.
+func Logs(ctx context.Context, site, project string, follow bool, tail string, w io.Writer) error {
+	runner, err := Runner(site)
+	if err != nil {
+		return err
+	}
+	return runner.WorkloadLogs(ctx, project, follow, tail, w)
+}
+
+// Remove tears a project down on a site.
+func Remove(site, project string) error {
+	runner, err := Runner(site)
+	if err != nil {
+		return err
+	}
+	return runner.RemoveWorkload(project)
+}
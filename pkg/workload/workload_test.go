@@ -0,0 +1,25 @@
+package workload
+
+import "testing"
+
+func TestProjectName(t *testing.T) {
+	tests := []struct {
+		name     string
+		workload Workload
+		want     string
+	}{
+		{"explicit", Workload{Project: "web", Compose: "/srv/App/compose.yaml"}, "web"},
+		{"compose directory", Workload{Compose: "/srv/app/compose.yaml"}, "app"},
+		{"lowercased", Workload{Compose: "/srv/MyApp/compose.yaml"}, "myapp"},
+		{"invalid characters", Workload{Compose: "/srv/my app.v2/compose.yaml"}, "myappv2"},
+		{"leading separators", Workload{Compose: "/srv/_-app/compose.yaml"}, "app"},
+		{"directory", Workload{Compose: "/srv/app/compose.yaml", Directory: "/srv/Stack_1"}, "stack_1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.workload.ProjectName(); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}